@@ -4,12 +4,13 @@ import (
 	"bytes"
 	"encoding/gob"
 	"fmt"
+	"math/big"
 	"reflect"
+	"sort"
 
 	sc "github.com/robskie/dynami/schema"
 
 	db "github.com/aws/aws-sdk-go/service/dynamodb"
-	dbattribute "github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
 )
 
 // BatchError represents a batch operation error.
@@ -22,12 +23,30 @@ func (e BatchError) Error() string {
 	return "dynami: an error occurred in one of the items"
 }
 
+// Unwrap returns the errors of every item sorted by
+// table name and then by index. This allows errors.Is
+// and errors.As to match the error of any item.
+func (e BatchError) Unwrap() []error {
+	tables := make([]string, 0, len(e))
+	for table := range e {
+		tables = append(tables, table)
+	}
+	sort.Strings(tables)
+
+	var errs []error
+	for _, table := range tables {
+		errs = append(errs, unwrapErrors(e[table])...)
+	}
+	return errs
+}
+
 // ikey contains the table and the key
 // values for an item. It is used as a
 // map key to get the item's input index.
+// Each key value is encoded by keyString.
 type ikey struct {
 	tableName string
-	keys      []interface{}
+	keys      []string
 }
 
 func (ik *ikey) toStr() string {
@@ -63,16 +82,36 @@ type ekey struct {
 // dynamodb item.
 func getIndexKey(tableName string, keySchema []sc.Key, item dbitem) string {
 	ik := &ikey{tableName: tableName}
-
-	mkey := map[string]interface{}{}
-	dbattribute.UnmarshalMap(item, &mkey)
 	for _, k := range keySchema {
-		ik.keys = append(ik.keys, mkey[k.Name])
+		ik.keys = append(ik.keys, keyString(item[k.Name]))
 	}
 
 	return ik.toStr()
 }
 
+// keyString returns the string representation of a key
+// value. Its type is included so that, eg. the string "1"
+// and the number 1 are different. Numbers are normalized
+// since DynamoDB may return them in a different format,
+// eg. "1.50" is returned as "1.5".
+func keyString(av *db.AttributeValue) string {
+	switch {
+	case av == nil:
+		return ""
+	case av.S != nil:
+		return "S" + *av.S
+	case av.N != nil:
+		if r, ok := new(big.Rat).SetString(*av.N); ok {
+			return "N" + r.RatString()
+		}
+		return "N" + *av.N
+	case av.B != nil:
+		return "B" + string(av.B)
+	}
+
+	return ""
+}
+
 // batchOp represents a batch operation.
 type batchOp struct {
 	// itemIdxs maps an item's
@@ -85,6 +124,11 @@ type batchOp struct {
 
 	schemas map[string][]sc.Key
 
+	// emptyValues returns how empty values of
+	// the items of the given table are written.
+	// If nil, empty values are omitted.
+	emptyValues func(tableName string) EmptyValues
+
 	errs map[ekey]error
 }
 
@@ -131,6 +175,11 @@ func (b *batchOp) addItems(
 	kschema := schema.Key
 	b.schemas[tableName] = kschema
 
+	empty := OmitEmpty
+	if b.emptyValues != nil {
+		empty = b.emptyValues(tableName)
+	}
+
 	dups := map[string][]int{}
 	unpItems := make([]dbitem, v.Len())
 	for i := range unpItems {
@@ -148,13 +197,12 @@ func (b *batchOp) addItems(
 
 		dbitem := k.value
 		if len(keysOnly) == 0 || keysOnly[0] == false {
-			dbitem, err = dbattribute.MarshalMap(item)
+			dbitem, err = marshalWriteItem(item, empty)
 			if err != nil {
-				err = fmt.Errorf("dynami: invalid item (%v)", err)
+				err = fmt.Errorf("dynami: invalid item (%w)", err)
 				b.errs[ekey{tableName, i}] = err
 				continue
 			}
-			dbitem = removeEmptyAttr(dbitem)
 		}
 
 		unpItems[i] = dbitem
@@ -266,14 +314,52 @@ func (b *batchOp) processItems(
 	}
 }
 
-// flushUnproc marks all unprocessed
-// items' indices as erroneous.
+// flushMissing marks the collected items that are neither
+// processed nor unprocessed as erroneous. This is used for
+// items that don't exist in a batch get.
+func (b *batchOp) flushMissing(
+	collected map[string][]dbitem,
+	unprocessed map[string][]dbitem,
+	err error) {
+
+	unproc := map[string]bool{}
+	for table, unprocs := range unprocessed {
+		kschema := b.schemas[table]
+		for _, unp := range unprocs {
+			unproc[getIndexKey(table, kschema, unp)] = true
+		}
+	}
+
+	for table, items := range collected {
+		kschema := b.schemas[table]
+		for _, item := range items {
+			ik := getIndexKey(table, kschema, item)
+			if _, ok := b.unprocIdxs[ik]; !ok || unproc[ik] {
+				continue
+			}
+
+			delete(b.unprocIdxs, ik)
+			for _, idx := range b.itemIdxs[ik] {
+				ek := ekey{table, idx}
+				if _, ok := b.errs[ek]; !ok {
+					b.errs[ek] = err
+				}
+			}
+		}
+	}
+}
+
+// flushUnproc marks all unprocessed items'
+// indices as erroneous. Existing errors
+// are not replaced.
 func (b *batchOp) flushUnproc(err error) {
 	for ik, idxs := range b.unprocIdxs {
 		tableName := ikeyFromStr(ik).tableName
 		for _, idx := range idxs {
 			ek := ekey{tableName, idx}
-			b.errs[ek] = err
+			if _, ok := b.errs[ek]; !ok {
+				b.errs[ek] = err
+			}
 		}
 	}
 }
@@ -297,6 +383,16 @@ func (b *batchOp) errors() error {
 	return berr
 }
 
+// countItems returns the number of items in all tables.
+func countItems(items map[string][]dbitem) int {
+	n := 0
+	for _, titems := range items {
+		n += len(titems)
+	}
+
+	return n
+}
+
 // unwrap converts a dynamodb batch
 // operation response items into dbitems.
 func (b *batchOp) unwrap(items interface{}) map[string][]dbitem {
@@ -4,142 +4,153 @@ import (
 	"errors"
 	"fmt"
 
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/credentials"
-	"github.com/aws/aws-sdk-go/aws/session"
-	db "github.com/aws/aws-sdk-go/service/dynamodb"
-	dbs "github.com/aws/aws-sdk-go/service/dynamodbstreams"
+	dbiface "github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	dbsiface "github.com/aws/aws-sdk-go/service/dynamodbstreams/dynamodbstreamsiface"
 )
 
 var (
 	// ErrNoSuchItem is returned when no item is found for the given key.
 	ErrNoSuchItem = errors.New("dynami: no such item")
+
+	// ErrConditionFailed is returned when the condition
+	// of a conditional write operation is not satisfied.
+	// It also matches conditional check failures of other
+	// requests, eg. those of a batch operation.
+	ErrConditionFailed = errors.New("dynami: condition failed")
+
+	// ErrVersionConflict is returned when writing an item
+	// whose version doesn't match the version of the stored
+	// item. This means that the item has been modified by
+	// another writer since it was last read.
+	ErrVersionConflict = errors.New("dynami: version conflict")
+
+	// ErrUnprocessed is recorded in a BatchError for each
+	// item that is still unprocessed after the batch
+	// operation's retry policy gives up. These items can
+	// be safely resubmitted later.
+	ErrUnprocessed = errors.New("dynami: unprocessed item")
+
+	// ErrTableNotFound matches errors caused by
+	// a table, index or stream that doesn't exist.
+	ErrTableNotFound = errors.New("dynami: table not found")
+
+	// ErrThrottled matches errors caused by exceeding
+	// the provisioned throughput of a table or index,
+	// or the request rate limits of DynamoDB.
+	ErrThrottled = errors.New("dynami: throttled")
+
+	// ErrValidation matches errors caused by requests
+	// rejected by DynamoDB as invalid, eg. a key that
+	// doesn't match the table's key schema.
+	ErrValidation = errors.New("dynami: validation failed")
+
+	// ErrItemTooLarge matches errors caused by
+	// an item exceeding the 400KB size limit.
+	// These errors also match ErrValidation.
+	ErrItemTooLarge = errors.New("dynami: item too large")
 )
 
-// Region defines where DynamoDB services are located.
-type Region struct {
-	Name string
+// Client represents a DynamoDB client.
+type Client struct {
+	db  dbiface.DynamoDBAPI
+	dbs dbsiface.DynamoDBStreamsAPI
+
+	// batchRetry is the default retry
+	// policy of batch operations.
+	batchRetry RetryPolicy
 
-	// DynamoDB and DynamoDB Streams endpoint
-	// URLs (hostname only or fully qualified URI)
-	DynamoDBEndpoint        string
-	DynamoDBStreamsEndpoint string
+	// chain contains the middleware
+	// added with Use.
+	chain *chain
+
+	// emptyValues is how empty values are written
+	// to tables not in tableEmptyValues.
+	emptyValues      EmptyValues
+	tableEmptyValues map[string]EmptyValues
 }
 
-// These are the list of all supported AWS regions.
-var (
-	USEast1 = &Region{
-		"us-east-1",
-		"dynamodb.us-east-1.amazonaws.com",
-		"streams.dynamodb.us-east-1.amazonaws.com",
+// NewClient creates a new client from the given credentials.
+// This panics if the client can't be created. To configure the
+// client further or to handle errors, use NewClientWithOptions.
+func NewClient(region *Region, accessKeyID string, secretAccessKey string) *Client {
+	c, err := NewClientWithOptions(
+		WithRegion(region),
+		WithStaticCredentials(accessKeyID, secretAccessKey, ""),
+	)
+	if err != nil {
+		panic(err)
 	}
 
-	USWest1 = &Region{
-		"us-west-1",
-		"dynamodb.us-west-1.amazonaws.com",
-		"streams.dynamodb.us-west-1.amazonaws.com",
-	}
+	return c
+}
 
-	USWest2 = &Region{
-		"us-west-2",
-		"dynamodb.us-west-2.amazonaws.com",
-		"streams.dynamodb.us-west-2.amazonaws.com",
+// NewClientFromAPI creates a new client that sends its requests
+// to the given DynamoDB and DynamoDB Streams APIs. This can be
+// used to wrap the SDK clients or to replace them with fakes in
+// tests. streamsAPI may be nil if streams are not used. Options
+// that configure the SDK clients, eg. WithRegion, are ignored.
+func NewClientFromAPI(
+	dbAPI dbiface.DynamoDBAPI,
+	streamsAPI dbsiface.DynamoDBStreamsAPI,
+	opts ...Option) (*Client, error) {
+
+	if dbAPI == nil {
+		return nil, fmt.Errorf("dynami: nil DynamoDB API")
 	}
 
-	EUWest1 = &Region{
-		"eu-west-1",
-		"dynamodb.eu-west-1.amazonaws.com",
-		"streams.dynamodb.eu-west-1.amazonaws.com",
+	o, err := newClientOptions(opts)
+	if err != nil {
+		return nil, err
 	}
 
-	EUCentral1 = &Region{
-		"eu-central-1",
-		"dynamodb.eu-central-1.amazonaws.com",
-		"streams.dynamodb.eu-central-1.amazonaws.com",
-	}
+	return newClient(dbAPI, streamsAPI, o), nil
+}
 
-	APNortheast1 = &Region{
-		"ap-northeast-1",
-		"dynamodb.ap-northeast-1.amazonaws.com",
-		"streams.dynamodb.ap-northeast-1.amazonaws.com",
-	}
+func newClient(
+	dbAPI dbiface.DynamoDBAPI,
+	streamsAPI dbsiface.DynamoDBStreamsAPI,
+	o *clientOptions) *Client {
 
-	APNortheast2 = &Region{
-		"ap-northeast-2",
-		"dynamodb.ap-northeast-2.amazonaws.com",
-		"streams.dynamodb.ap-northeast-2.amazonaws.com",
+	ch := &chain{}
+	if o.metrics != nil {
+		ch.add(o.metrics.Middleware())
 	}
-
-	APSoutheast1 = &Region{
-		"ap-southeast-1",
-		"dynamodb.ap-southeast-1.amazonaws.com",
-		"streams.dynamodb.ap-southeast-1.amazonaws.com",
+	dbAPI = &middlewareDB{DynamoDBAPI: dbAPI, ch: ch}
+	if streamsAPI != nil {
+		streamsAPI = &middlewareStreams{DynamoDBStreamsAPI: streamsAPI, ch: ch}
 	}
-
-	APSoutheast2 = &Region{
-		"ap-southeast-2",
-		"dynamodb.ap-southeast-2.amazonaws.com",
-		"streams.dynamodb.ap-southeast-2.amazonaws.com",
+	if len(o.rateLimits) > 0 {
+		dbAPI = &limitedDB{
+			DynamoDBAPI: dbAPI,
+			l:           newLimiter(o.rateLimits),
+		}
 	}
-
-	SAEast1 = &Region{
-		"sa-east-1",
-		"dynamodb.sa-east-1.amazonaws.com",
-		"streams.dynamodb.sa-east-1.amazonaws.com",
+	if o.collector != nil {
+		dbAPI = &collectedDB{DynamoDBAPI: dbAPI, c: o.collector}
 	}
-)
 
-var regions = map[string]*Region{
-	"us-east-1":      USEast1,
-	"us-west-1":      USWest1,
-	"us-west-2":      USWest2,
-	"eu-west-1":      EUWest1,
-	"eu-central-1":   EUCentral1,
-	"ap-northeast-1": APNortheast1,
-	"ap-northeast-2": APNortheast2,
-	"ap-southeast-1": APSoutheast1,
-	"ap-southeast-2": APSoutheast2,
-	"sa-east-1":      SAEast1,
-}
+	c := &Client{
+		db:         dbAPI,
+		dbs:        streamsAPI,
+		batchRetry: DefaultRetryPolicy,
+		chain:      ch,
 
-// GetRegion returns a new Region object
-// given a valid AWS region, eg. "us-east-1".
-func GetRegion(name string) *Region {
-	reg, ok := regions[name]
-	if !ok {
-		panic(fmt.Errorf("dynami: unknown region (%v)", name))
+		emptyValues:      o.emptyValues,
+		tableEmptyValues: o.tableEmptyValues,
+	}
+	if o.batchRetry != nil {
+		c.batchRetry = *o.batchRetry
 	}
 
-	return reg
+	return c
 }
 
-// Client represents a DynamoDB client.
-type Client struct {
-	db  *db.DynamoDB
-	dbs *dbs.DynamoDBStreams
-}
-
-// NewClient creates a new client from the given credentials.
-func NewClient(region *Region, accessKeyID string, secretAccessKey string) *Client {
-	dbSession, err := session.NewSession(&aws.Config{
-		Region:      aws.String(region.Name),
-		Endpoint:    aws.String(region.DynamoDBEndpoint),
-		Credentials: credentials.NewStaticCredentials(accessKeyID, secretAccessKey, ""),
-	})
-	if err != nil {
-		panic(fmt.Errorf("dynami: cannot create new client (%v)", err))
-	}
-	db := db.New(dbSession)
-
-	dbsSession, err := session.NewSession(&aws.Config{
-		Region:      aws.String(region.Name),
-		Endpoint:    aws.String(region.DynamoDBStreamsEndpoint),
-		Credentials: credentials.NewStaticCredentials(accessKeyID, secretAccessKey, ""),
-	})
-	if err != nil {
-		panic(fmt.Errorf("dynami: cannot create new client (%v)", err))
+// emptyValuesOf returns how empty values
+// are written to the given table.
+func (c *Client) emptyValuesOf(tableName string) EmptyValues {
+	if mode, ok := c.tableEmptyValues[tableName]; ok {
+		return mode
 	}
-	dbs := dbs.New(dbsSession)
 
-	return &Client{db: db, dbs: dbs}
+	return c.emptyValues
 }
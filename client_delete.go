@@ -1,43 +1,142 @@
 package dynami
 
 import (
+	"context"
 	"fmt"
 	"reflect"
 
 	"github.com/aws/aws-sdk-go/aws"
 	db "github.com/aws/aws-sdk-go/service/dynamodb"
+	dbiface "github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
 )
 
 // DeleteItem removes an item from a table. item must
 // be a map[string]interface{}, struct, or a pointer to
-// any of the two with nonempty primary key.
+// any of the two with nonempty primary key. If item
+// has a nonzero version field, the item is only deleted
+// if its version matches the stored version. Otherwise,
+// ErrVersionConflict is returned.
 func (c *Client) DeleteItem(tableName string, item interface{}) error {
-	err := checkType(item, reflect.Struct, map[string]interface{}{})
+	return c.DeleteItemWithContext(aws.BackgroundContext(), tableName, item)
+}
+
+// DeleteItemWithContext is the same as DeleteItem
+// with the addition of a request context.
+func (c *Client) DeleteItemWithContext(
+	ctx context.Context,
+	tableName string,
+	item interface{}) error {
+
+	return c.deleteItem(ctx, tableName, item, nil)
+}
+
+// DeleteItemIf removes an item from a table only if the
+// condition expression expr evaluates to true for the stored
+// item. This returns ErrConditionFailed if the condition is
+// not satisfied. See PutItemIf for more details.
+func (c *Client) DeleteItemIf(
+	tableName string,
+	item interface{},
+	expr string,
+	values ...interface{}) error {
+
+	return c.DeleteItemIfWithContext(aws.BackgroundContext(), tableName, item, expr, values...)
+}
+
+// DeleteItemIfWithContext is the same as DeleteItemIf
+// with the addition of a request context.
+func (c *Client) DeleteItemIfWithContext(
+	ctx context.Context,
+	tableName string,
+	item interface{},
+	expr string,
+	values ...interface{}) error {
+
+	cond, err := parseCondition(expr, values)
 	if err != nil {
 		return err
 	}
 
-	key, err := getKey(item)
+	return c.deleteItem(ctx, tableName, item, cond)
+}
+
+func (c *Client) deleteItem(
+	ctx context.Context,
+	tableName string,
+	item interface{},
+	cond *condition) error {
+
+	input, version, err := newDeleteItemInput(tableName, item, cond)
 	if err != nil {
 		return err
 	}
 
 	cdb := c.db
-	_, err = cdb.DeleteItem(&db.DeleteItemInput{
+	_, err = cdb.DeleteItemWithContext(ctx, input)
+	if awsErrCode(err) == db.ErrCodeConditionalCheckFailedException {
+		return conditionFailed(cond, version)
+	} else if err != nil {
+		return newError("cannot delete item", err)
+	}
+
+	return nil
+}
+
+// newDeleteItemInput creates the delete request for the given item.
+// The returned version is nil if the item's version is not checked.
+func newDeleteItemInput(
+	tableName string,
+	item interface{},
+	cond *condition) (*db.DeleteItemInput, *itemVersion, error) {
+
+	err := checkType(item, reflect.Struct, map[string]interface{}{})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	key, err := getKey(item)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Only check nonzero versions since
+	// zero means that the version is unknown
+	var version *itemVersion
+	var vcond *condition
+	if vname, vfield := getVersion(item); vname != "" {
+		if value := versionValue(vfield); value != 0 {
+			vcond = versionCondition(vname, value)
+			version = &itemVersion{
+				cond:  vcond,
+				field: vfield,
+				value: value,
+			}
+		}
+	}
+
+	input := &db.DeleteItemInput{
 		Key:       key.value,
 		TableName: aws.String(tableName),
-	})
+	}
+
+	mcond, err := mergeConditions(cond, vcond)
 	if err != nil {
-		return fmt.Errorf("dynami: cannot delete item (%v)", err)
+		return nil, nil, err
+	} else if mcond != nil {
+		input.ConditionExpression = aws.String(mcond.expr)
+		input.ExpressionAttributeNames = mcond.attributeNames
+		input.ExpressionAttributeValues = mcond.attributeValues
 	}
 
-	return nil
+	return input, version, nil
 }
 
 // BatchDelete can delete multiple items from one or more tables.
 type BatchDelete struct {
-	db *db.DynamoDB
-	op *batchOp
+	db    dbiface.DynamoDBAPI
+	op    *batchOp
+	retry RetryPolicy
+	stats Stats
 
 	err    error
 	tables map[string]bool
@@ -49,6 +148,7 @@ func (c *Client) BatchDelete(tableName string, items interface{}) *BatchDelete {
 	b := &BatchDelete{
 		db:     c.db,
 		op:     newBatchOp(),
+		retry:  c.batchRetry,
 		tables: map[string]bool{},
 	}
 	b.tables[tableName] = true
@@ -83,9 +183,35 @@ func (b *BatchDelete) Delete(tableName string, items interface{}) *BatchDelete {
 	return b
 }
 
+// Retry sets the retry policy for the unprocessed items of
+// this batch. This overrides the default policy of the client.
+func (b *BatchDelete) Retry(policy RetryPolicy) *BatchDelete {
+	if b.err != nil {
+		return b
+	} else if err := policy.validate(); err != nil {
+		b.err = err
+		return b
+	}
+
+	b.retry = policy
+	return b
+}
+
+// Stats returns the consumed capacity of every
+// request sent by Run.
+func (b *BatchDelete) Stats() Stats {
+	return b.stats.clone()
+}
+
 // Run executes all delete operations in
 // this batch. This may return a BatchError.
 func (b *BatchDelete) Run() error {
+	return b.RunWithContext(aws.BackgroundContext())
+}
+
+// RunWithContext is the same as Run with
+// the addition of a request context.
+func (b *BatchDelete) RunWithContext(ctx context.Context) error {
 	const maxDelsPerOp = 25
 
 	if b.err != nil {
@@ -93,6 +219,11 @@ func (b *BatchDelete) Run() error {
 	}
 
 	op := b.op
+	bo := &backoff{policy: b.retry}
+
+	// attempt counts the requests in a row that retry
+	// unprocessed items, regardless of the backoff.
+	attempt := 0
 	citems := map[string][]dbitem{}
 	for !op.isEmpty() || len(citems) > 0 {
 		citems = op.collectItems(maxDelsPerOp, citems)
@@ -111,17 +242,39 @@ func (b *BatchDelete) Run() error {
 
 		// Delete items from database
 		input := &db.BatchWriteItemInput{
-			RequestItems: reqItems,
+			RequestItems:           reqItems,
+			ReturnConsumedCapacity: aws.String(db.ReturnConsumedCapacityIndexes),
 		}
-		resp, err := b.db.BatchWriteItem(input)
+		resp, err := b.db.BatchWriteItemWithContext(withAttempt(ctx, attempt), input)
 
 		if err != nil {
-			return fmt.Errorf("dynami: BatchDelete failed (%v)", err)
+			return newError("BatchDelete failed", err)
 		}
 
+		b.stats.addCapacity(resp.ConsumedCapacity, true)
+
 		unproc := op.unwrap(resp.UnprocessedItems)
 		op.processItems(citems, unproc, nil)
+
+		progressed := countItems(unproc) < countItems(citems)
 		citems = unproc
+		if len(unproc) == 0 {
+			attempt = 0
+			bo.reset()
+			continue
+		} else if progressed {
+			bo.progress()
+		}
+		attempt++
+
+		// Wait before retrying unprocessed items
+		ok, err := bo.wait(ctx)
+		if err != nil {
+			return fmt.Errorf("dynami: BatchDelete failed (%w)", err)
+		} else if !ok {
+			op.flushUnproc(ErrUnprocessed)
+			return op.errors()
+		}
 	}
 
 	return op.errors()
@@ -252,3 +252,27 @@ func (suite *DatabaseTestSuite) TestBatchDeleteMultiTable() {
 
 	fmt.Println("\rTest finished. Cleaning up...")
 }
+
+func (suite *DatabaseTestSuite) TestDeleteIf() {
+	assert := suite.Assert()
+	require := suite.Require()
+
+	book := tBook{
+		Title:  "Brave New World",
+		Author: "Aldous Huxley",
+		Genre:  "Science Fiction",
+	}
+
+	c := suite.client
+	err := c.PutItem("Book", book)
+	require.Nil(err)
+
+	err = c.DeleteItemIf("Book", book, "Genre = :genre", "Fiction")
+	assert.Equal(ErrConditionFailed, err)
+
+	err = c.DeleteItemIf("Book", book, "Genre = :genre", "Science Fiction")
+	require.Nil(err)
+
+	err = c.GetItem("Book", &book, true)
+	assert.Equal(ErrNoSuchItem, err)
+}
@@ -1,12 +1,13 @@
 package dynami
 
 import (
+	"context"
 	"fmt"
 	"reflect"
 
 	"github.com/aws/aws-sdk-go/aws"
 	db "github.com/aws/aws-sdk-go/service/dynamodb"
-	dbattribute "github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	dbiface "github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
 )
 
 // GetItem fetches an item from the database. item must
@@ -19,6 +20,58 @@ func (c *Client) GetItem(
 	tableName string,
 	item interface{}, consistent ...bool) error {
 
+	return c.GetItemWithContext(aws.BackgroundContext(), tableName, item, consistent...)
+}
+
+// GetItemWithContext is the same as GetItem
+// with the addition of a request context.
+func (c *Client) GetItemWithContext(
+	ctx context.Context,
+	tableName string,
+	item interface{}, consistent ...bool) error {
+
+	return c.getItem(ctx, tableName, item, nil, consistent)
+}
+
+// GetItemSelect is the same as GetItem except that only the
+// attributes in attrs are fetched. Nested attributes can be
+// selected using a dot separator, eg. "Info.Publisher". If attrs
+// is empty, the attributes are derived from the fields of item.
+// The key attributes used to fetch item are always fetched.
+func (c *Client) GetItemSelect(
+	tableName string,
+	item interface{},
+	attrs []string, consistent ...bool) error {
+
+	return c.GetItemSelectWithContext(aws.BackgroundContext(), tableName, item, attrs, consistent...)
+}
+
+// GetItemSelectWithContext is the same as
+// GetItemSelect with the addition of a request context.
+func (c *Client) GetItemSelectWithContext(
+	ctx context.Context,
+	tableName string,
+	item interface{},
+	attrs []string, consistent ...bool) error {
+
+	if len(attrs) == 0 {
+		attrs = Attributes(item)
+	}
+
+	if len(attrs) == 0 {
+		return fmt.Errorf("dynami: empty projection")
+	}
+
+	return c.getItem(ctx, tableName, item, attrs, consistent)
+}
+
+func (c *Client) getItem(
+	ctx context.Context,
+	tableName string,
+	item interface{},
+	attrs []string,
+	consistent []bool) error {
+
 	err := checkPtrType(item, reflect.Struct, map[string]interface{}{})
 	if err != nil {
 		return err
@@ -29,6 +82,14 @@ func (c *Client) GetItem(
 		return err
 	}
 
+	var proj *projectionExpr
+	if len(attrs) > 0 {
+		proj, err = parseProjection(withKey(attrs, key.value))
+		if err != nil {
+			return err
+		}
+	}
+
 	var consistentRead *bool
 	if len(consistent) > 0 && key.indexType != globalIndexType {
 		consistentRead = aws.Bool(consistent[0])
@@ -37,22 +98,28 @@ func (c *Client) GetItem(
 	// Fetch using primary key
 	cdb := c.db
 	if key.indexName == "" {
-		resp, err := cdb.GetItem(&db.GetItemInput{
+		input := &db.GetItemInput{
 			Key:            key.value,
 			TableName:      aws.String(tableName),
 			ConsistentRead: consistentRead,
-		})
+		}
+		if proj != nil {
+			input.ProjectionExpression = aws.String(proj.expr)
+			input.ExpressionAttributeNames = proj.attributeNames
+		}
+
+		resp, err := cdb.GetItemWithContext(ctx, input)
 
 		if err != nil {
-			return fmt.Errorf("dynami: cannot get item (%v)", err)
+			return newError("cannot get item", err)
 		}
 		if len(resp.Item) == 0 {
 			return ErrNoSuchItem
 		}
 
-		err = dbattribute.UnmarshalMap(resp.Item, item)
+		err = unmarshalItem(resp.Item, item)
 		if err != nil {
-			return fmt.Errorf("dynami: cannot get item (%v)", err)
+			return fmt.Errorf("dynami: cannot get item (%w)", err)
 		}
 
 		return nil
@@ -87,18 +154,24 @@ func (c *Client) GetItem(
 		ConsistentRead:            consistentRead,
 		Limit:                     aws.Int64(1),
 	}
+	if proj != nil {
+		queryInput.ProjectionExpression = aws.String(proj.expr)
+		for ph, name := range proj.attributeNames {
+			attributeNames[ph] = name
+		}
+	}
 
-	resp, err := cdb.Query(queryInput)
+	resp, err := cdb.QueryWithContext(ctx, queryInput)
 	if err != nil {
-		return fmt.Errorf("dynami: cannot get item (%v)", err)
+		return newError("cannot get item", err)
 	}
 	if len(resp.Items) == 0 {
 		return ErrNoSuchItem
 	}
 
-	err = dbattribute.UnmarshalMap(resp.Items[0], item)
+	err = unmarshalItem(resp.Items[0], item)
 	if err != nil {
-		return fmt.Errorf("dynami: invalid item (%v)", err)
+		return fmt.Errorf("dynami: invalid item (%w)", err)
 	}
 
 	return nil
@@ -108,12 +181,15 @@ func (c *Client) GetItem(
 // It allows fetching of multiple items from
 // multiple tables.
 type BatchGet struct {
-	db *db.DynamoDB
-	op *batchOp
-
-	err        error
-	items      map[string]reflect.Value
-	consistent map[string]bool
+	db    dbiface.DynamoDBAPI
+	op    *batchOp
+	retry RetryPolicy
+	stats Stats
+
+	err         error
+	items       map[string]reflect.Value
+	consistent  map[string]bool
+	projections map[string]*projectionExpr
 }
 
 // BatchGet queues a batch get operation. items must
@@ -124,10 +200,12 @@ func (c *Client) BatchGet(
 	consistent ...bool) *BatchGet {
 
 	b := &BatchGet{
-		db:         c.db,
-		op:         newBatchOp(),
-		items:      map[string]reflect.Value{},
-		consistent: map[string]bool{},
+		db:          c.db,
+		op:          newBatchOp(),
+		retry:       c.batchRetry,
+		items:       map[string]reflect.Value{},
+		consistent:  map[string]bool{},
+		projections: map[string]*projectionExpr{},
 	}
 
 	if err := checkSliceType(items, reflect.Interface, reflect.Struct, map[string]interface{}{}); err != nil {
@@ -172,9 +250,77 @@ func (b *BatchGet) Get(
 	return b
 }
 
+// Select fetches only the attributes in attrs for the items
+// in the given table. It must be called after the table's items
+// are added. If attrs is empty, the attributes are derived from
+// the fields of the table's items. Primary key attributes are
+// always fetched.
+func (b *BatchGet) Select(tableName string, attrs ...string) *BatchGet {
+	if b.err != nil {
+		return b
+	}
+
+	items, ok := b.items[tableName]
+	if !ok {
+		b.err = fmt.Errorf("dynami: no BatchGet operation for table (%v)", tableName)
+		return b
+	}
+
+	if len(attrs) == 0 {
+		attrs = Attributes(reflect.Zero(items.Type().Elem()).Interface())
+		if len(attrs) == 0 {
+			b.err = fmt.Errorf("dynami: empty projection")
+			return b
+		}
+	}
+
+	// Key attributes are needed
+	// to match the fetched items
+	attrs = append([]string{}, attrs...)
+	for _, k := range b.op.schemas[tableName] {
+		attrs = append(attrs, k.Name)
+	}
+
+	proj, err := parseProjection(attrs)
+	if err != nil {
+		b.err = err
+		return b
+	}
+
+	b.projections[tableName] = proj
+	return b
+}
+
+// Retry sets the retry policy for the unprocessed items of
+// this batch. This overrides the default policy of the client.
+func (b *BatchGet) Retry(policy RetryPolicy) *BatchGet {
+	if b.err != nil {
+		return b
+	} else if err := policy.validate(); err != nil {
+		b.err = err
+		return b
+	}
+
+	b.retry = policy
+	return b
+}
+
+// Stats returns the consumed capacity of every
+// request sent by Run. Count is the number of
+// fetched items.
+func (b *BatchGet) Stats() Stats {
+	return b.stats.clone()
+}
+
 // Run fetches all the items in this
 // batch. This may return a BatchError.
 func (b *BatchGet) Run() error {
+	return b.RunWithContext(aws.BackgroundContext())
+}
+
+// RunWithContext is the same as Run with
+// the addition of a request context.
+func (b *BatchGet) RunWithContext(ctx context.Context) error {
 	const maxGetsPerOp = 100
 
 	if b.err != nil {
@@ -182,6 +328,11 @@ func (b *BatchGet) Run() error {
 	}
 
 	op := b.op
+	bo := &backoff{policy: b.retry}
+
+	// attempt counts the requests in a row that retry
+	// unprocessed items, regardless of the backoff.
+	attempt := 0
 	citems := map[string][]dbitem{}
 	for !op.isEmpty() || len(citems) > 0 {
 		citems = op.collectItems(maxGetsPerOp, citems)
@@ -198,20 +349,27 @@ func (b *BatchGet) Run() error {
 				Keys:           keys,
 				ConsistentRead: aws.Bool(b.consistent[table]),
 			}
+			if proj := b.projections[table]; proj != nil {
+				reqItems[table].ProjectionExpression = aws.String(proj.expr)
+				reqItems[table].ExpressionAttributeNames = proj.attributeNames
+			}
 		}
 
 		// Get items from database
 		input := &db.BatchGetItemInput{
-			RequestItems: reqItems,
+			RequestItems:           reqItems,
+			ReturnConsumedCapacity: aws.String(db.ReturnConsumedCapacityIndexes),
 		}
-		resp, err := b.db.BatchGetItem(input)
+		resp, err := b.db.BatchGetItemWithContext(withAttempt(ctx, attempt), input)
 
 		if err != nil {
-			return fmt.Errorf("dynami: BatchGet failed (%v)", err)
+			return newError("BatchGet failed", err)
 		}
 
 		proc := op.unwrap(resp.Responses)
 		unproc := op.unwrap(resp.UnprocessedKeys)
+		b.stats.addCapacity(resp.ConsumedCapacity, false)
+		b.stats.Count += countItems(proc)
 
 		op.processItems(
 			proc,
@@ -223,12 +381,31 @@ func (b *BatchGet) Run() error {
 					vitem = vitem.Addr()
 				}
 
-				err := dbattribute.UnmarshalMap(item, vitem.Interface())
+				err := unmarshalItem(item, vitem.Interface())
 				return err
 			})
+		op.flushMissing(citems, unproc, ErrNoSuchItem)
+
+		progressed := countItems(unproc) < countItems(citems)
 		citems = unproc
+		if len(unproc) == 0 {
+			attempt = 0
+			bo.reset()
+			continue
+		} else if progressed {
+			bo.progress()
+		}
+		attempt++
+
+		// Wait before retrying unprocessed items
+		ok, err := bo.wait(ctx)
+		if err != nil {
+			return fmt.Errorf("dynami: BatchGet failed (%w)", err)
+		} else if !ok {
+			op.flushUnproc(ErrUnprocessed)
+			return op.errors()
+		}
 	}
 
-	op.flushUnproc(ErrNoSuchItem)
 	return op.errors()
 }
@@ -150,6 +150,48 @@ func (suite *DatabaseTestSuite) TestBatchGet() {
 	assert.Equal(ErrNoSuchItem, berr)
 }
 
+func (suite *DatabaseTestSuite) TestBatchGetRetry() {
+	assert := suite.Assert()
+	require := suite.Require()
+
+	book := tBook{
+		Title:  "Solaris",
+		Author: "Stanislaw Lem",
+		Genre:  "Science Fiction",
+	}
+	err := suite.client.PutItem("Book", book)
+	require.Nil(err)
+
+	fetched := []tBook{
+		{Title: book.Title, Author: book.Author},
+		{Title: randString(20), Author: randString(15)},
+	}
+
+	policy := RetryPolicy{MaxAttempts: 2}
+	fake := &tThrottledDB{DynamoDBAPI: suite.db, throttles: 2}
+	c, err := NewClientFromAPI(fake, nil, WithBatchRetry(policy))
+	require.Nil(err)
+
+	err = c.BatchGet("Book", fetched, true).Run()
+	require.NotNil(err)
+	assert.Equal(book, fetched[0])
+
+	batchErr, ok := err.(BatchError)
+	require.True(ok)
+	require.Len(batchErr["Book"], 1)
+	assert.Equal(ErrNoSuchItem, batchErr["Book"][1])
+
+	// Unprocessed keys are not reported as missing
+	fake.throttles = -1
+	err = c.BatchGet("Book", fetched, true).Run()
+	require.NotNil(err)
+
+	batchErr, ok = err.(BatchError)
+	require.True(ok)
+	assert.Equal(ErrUnprocessed, batchErr["Book"][0])
+	assert.Equal(ErrUnprocessed, batchErr["Book"][1])
+}
+
 func (suite *DatabaseTestSuite) TestBatchGetMap() {
 	assert := suite.Assert()
 	require := suite.Require()
@@ -345,3 +387,118 @@ func (suite *DatabaseTestSuite) TestBatchGetBig() {
 
 	fmt.Println("\rTest finished. Cleaning up...")
 }
+
+func (suite *DatabaseTestSuite) TestGetSelect() {
+	assert := suite.Assert()
+	require := suite.Require()
+
+	book := tBook{
+		Title:  "Brave New World",
+		Author: "Aldous Huxley",
+		Genre:  "Dystopian",
+		Info: tInfo{
+			Publisher:     "Chatto & Windus",
+			DatePublished: 1932,
+		},
+	}
+
+	c := suite.client
+	err := c.PutItem("Book", book)
+	require.Nil(err)
+
+	// Select top level and nested attributes
+	fetched := tBook{
+		Title:  book.Title,
+		Author: book.Author,
+	}
+	err = c.GetItemSelect("Book", &fetched, []string{"Genre", "Info.Publisher"})
+	require.Nil(err)
+	assert.Equal(tBook{
+		Title:  book.Title,
+		Author: book.Author,
+		Genre:  book.Genre,
+		Info:   tInfo{Publisher: book.Info.Publisher},
+	}, fetched)
+
+	// Select by secondary key
+	fetched = tBook{
+		Genre: book.Genre,
+		Title: book.Title,
+	}
+	err = c.GetItemSelect("Book", &fetched, []string{"Author"})
+	require.Nil(err)
+	assert.Equal(tBook{
+		Title:  book.Title,
+		Author: book.Author,
+		Genre:  book.Genre,
+	}, fetched)
+
+	// Derive attributes from item
+	fetched = tBook{
+		Title:  book.Title,
+		Author: book.Author,
+	}
+	err = c.GetItemSelect("Book", &fetched, nil)
+	require.Nil(err)
+	assert.Equal(book, fetched)
+
+	// Invalid attribute
+	err = c.GetItemSelect("Book", &fetched, []string{"Info..Publisher"})
+	assert.NotNil(err)
+
+	// Map items can't derive attributes
+	mitem := map[string]interface{}{
+		"Title":  book.Title,
+		"Author": book.Author,
+	}
+	err = c.GetItemSelect("Book", &mitem, nil)
+	assert.NotNil(err)
+}
+
+func (suite *DatabaseTestSuite) TestBatchGetSelect() {
+	assert := suite.Assert()
+	require := suite.Require()
+
+	origBooks := []tBook{
+		{
+			Title:  "Nineteen Eighty-Four",
+			Author: "George Orwell",
+			Genre:  "Dystopian",
+			Info:   tInfo{Publisher: "Secker & Warburg"},
+		},
+		{
+			Title:  "Animal Farm",
+			Author: "George Orwell",
+			Genre:  "Satire",
+			Info:   tInfo{Publisher: "Secker & Warburg"},
+		},
+	}
+
+	c := suite.client
+	err := c.BatchPut("Book", origBooks).Run()
+	require.Nil(err)
+
+	fetchedBooks := make([]tBook, len(origBooks))
+	for i, b := range origBooks {
+		fetchedBooks[i] = tBook{
+			Title:  b.Title,
+			Author: b.Author,
+		}
+	}
+
+	err = c.BatchGet("Book", fetchedBooks).
+		Select("Book", "Genre").
+		Run()
+	require.Nil(err)
+
+	for i, b := range fetchedBooks {
+		assert.Equal(origBooks[i].Genre, b.Genre)
+		assert.Empty(b.Info.Publisher)
+	}
+
+	// Unknown table
+	err = c.BatchGet("Book", fetchedBooks).
+		Select("Quote", "Topic").
+		Run()
+	assert.NotNil(err)
+}
@@ -1,49 +1,192 @@
 package dynami
 
 import (
+	"context"
 	"fmt"
 	"reflect"
+	"strconv"
 
 	"github.com/aws/aws-sdk-go/aws"
 	db "github.com/aws/aws-sdk-go/service/dynamodb"
-	dbattribute "github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	dbiface "github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
 )
 
+// EmptyValues specifies how empty strings and null
+// values, eg. nil pointers, of an item are written.
+// Empty sets are never stored and empty key and index
+// key attributes are always omitted since DynamoDB
+// rejects them.
+type EmptyValues int
+
+// These are the valid empty value modes.
+const (
+	// OmitEmpty omits empty strings and null values.
+	// Empty strings in lists are stored as nulls so
+	// that the positions of the other elements are
+	// kept. This is the default.
+	OmitEmpty EmptyValues = iota
+
+	// KeepEmptyStrings stores empty strings but omits
+	// null values. An empty string and a nil pointer
+	// are then fetched as an empty string and nil,
+	// respectively.
+	KeepEmptyStrings
+
+	// KeepNulls stores both empty strings
+	// and null values as is.
+	KeepNulls
+)
+
+func (e EmptyValues) validate() error {
+	if e < OmitEmpty || e > KeepNulls {
+		return fmt.Errorf("dynami: invalid empty values (%v)", int(e))
+	}
+
+	return nil
+}
+
 // PutItem adds an item to the database. item must be a
 // map[string]interface{}, struct, or a pointer to any
 // of those with nonempty primary key.
+//
+// If item is a struct with a field tagged with dbversion,
+// the item is only added if its version matches the version
+// of the stored item, or if there's no stored item when the
+// version is zero. Otherwise, ErrVersionConflict is returned.
+// The stored version is incremented on every put. If item is
+// a pointer, its version field is updated as well.
 func (c *Client) PutItem(tableName string, item interface{}) error {
-	err := checkType(item, reflect.Struct, map[string]interface{}{})
+	return c.PutItemWithContext(aws.BackgroundContext(), tableName, item)
+}
+
+// PutItemWithContext is the same as PutItem
+// with the addition of a request context.
+func (c *Client) PutItemWithContext(
+	ctx context.Context,
+	tableName string,
+	item interface{}) error {
+
+	return c.putItem(ctx, tableName, item, nil)
+}
+
+// PutItemIf adds an item to the database only if the condition
+// expression expr evaluates to true. expr has the same syntax as
+// the one in Query.Filter and is evaluated against the existing
+// item. For example, "attribute_not_exists(Key)" only adds the
+// item if it isn't present yet. This returns ErrConditionFailed
+// if the condition is not satisfied.
+func (c *Client) PutItemIf(
+	tableName string,
+	item interface{},
+	expr string,
+	values ...interface{}) error {
+
+	return c.PutItemIfWithContext(aws.BackgroundContext(), tableName, item, expr, values...)
+}
+
+// PutItemIfWithContext is the same as PutItemIf
+// with the addition of a request context.
+func (c *Client) PutItemIfWithContext(
+	ctx context.Context,
+	tableName string,
+	item interface{},
+	expr string,
+	values ...interface{}) error {
+
+	cond, err := parseCondition(expr, values)
 	if err != nil {
 		return err
 	}
 
-	item = reflect.Indirect(reflect.ValueOf(item)).Interface()
-	mitem, err := dbattribute.MarshalMap(item)
+	return c.putItem(ctx, tableName, item, cond)
+}
+
+func (c *Client) putItem(
+	ctx context.Context,
+	tableName string,
+	item interface{},
+	cond *condition) error {
+
+	empty := c.emptyValuesOf(tableName)
+	input, version, err := newPutItemInput(tableName, item, cond, empty)
 	if err != nil {
-		return fmt.Errorf("dynami: invalid item (%v)", err)
+		return err
 	}
-	mitem = removeEmptyAttr(mitem)
 
 	cdb := c.db
-	_, err = cdb.PutItem(&db.PutItemInput{
+	_, err = cdb.PutItemWithContext(ctx, input)
+	if awsErrCode(err) == db.ErrCodeConditionalCheckFailedException {
+		return conditionFailed(cond, version)
+	} else if err != nil {
+		return newError("cannot put item", err)
+	}
+
+	version.increment()
+	return nil
+}
+
+// newPutItemInput creates the put request for the given item
+// whose empty values are written according to empty. The
+// returned version is nil if item is not versioned.
+func newPutItemInput(
+	tableName string,
+	item interface{},
+	cond *condition,
+	empty EmptyValues) (*db.PutItemInput, *itemVersion, error) {
+
+	err := checkType(item, reflect.Struct, map[string]interface{}{})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	mitem, err := marshalWriteItem(item, empty)
+	if err != nil {
+		return nil, nil, fmt.Errorf("dynami: invalid item (%w)", err)
+	}
+
+	// Check and increment item version
+	var version *itemVersion
+	if vname, vfield := getVersion(item); vname != "" {
+		version = &itemVersion{
+			field: vfield,
+			value: versionValue(vfield),
+		}
+		version.cond = versionCondition(vname, version.value)
+		mitem[vname] = &db.AttributeValue{
+			N: aws.String(strconv.FormatInt(version.value+1, 10)),
+		}
+	}
+
+	input := &db.PutItemInput{
 		Item:      mitem,
 		TableName: aws.String(tableName),
-	})
+	}
+
+	var vcond *condition
+	if version != nil {
+		vcond = version.cond
+	}
 
+	mcond, err := mergeConditions(cond, vcond)
 	if err != nil {
-		return fmt.Errorf("dynami: cannot put item (%v)", err)
+		return nil, nil, err
+	} else if mcond != nil {
+		input.ConditionExpression = aws.String(mcond.expr)
+		input.ExpressionAttributeNames = mcond.attributeNames
+		input.ExpressionAttributeValues = mcond.attributeValues
 	}
 
-	return nil
+	return input, version, nil
 }
 
 // BatchPut represents a batch put operation. It
 // can put multiple items in multiple tables with
 // one DynamoDB operation.
 type BatchPut struct {
-	db *db.DynamoDB
-	op *batchOp
+	db    dbiface.DynamoDBAPI
+	op    *batchOp
+	retry RetryPolicy
+	stats Stats
 
 	err    error
 	tables map[string]bool
@@ -51,12 +194,16 @@ type BatchPut struct {
 
 // BatchPut queues a batch put operation. items must
 // satisfy the same conditions as that in BatchDelete.
+// Batch operations can't be conditional, so item versions
+// are neither checked nor incremented.
 func (c *Client) BatchPut(tableName string, items interface{}) *BatchPut {
 	b := &BatchPut{
 		db:     c.db,
 		op:     newBatchOp(),
+		retry:  c.batchRetry,
 		tables: map[string]bool{},
 	}
+	b.op.emptyValues = c.emptyValuesOf
 
 	err := checkSliceType(items, reflect.Interface, reflect.Struct, map[string]interface{}{})
 	if err != nil {
@@ -88,9 +235,35 @@ func (b *BatchPut) Put(tableName string, items interface{}) *BatchPut {
 	return b
 }
 
+// Retry sets the retry policy for the unprocessed items of
+// this batch. This overrides the default policy of the client.
+func (b *BatchPut) Retry(policy RetryPolicy) *BatchPut {
+	if b.err != nil {
+		return b
+	} else if err := policy.validate(); err != nil {
+		b.err = err
+		return b
+	}
+
+	b.retry = policy
+	return b
+}
+
+// Stats returns the consumed capacity of every
+// request sent by Run.
+func (b *BatchPut) Stats() Stats {
+	return b.stats.clone()
+}
+
 // Run executes every put operation in
 // this batch. This may return a BatchError.
 func (b *BatchPut) Run() error {
+	return b.RunWithContext(aws.BackgroundContext())
+}
+
+// RunWithContext is the same as Run with
+// the addition of a request context.
+func (b *BatchPut) RunWithContext(ctx context.Context) error {
 	const maxPutsPerOp = 25
 
 	if b.err != nil {
@@ -98,6 +271,11 @@ func (b *BatchPut) Run() error {
 	}
 
 	op := b.op
+	bo := &backoff{policy: b.retry}
+
+	// attempt counts the requests in a row that retry
+	// unprocessed items, regardless of the backoff.
+	attempt := 0
 	citems := map[string][]dbitem{}
 	for !op.isEmpty() || len(citems) > 0 {
 		citems = op.collectItems(maxPutsPerOp, citems)
@@ -116,17 +294,39 @@ func (b *BatchPut) Run() error {
 
 		// Put items into database
 		input := &db.BatchWriteItemInput{
-			RequestItems: reqItems,
+			RequestItems:           reqItems,
+			ReturnConsumedCapacity: aws.String(db.ReturnConsumedCapacityIndexes),
 		}
-		resp, err := b.db.BatchWriteItem(input)
+		resp, err := b.db.BatchWriteItemWithContext(withAttempt(ctx, attempt), input)
 
 		if err != nil {
-			return fmt.Errorf("dynami: BatchPut failed (%v)", err)
+			return newError("BatchPut failed", err)
 		}
 
+		b.stats.addCapacity(resp.ConsumedCapacity, true)
+
 		unproc := op.unwrap(resp.UnprocessedItems)
 		op.processItems(citems, unproc, nil)
+
+		progressed := countItems(unproc) < countItems(citems)
 		citems = unproc
+		if len(unproc) == 0 {
+			attempt = 0
+			bo.reset()
+			continue
+		} else if progressed {
+			bo.progress()
+		}
+		attempt++
+
+		// Wait before retrying unprocessed items
+		ok, err := bo.wait(ctx)
+		if err != nil {
+			return fmt.Errorf("dynami: BatchPut failed (%w)", err)
+		} else if !ok {
+			op.flushUnproc(ErrUnprocessed)
+			return op.errors()
+		}
 	}
 
 	return op.errors()
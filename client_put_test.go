@@ -1,8 +1,10 @@
 package dynami
 
 import (
+	"context"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	db "github.com/aws/aws-sdk-go/service/dynamodb"
@@ -157,6 +159,119 @@ func (suite *DatabaseTestSuite) TestBatchPut() {
 	assert.NotNil(err)
 }
 
+func (suite *DatabaseTestSuite) TestBatchPutRetry() {
+	assert := suite.Assert()
+	require := suite.Require()
+
+	books := []tBook{
+		{
+			Title:  "Dune",
+			Author: "Frank Herbert",
+		},
+		{
+			Title:  "Neuromancer",
+			Author: "William Gibson",
+		},
+	}
+
+	policy := RetryPolicy{
+		BaseDelay:   time.Millisecond,
+		MaxDelay:    4 * time.Millisecond,
+		Jitter:      1,
+		MaxAttempts: 3,
+	}
+
+	// Recover from throttling
+	fake := &tThrottledDB{DynamoDBAPI: suite.db, throttles: 2}
+	c, err := NewClientFromAPI(fake, nil, WithBatchRetry(policy))
+	require.Nil(err)
+
+	err = c.BatchPut("Book", books).Run()
+	require.Nil(err)
+	assert.Equal(3, fake.requests)
+
+	fetched := tBook{Title: books[0].Title, Author: books[0].Author}
+	err = c.GetItem("Book", &fetched)
+	require.Nil(err)
+
+	// Give up after max attempts
+	fake = &tThrottledDB{DynamoDBAPI: suite.db, throttles: -1}
+	c, err = NewClientFromAPI(fake, nil)
+	require.Nil(err)
+
+	err = c.BatchPut("Book", books).Retry(policy).Run()
+	require.NotNil(err)
+	assert.Equal(policy.MaxAttempts+1, fake.requests)
+
+	batchErr, ok := err.(BatchError)
+	require.True(ok)
+	require.Len(batchErr["Book"], len(books))
+	for i := range books {
+		assert.Equal(ErrUnprocessed, batchErr["Book"][i])
+	}
+
+	// Keep retrying while each request makes progress
+	var many []tBook
+	for i := 0; i < 30; i++ {
+		many = append(many, tBook{Title: randString(20), Author: randString(15)})
+	}
+
+	fake = &tThrottledDB{DynamoDBAPI: suite.db, throttles: -1, partial: true}
+	c, err = NewClientFromAPI(fake, nil)
+	require.Nil(err)
+
+	err = c.BatchPut("Book", many[:5]).Retry(policy).Run()
+	require.Nil(err)
+	assert.Equal(5, fake.requests)
+
+	// Give up under sustained partial throttling since
+	// the delay keeps growing until the time runs out
+	fake.requests = 0
+	err = c.BatchPut("Book", many).
+		Retry(RetryPolicy{
+			BaseDelay:      10 * time.Millisecond,
+			MaxElapsedTime: 100 * time.Millisecond,
+		}).
+		Run()
+	require.NotNil(err)
+	assert.Equal(4, fake.requests)
+
+	batchErr, ok = err.(BatchError)
+	require.True(ok)
+	assert.Len(batchErr["Book"], len(many)-fake.requests)
+
+	// Give up after max elapsed time
+	fake.partial = false
+	fake.requests = 0
+	err = c.BatchDelete("Book", books).
+		Retry(RetryPolicy{
+			BaseDelay:      10 * time.Millisecond,
+			MaxElapsedTime: 50 * time.Millisecond,
+		}).
+		Run()
+	require.NotNil(err)
+	assert.Equal(3, fake.requests)
+
+	batchErr, ok = err.(BatchError)
+	require.True(ok)
+	assert.Equal(ErrUnprocessed, batchErr["Book"][0])
+
+	// Stop waiting when the context is done
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = c.BatchPut("Book", books).RunWithContext(ctx)
+	require.NotNil(err)
+	_, ok = err.(BatchError)
+	assert.False(ok)
+
+	// Invalid policies
+	err = c.BatchPut("Book", books).Retry(RetryPolicy{MaxAttempts: -1}).Run()
+	assert.NotNil(err)
+
+	err = c.BatchPut("Book", books).Retry(RetryPolicy{}).Run()
+	assert.NotNil(err)
+}
+
 func (suite *DatabaseTestSuite) TestBatchPutMap() {
 	assert := suite.Assert()
 	require := suite.Require()
@@ -274,3 +389,209 @@ func (suite *DatabaseTestSuite) TestBatchPutMultiTable() {
 
 	fmt.Println("\rTest finished. Cleaning up...")
 }
+
+func (suite *DatabaseTestSuite) TestPutIf() {
+	assert := suite.Assert()
+	require := suite.Require()
+
+	book := tBook{
+		Title:  "Brave New World",
+		Author: "Aldous Huxley",
+		Genre:  "Science Fiction",
+	}
+
+	c := suite.client
+	err := c.PutItemIf("Book", book, "attribute_not_exists(Title)")
+	require.Nil(err)
+
+	// Item already exists
+	book.Genre = "Fiction"
+	err = c.PutItemIf("Book", book, "attribute_not_exists(Title)")
+	assert.Equal(ErrConditionFailed, err)
+
+	err = c.PutItemIf("Book", book, "Genre = :genre", "Science Fiction")
+	require.Nil(err)
+
+	fetched := tBook{
+		Title:  book.Title,
+		Author: book.Author,
+	}
+	err = c.GetItem("Book", &fetched, true)
+	require.Nil(err)
+	assert.Equal(book, fetched)
+
+	// Invalid condition expression
+	err = c.PutItemIf("Book", book, "Genre = :genre")
+	assert.NotNil(err)
+}
+
+func (suite *DatabaseTestSuite) TestPutVersion() {
+	assert := suite.Assert()
+	require := suite.Require()
+
+	type tVersionedQuote struct {
+		Author  string `dbkey:"hash"`
+		Text    string `dbkey:"range"`
+		Topic   string
+		Version int `dbversion:"true"`
+	}
+
+	quote := tVersionedQuote{
+		Author: "Oscar Wilde",
+		Text:   "Be yourself; everyone else is already taken.",
+		Topic:  "Life",
+	}
+
+	c := suite.client
+	err := c.PutItem("Quote", &quote)
+	require.Nil(err)
+	assert.Equal(1, quote.Version)
+
+	// Another writer puts the same item
+	stale := quote
+	stale.Version = 0
+	err = c.PutItem("Quote", &stale)
+	assert.Equal(ErrVersionConflict, err)
+
+	quote.Topic = "Individuality"
+	err = c.PutItem("Quote", &quote)
+	require.Nil(err)
+	assert.Equal(2, quote.Version)
+
+	// Update increments the version
+	var updated tVersionedQuote
+	err = c.Update("Quote", quote).
+		Set("Topic", "Life").
+		Run(&updated)
+	require.Nil(err)
+	assert.Equal(3, updated.Version)
+
+	err = c.Update("Quote", quote).
+		Set("Topic", "Individuality").
+		Run(nil)
+	assert.Equal(ErrVersionConflict, err)
+
+	// Delete using an outdated version
+	err = c.DeleteItem("Quote", quote)
+	assert.Equal(ErrVersionConflict, err)
+
+	err = c.DeleteItem("Quote", updated)
+	require.Nil(err)
+}
+
+func (suite *DatabaseTestSuite) TestPutWithContext() {
+	assert := suite.Assert()
+
+	book := tBook{
+		Title:  "The Pillars of the Earth",
+		Author: "Ken Follett",
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	c := suite.client
+	err := c.PutItemWithContext(ctx, "Book", book)
+	assert.NotNil(err)
+
+	err = c.GetItemWithContext(context.Background(), "Book", &book, true)
+	assert.Equal(ErrNoSuchItem, err)
+}
+
+type tNote struct {
+	Title  string `dbkey:"hash"`
+	Author string `dbkey:"range"`
+	Genre  string `dbindex:"hash,GenreIndex"`
+	Note   string
+	Ref    *string
+	Lines  []string
+	Info   tInfo
+}
+
+func (suite *DatabaseTestSuite) TestPutEmptyValues() {
+	assert := suite.Assert()
+	require := suite.Require()
+
+	fetch := func(title string) dbitem {
+		out, err := suite.db.GetItem(&db.GetItemInput{
+			TableName: aws.String("Book"),
+			Key: dbitem{
+				"Title":  {S: aws.String(title)},
+				"Author": {S: aws.String("author")},
+			},
+		})
+		require.Nil(err)
+		require.NotNil(out.Item)
+		return out.Item
+	}
+
+	note := tNote{
+		Author: "author",
+		Lines:  []string{"line", ""},
+	}
+
+	// Empty strings and nulls are omitted by default
+	note.Title = "omit"
+	err := suite.client.PutItem("Book", note)
+	require.Nil(err)
+
+	item := fetch("omit")
+	assert.NotContains(item, "Genre")
+	assert.NotContains(item, "Note")
+	assert.NotContains(item, "Ref")
+	assert.True(aws.BoolValue(item["Lines"].L[1].NULL))
+	assert.NotContains(item["Info"].M, "Publisher")
+
+	c, err := NewClientFromAPI(
+		suite.db,
+		nil,
+		WithEmptyValues(KeepEmptyStrings),
+		WithTableEmptyValues("Quote", OmitEmpty),
+	)
+	require.Nil(err)
+
+	note.Title = "keep"
+	err = c.PutItem("Book", note)
+	require.Nil(err)
+
+	item = fetch("keep")
+	assert.NotContains(item, "Genre")
+	assert.Equal("", aws.StringValue(item["Note"].S))
+	assert.NotContains(item, "Ref")
+	assert.Equal("", aws.StringValue(item["Lines"].L[1].S))
+	assert.Equal("", aws.StringValue(item["Info"].M["Publisher"].S))
+
+	// Empty strings and nil pointers round-trip
+	notes := []tNote{
+		{Title: "empty", Author: "author", Ref: aws.String("")},
+		{Title: "nil", Author: "author"},
+	}
+	err = c.BatchPut("Book", notes).Run()
+	require.Nil(err)
+
+	fetched := tNote{Title: "empty", Author: "author"}
+	err = c.GetItem("Book", &fetched)
+	require.Nil(err)
+	require.NotNil(fetched.Ref)
+	assert.Equal("", *fetched.Ref)
+
+	fetched = tNote{Title: "nil", Author: "author"}
+	err = c.GetItem("Book", &fetched)
+	require.Nil(err)
+	assert.Nil(fetched.Ref)
+
+	c, err = NewClientFromAPI(suite.db, nil, WithTableEmptyValues("Book", KeepNulls))
+	require.Nil(err)
+
+	note.Title = "null"
+	err = c.Transaction().Put("Book", note).Run()
+	require.Nil(err)
+
+	item = fetch("null")
+	assert.NotContains(item, "Genre")
+	assert.Equal("", aws.StringValue(item["Note"].S))
+	assert.True(aws.BoolValue(item["Ref"].NULL))
+
+	_, err = NewClientFromAPI(suite.db, nil, WithEmptyValues(EmptyValues(3)))
+	assert.NotNil(err)
+}
@@ -1,21 +1,23 @@
 package dynami
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"regexp"
+	"strconv"
 	"strings"
 
 	"github.com/aws/aws-sdk-go/aws"
 	db "github.com/aws/aws-sdk-go/service/dynamodb"
-	dbattribute "github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	dbiface "github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
 )
 
 // Query represents a client query. This may perform
 // a DynamoDB query or scan operation depending on whether
 // a hash filter is added or not.
 type Query struct {
-	db *db.DynamoDB
+	db dbiface.DynamoDBAPI
 
 	table string
 	index string
@@ -26,6 +28,10 @@ type Query struct {
 	nfilters   int
 	filterExpr string
 
+	projExpr string
+	start    *cursor
+	segments int
+
 	attributeNames  map[string]*string
 	attributeValues map[string]*db.AttributeValue
 
@@ -90,6 +96,47 @@ func (q *Query) Consistent() *Query {
 	return q
 }
 
+// Select fetches only the attributes in attrs instead of
+// whole items. Nested attributes can be selected using a dot
+// separator, eg. "Info.Publisher". To select the attributes of
+// a struct, use Select(dynami.Attributes(Item{})...).
+func (q *Query) Select(attrs ...string) *Query {
+	if q.err != nil {
+		return q
+	}
+
+	proj, err := parseProjection(attrs)
+	if err != nil {
+		q.err = err
+		return q
+	}
+
+	q.projExpr = proj.expr
+	for ph, name := range proj.attributeNames {
+		q.addAttributeName(ph, name)
+	}
+
+	return q
+}
+
+// StartFrom resumes the query from the given cursor which is
+// obtained from ItemIterator.Cursor. The cursor must come from
+// a query on the same table and index.
+func (q *Query) StartFrom(cursor string) *Query {
+	if q.err != nil {
+		return q
+	}
+
+	c, err := decodeCursor(cursor)
+	if err != nil {
+		q.err = err
+		return q
+	}
+
+	q.start = c
+	return q
+}
+
 func (q *Query) addAttributeName(placeholder string, value *string) {
 	if q.attributeNames == nil {
 		q.attributeNames = map[string]*string{}
@@ -119,9 +166,15 @@ func (q *Query) HashFilter(name string, value interface{}) *Query {
 		q.hashExpr = "#H = :hv"
 		q.addAttributeName("#H", aws.String(name))
 
-		attr, err := dbattribute.ConvertTo(value)
+		kv, err := keyValue(value)
+		if err != nil {
+			q.err = fmt.Errorf("dynami: hash filter value is invalid (%w)", err)
+			return q
+		}
+
+		attr, err := marshalValue(kv)
 		if err != nil {
-			q.err = fmt.Errorf("dynami: hash filter value is invalid (%v)", err)
+			q.err = fmt.Errorf("dynami: hash filter value is invalid (%w)", err)
 			return q
 		}
 		q.addAttributeValue(":hv", attr)
@@ -139,7 +192,17 @@ func (q *Query) RangeFilter(expr string, values ...interface{}) *Query {
 	}
 
 	if expr != "" && len(values) > 0 {
-		v, err := parseExpression(expr, values)
+		kvalues := make([]interface{}, len(values))
+		for i, value := range values {
+			kv, err := keyValue(value)
+			if err != nil {
+				q.err = fmt.Errorf("dynami: range filter value is invalid (%w)", err)
+				return q
+			}
+			kvalues[i] = kv
+		}
+
+		v, err := parseExpression(expr, kvalues)
 		if err != nil {
 			q.err = err
 			return q
@@ -190,11 +253,39 @@ func (q *Query) Filter(expr string, values ...interface{}) *Query {
 
 // Run executes the query and returns a result iterator.
 func (q *Query) Run() *ItemIterator {
+	return q.RunWithContext(aws.BackgroundContext())
+}
+
+// RunWithContext is the same as Run with the addition of a
+// request context. The context is also used by the returned
+// iterator when fetching the rest of the results.
+func (q *Query) RunWithContext(ctx context.Context) *ItemIterator {
 	if q.err != nil {
-		return &ItemIterator{}
+		return &ItemIterator{err: q.err}
+	}
+
+	if q.segments > 1 && q.hashExpr != "" {
+		return &ItemIterator{
+			err: fmt.Errorf("dynami: parallel scans can't have a hash filter"),
+		}
+	} else if q.segments > 1 && q.start != nil {
+		return &ItemIterator{
+			err: fmt.Errorf("dynami: parallel scans can't be resumed from a cursor"),
+		}
+	}
+
+	var startKey dbitem
+	if q.start != nil {
+		if q.start.Table != q.table || q.start.Index != q.index {
+			return &ItemIterator{
+				err: fmt.Errorf("dynami: cursor is from a different table or index"),
+			}
+		}
+		startKey = q.start.key()
 	}
 
 	qdb := q.db
+	var stats Stats
 	var lastKey map[string]*db.AttributeValue
 	var outpItems []map[string]*db.AttributeValue
 
@@ -208,15 +299,24 @@ func (q *Query) Run() *ItemIterator {
 			IndexName:                 toPtr(q.index).(*string),
 			KeyConditionExpression:    toPtr(keyExpr).(*string),
 			FilterExpression:          toPtr(q.filterExpr).(*string),
+			ProjectionExpression:      toPtr(q.projExpr).(*string),
 			ExpressionAttributeNames:  q.attributeNames,
 			ExpressionAttributeValues: q.attributeValues,
 			Limit:            toPtr(int64(q.limit)).(*int64),
 			ScanIndexForward: toPtr(q.scanForward).(*bool),
 			ConsistentRead:   toPtr(q.consistentRead).(*bool),
+			ExclusiveStartKey: startKey,
+			ReturnConsumedCapacity: aws.String(db.ReturnConsumedCapacityIndexes),
+		}
+		qoutput, err := qdb.QueryWithContext(ctx, qinput)
+		if err != nil {
+			return &ItemIterator{
+				err: newError("cannot run query", err),
+			}
 		}
-		qoutput, _ := qdb.Query(qinput)
 		lastKey = qoutput.LastEvaluatedKey
 		outpItems = qoutput.Items
+		stats = pageStats(qoutput.ConsumedCapacity, qoutput.Count, qoutput.ScannedCount)
 
 		queryInput = qinput
 
@@ -229,31 +329,53 @@ func (q *Query) Run() *ItemIterator {
 			TableName:                 toPtr(q.table).(*string),
 			IndexName:                 toPtr(q.index).(*string),
 			FilterExpression:          toPtr(filterExpr).(*string),
+			ProjectionExpression:      toPtr(q.projExpr).(*string),
 			ExpressionAttributeNames:  q.attributeNames,
 			ExpressionAttributeValues: q.attributeValues,
 			Limit:          toPtr(int64(q.limit)).(*int64),
 			ConsistentRead: toPtr(q.consistentRead).(*bool),
+			ExclusiveStartKey: startKey,
+			ReturnConsumedCapacity: aws.String(db.ReturnConsumedCapacityIndexes),
 		}
 
-		soutput, _ := qdb.Scan(sinput)
+		if q.segments > 1 {
+			return q.runParallel(ctx, sinput)
+		}
+
+		soutput, err := qdb.ScanWithContext(ctx, sinput)
+		if err != nil {
+			return &ItemIterator{
+				err: newError("cannot run query", err),
+			}
+		}
 		lastKey = soutput.LastEvaluatedKey
 		outpItems = soutput.Items
+		stats = pageStats(soutput.ConsumedCapacity, soutput.Count, soutput.ScannedCount)
 
 		queryInput = sinput
 	}
 
 	return &ItemIterator{
 		db:         qdb,
+		ctx:        ctx,
+		table:      q.table,
+		indexName:  q.index,
 		limit:      q.limit,
 		items:      outpItems,
+		startKey:   startKey,
 		lastKey:    lastKey,
 		queryInput: queryInput,
+		stats:      stats,
 	}
 }
 
 // ItemIterator iterates over the result of a query.
 type ItemIterator struct {
-	db *db.DynamoDB
+	db  dbiface.DynamoDBAPI
+	ctx context.Context
+
+	table     string
+	indexName string
 
 	index int
 	limit int
@@ -263,46 +385,83 @@ type ItemIterator struct {
 	// if the results are greater than 1MB
 	lastKey map[string]*db.AttributeValue
 
+	// startKey is the start key of the current
+	// page. keyNames caches the key attribute
+	// names used to create cursors.
+	startKey dbitem
+	keyNames []string
+
+	// These are used by parallel scans.
+	// pages receives the pages of every
+	// segment and cancel stops the scan.
+	pages  <-chan scanPage
+	cancel context.CancelFunc
+	count  int
+	closed bool
+
 	// This can be a *dynamodb.ScanInput
 	// or *dynamodb.QueryInput
 	queryInput interface{}
+
+	// stats accumulates the stats
+	// of every fetched page.
+	stats Stats
+
+	err error
 }
 
-// HasNext returns true if there are
-// more query results to iterate over.
+// HasNext returns true if there are more query results
+// to iterate over. This returns false if an error occurred
+// while fetching the results. Use Err to check for it.
 func (it *ItemIterator) HasNext() bool {
-	if it.index < len(it.items) {
+	if it.err != nil {
+		return false
+	} else if it.pages != nil {
+		return it.hasNextPage()
+	} else if it.index < len(it.items) {
 		return true
 	}
 
-	if len(it.lastKey) > 0 && (it.limit == -1 || it.index < it.limit) {
+	// Pages may be empty if all of their items are
+	// filtered out so keep fetching until there are
+	// items or until there are no more pages.
+	for len(it.lastKey) > 0 && (it.limit == -1 || it.index < it.limit) {
 		var lastKey map[string]*db.AttributeValue
 		var outpItems []map[string]*db.AttributeValue
 
 		switch qin := it.queryInput.(type) {
 		case *db.ScanInput:
 			qin.ExclusiveStartKey = it.lastKey
-			qout, _ := it.db.Scan(qin)
+			qout, err := it.db.ScanWithContext(it.ctx, qin)
+			if err != nil {
+				it.err = newError("cannot run query", err)
+				return false
+			}
 
 			outpItems = qout.Items
 			lastKey = qout.LastEvaluatedKey
+			it.stats.Add(pageStats(qout.ConsumedCapacity, qout.Count, qout.ScannedCount))
 		case *db.QueryInput:
 			qin.ExclusiveStartKey = it.lastKey
-			qout, _ := it.db.Query(qin)
+			qout, err := it.db.QueryWithContext(it.ctx, qin)
+			if err != nil {
+				it.err = newError("cannot run query", err)
+				return false
+			}
 
 			outpItems = qout.Items
 			lastKey = qout.LastEvaluatedKey
-		}
-
-		if len(outpItems) == 0 {
-			return false
+			it.stats.Add(pageStats(qout.ConsumedCapacity, qout.Count, qout.ScannedCount))
 		}
 
 		it.index = 0
 		it.items = outpItems
+		it.startKey = it.lastKey
 		it.lastKey = lastKey
 
-		return true
+		if len(outpItems) > 0 {
+			return true
+		}
 	}
 
 	return false
@@ -310,22 +469,154 @@ func (it *ItemIterator) HasNext() bool {
 
 // Next loads the next result to item. item must be a
 // pointer to map[string]interface{} or a pointer to struct.
+// This returns the error that stopped the iteration, if any.
 func (it *ItemIterator) Next(item interface{}) error {
 	if !it.HasNext() {
+		if it.err != nil {
+			return it.err
+		}
 		return fmt.Errorf("dynami: no more items to return")
 	}
 
 	if item != nil {
-		err := dbattribute.UnmarshalMap(it.items[it.index], item)
+		err := unmarshalItem(it.items[it.index], item)
 		if err != nil {
-			return fmt.Errorf("dynami: invalid item (%v)", err)
+			return fmt.Errorf("dynami: invalid item (%w)", err)
 		}
 	}
 
 	it.index++
+	it.count++
 	return nil
 }
 
+// Close stops the iteration and releases its resources.
+// This must be called if a parallel scan is not iterated
+// until the end. It's safe to call this on any iterator.
+func (it *ItemIterator) Close() {
+	it.closed = true
+	it.items = nil
+	it.lastKey = nil
+	if it.cancel != nil {
+		it.cancel()
+	}
+}
+
+// Cursor returns an opaque, URL-safe cursor that points after the
+// last item returned by Next. Passing it to Query.StartFrom resumes
+// the query from there, eg. in a later request of a paginated API.
+// This returns an empty string if there are no more items. If the
+// query has a projection, it must include the key attributes.
+func (it *ItemIterator) Cursor() (string, error) {
+	if it.err != nil {
+		return "", it.err
+	} else if it.pages != nil {
+		return "", fmt.Errorf("dynami: cannot create cursor for parallel scans")
+	}
+
+	var key dbitem
+	if it.index >= len(it.items) {
+		if len(it.lastKey) == 0 {
+			return "", nil
+		}
+		key = it.lastKey
+	} else if it.index == 0 {
+		key = it.startKey
+	} else {
+		names, err := it.getKeyNames()
+		if err != nil {
+			return "", err
+		}
+
+		// Use the key of the last returned item
+		item := it.items[it.index-1]
+		key = dbitem{}
+		for _, name := range names {
+			value, ok := item[name]
+			if !ok {
+				return "", fmt.Errorf("dynami: cannot create cursor (missing key attribute %v)", name)
+			}
+			key[name] = value
+		}
+	}
+
+	return encodeCursor(it.table, it.indexName, key)
+}
+
+// Stats returns the consumed capacity and the
+// number of returned and scanned items of every
+// page fetched so far.
+func (it *ItemIterator) Stats() Stats {
+	return it.stats.clone()
+}
+
+// pageStats returns the stats of a query or scan page.
+func pageStats(cc *db.ConsumedCapacity, count, scanned *int64) Stats {
+	stats := Stats{
+		Count:        int(aws.Int64Value(count)),
+		ScannedCount: int(aws.Int64Value(scanned)),
+	}
+	stats.addCapacity([]*db.ConsumedCapacity{cc}, false)
+	return stats
+}
+
+// getKeyNames returns the names of the attributes
+// that make up the last evaluated key of this query.
+func (it *ItemIterator) getKeyNames() ([]string, error) {
+	if it.keyNames != nil {
+		return it.keyNames, nil
+	}
+
+	// The last evaluated key has the same attributes
+	// as the start key of any page. If there's none,
+	// get the key attributes from the table description.
+	var names []string
+	if len(it.lastKey) > 0 {
+		for name := range it.lastKey {
+			names = append(names, name)
+		}
+	} else {
+		resp, err := it.db.DescribeTableWithContext(it.ctx, &db.DescribeTableInput{
+			TableName: aws.String(it.table),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("dynami: cannot create cursor (%w)", err)
+		}
+
+		table := resp.Table
+		keys := table.KeySchema
+		for _, idx := range table.LocalSecondaryIndexes {
+			if aws.StringValue(idx.IndexName) == it.indexName {
+				keys = append(keys, idx.KeySchema...)
+			}
+		}
+		for _, idx := range table.GlobalSecondaryIndexes {
+			if aws.StringValue(idx.IndexName) == it.indexName {
+				keys = append(keys, idx.KeySchema...)
+			}
+		}
+
+		seen := map[string]bool{}
+		for _, k := range keys {
+			name := aws.StringValue(k.AttributeName)
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+
+	it.keyNames = names
+	return names, nil
+}
+
+// Err returns the error that stopped the iteration.
+// This returns nil if the iteration has not stopped
+// or if all results have been iterated over.
+func (it *ItemIterator) Err() error {
+	return it.err
+}
+
 type exprValue struct {
 	expr       string
 	attrNames  []attrName
@@ -435,6 +726,96 @@ func parseExpression(expr string, values []interface{}) (*exprValue, error) {
 	return v, nil
 }
 
+// condition is a parsed condition expression
+// used by conditional write operations.
+type condition struct {
+	expr            string
+	attributeNames  map[string]*string
+	attributeValues map[string]*db.AttributeValue
+}
+
+func parseCondition(expr string, values []interface{}) (*condition, error) {
+	if expr == "" {
+		return nil, fmt.Errorf("dynami: empty condition expression")
+	}
+
+	v, err := parseExpression(expr, values)
+	if err != nil {
+		return nil, err
+	}
+
+	cond := &condition{expr: v.expr}
+	for _, n := range v.attrNames {
+		if cond.attributeNames == nil {
+			cond.attributeNames = map[string]*string{}
+		}
+		cond.attributeNames[n.placeholder] = n.value
+	}
+	for _, v := range v.attrValues {
+		if cond.attributeValues == nil {
+			cond.attributeValues = map[string]*db.AttributeValue{}
+		}
+		cond.attributeValues[v.placeholder] = v.value
+	}
+
+	return cond, nil
+}
+
+// versionCondition returns a condition that is satisfied if
+// the version attribute of the stored item is equal to version.
+// A zero version means that the item must not have a version yet.
+func versionCondition(name string, version int64) *condition {
+	cond := &condition{
+		attributeNames: map[string]*string{"#dbversion": aws.String(name)},
+	}
+
+	if version == 0 {
+		cond.expr = "attribute_not_exists(#dbversion)"
+		return cond
+	}
+
+	cond.expr = "#dbversion = :dbversion"
+	cond.attributeValues = map[string]*db.AttributeValue{
+		":dbversion": {N: aws.String(strconv.FormatInt(version, 10))},
+	}
+	return cond
+}
+
+// mergeConditions AND's the given conditions together.
+// Nil conditions are ignored.
+func mergeConditions(conds ...*condition) (*condition, error) {
+	var exprs []string
+	merged := &condition{}
+	for _, cond := range conds {
+		if cond == nil {
+			continue
+		}
+		exprs = append(exprs, "("+cond.expr+")")
+
+		for ph, n := range cond.attributeNames {
+			if merged.attributeNames == nil {
+				merged.attributeNames = map[string]*string{}
+			}
+			merged.attributeNames[ph] = n
+		}
+		for ph, v := range cond.attributeValues {
+			if merged.attributeValues == nil {
+				merged.attributeValues = map[string]*db.AttributeValue{}
+			} else if _, ok := merged.attributeValues[ph]; ok {
+				return nil, fmt.Errorf("dynami: duplicate placeholder (%v)", ph)
+			}
+			merged.attributeValues[ph] = v
+		}
+	}
+
+	if len(exprs) == 0 {
+		return nil, nil
+	}
+
+	merged.expr = strings.Join(exprs, " AND ")
+	return merged, nil
+}
+
 func parseFuncExpr(expr string) (string, []string, error) {
 	if m := reFunc.FindStringSubmatch(expr); len(m) > 0 {
 		if len(m) != 3 {
@@ -487,7 +868,12 @@ func parseBetweenExpr(expr string) (string, []string, error) {
 			return "", nil, fmt.Errorf("dynami: invalid expression (%v)", expr)
 		}
 
-		return trimLeftP(m[1]), []string{m[2], trimRightP(m[3])}, nil
+		attrName, err := trimSizeFunc(trimLeftP(m[1]), expr)
+		if err != nil {
+			return "", nil, err
+		}
+
+		return attrName, []string{m[2], trimRightP(m[3])}, nil
 	}
 
 	return "", nil, errNoMatch
@@ -499,13 +885,9 @@ func parseCompExpr(expr string) (string, []string, error) {
 			return "", nil, fmt.Errorf("dynami: invalid expression (%v)", expr)
 		}
 
-		// Parse size function if present
-		attrName := trimLeftP(m[1])
-		if mf := reFunc.FindStringSubmatch(attrName); len(mf) > 0 {
-			if len(mf) != 3 || mf[1] != "size" {
-				return "", nil, fmt.Errorf("dynami: invalid expression (%v)", expr)
-			}
-			attrName = mf[2]
+		attrName, err := trimSizeFunc(trimLeftP(m[1]), expr)
+		if err != nil {
+			return "", nil, err
 		}
 
 		return attrName, []string{trimRightP(m[2])}, nil
@@ -514,6 +896,20 @@ func parseCompExpr(expr string) (string, []string, error) {
 	return "", nil, errNoMatch
 }
 
+// trimSizeFunc returns the attribute name of operand
+// if it's a size function, eg. "Tags" in "size(Tags)".
+// Otherwise, operand is returned as is.
+func trimSizeFunc(operand, expr string) (string, error) {
+	if mf := reFunc.FindStringSubmatch(operand); len(mf) > 0 {
+		if len(mf) != 3 || mf[1] != "size" {
+			return "", fmt.Errorf("dynami: invalid expression (%v)", expr)
+		}
+		return mf[2], nil
+	}
+
+	return operand, nil
+}
+
 func parseExprAttrName(
 	expr string,
 	exprAttrName string) (string, []attrName) {
@@ -548,9 +944,9 @@ func parseExprAttrValue(
 			return nil, fmt.Errorf("dynami: invalid value placeholder (%v)", ph)
 		}
 
-		attr, err := dbattribute.ConvertTo(exprAttrValue[i])
+		attr, err := marshalValue(exprAttrValue[i])
 		if err != nil {
-			return nil, fmt.Errorf("dynami: invalid expression value (%v)", err)
+			return nil, fmt.Errorf("dynami: invalid expression value (%w)", err)
 		}
 
 		attrs[i] = attrValue{
@@ -117,7 +117,7 @@ func (suite *DatabaseTestSuite) TestQuery() {
 	for _, b := range books {
 		item, err := dbattribute.MarshalMap(b)
 		require.Nil(err)
-		item = removeEmptyAttr(item)
+		item = removeEmptyAttr(item, OmitEmpty)
 
 		_, err = sdb.PutItem(&db.PutItemInput{
 			Item:      item,
@@ -363,3 +363,240 @@ func (suite *DatabaseTestSuite) TestQueryBig() {
 
 	fmt.Println("\rTest finished. Cleaning up...")
 }
+
+func (suite *DatabaseTestSuite) TestQueryError() {
+	assert := suite.Assert()
+
+	// Query unknown table
+	c := suite.client
+	it := c.Query("UnknownTable").
+		HashFilter("Author", "some author").
+		Run()
+	assert.False(it.HasNext())
+	assert.NotNil(it.Err())
+	assert.Equal(it.Err(), it.Next(nil))
+
+	// Scan unknown table
+	it = c.Query("UnknownTable").Run()
+	assert.False(it.HasNext())
+	assert.NotNil(it.Err())
+
+	// Invalid query
+	it = c.Query("Quote").
+		Filter("Topic = :topic").
+		Run()
+	assert.False(it.HasNext())
+	assert.NotNil(it.Err())
+
+	// Empty result is not an error
+	it = c.Query("Quote").
+		HashFilter("Author", randString(15)).
+		Run()
+	assert.False(it.HasNext())
+	assert.Nil(it.Err())
+}
+
+func (suite *DatabaseTestSuite) TestQuerySelect() {
+	assert := suite.Assert()
+	require := suite.Require()
+
+	author := randString(15)
+	quotes := []tQuote{
+		{Author: author, Text: "A", Topic: "life", Date: 1},
+		{Author: author, Text: "B", Topic: "love", Date: 2},
+		{Author: author, Text: "C", Topic: "life", Date: 3},
+	}
+
+	c := suite.client
+	err := c.BatchPut("Quote", quotes).Run()
+	require.Nil(err)
+
+	// Projected and filtered attributes
+	// share the same name placeholder
+	it := c.Query("Quote").
+		HashFilter("Author", author).
+		Filter("Topic = :topic", "life").
+		Select("Text", "Topic").
+		Run()
+
+	var fetched []tQuote
+	for it.HasNext() {
+		var q tQuote
+		err := it.Next(&q)
+		require.Nil(err)
+		fetched = append(fetched, q)
+	}
+	require.Nil(it.Err())
+	assert.Equal([]tQuote{
+		{Text: "A", Topic: "life"},
+		{Text: "C", Topic: "life"},
+	}, fetched)
+
+	// Scan using struct attributes
+	it = c.Query("Quote").
+		Filter("Author = :author", author).
+		Select(Attributes(tQuote{})...).
+		Run()
+
+	fetched = nil
+	for it.HasNext() {
+		var q tQuote
+		err := it.Next(&q)
+		require.Nil(err)
+		fetched = append(fetched, q)
+	}
+	require.Nil(it.Err())
+	assert.Len(fetched, len(quotes))
+	for _, q := range fetched {
+		assert.Contains(quotes, q)
+	}
+
+	// Empty projection
+	it = c.Query("Quote").
+		HashFilter("Author", author).
+		Select().
+		Run()
+	assert.NotNil(it.Err())
+}
+
+func (suite *DatabaseTestSuite) TestQueryCursor() {
+	assert := suite.Assert()
+	require := suite.Require()
+
+	author := randString(15)
+	quotes := make([]tQuote, 10)
+	for i := range quotes {
+		quotes[i] = tQuote{
+			Author: author,
+			Text:   fmt.Sprintf("Quote %02d", i),
+			Topic:  "life",
+		}
+	}
+
+	c := suite.client
+	err := c.BatchPut("Quote", quotes).Run()
+	require.Nil(err)
+
+	// Fetch three items at a time
+	var fetched []tQuote
+	cursor := ""
+	for i := 0; i < 10; i++ {
+		q := c.Query("Quote").HashFilter("Author", author)
+		if cursor != "" {
+			q = q.StartFrom(cursor)
+		}
+
+		it := q.Run()
+		for j := 0; j < 3 && it.HasNext(); j++ {
+			var quote tQuote
+			err := it.Next(&quote)
+			require.Nil(err)
+			fetched = append(fetched, quote)
+		}
+
+		cursor, err = it.Cursor()
+		require.Nil(err)
+		if cursor == "" {
+			break
+		}
+	}
+	assert.Equal(quotes, fetched)
+
+	// Cursor before any item is returned
+	it := c.Query("Quote").HashFilter("Author", author).Run()
+	cursor, err = it.Cursor()
+	require.Nil(err)
+	require.NotEmpty(cursor)
+
+	it = c.Query("Quote").
+		HashFilter("Author", author).
+		StartFrom(cursor).
+		Run()
+	var first tQuote
+	err = it.Next(&first)
+	require.Nil(err)
+	assert.Equal(quotes[0], first)
+
+	// Cursor from a different table
+	it = c.Query("Book").StartFrom(cursor).Run()
+	assert.NotNil(it.Err())
+
+	// Invalid cursor
+	it = c.Query("Quote").StartFrom("invalid cursor").Run()
+	assert.NotNil(it.Err())
+}
+
+func (suite *DatabaseTestSuite) TestQueryParallel() {
+	assert := suite.Assert()
+	require := suite.Require()
+
+	topic := randString(15)
+	quotes := make([]tQuote, 50)
+	for i := range quotes {
+		quotes[i] = tQuote{
+			Author: randString(10),
+			Text:   randString(20),
+			Topic:  topic,
+		}
+	}
+
+	c := suite.client
+	err := c.BatchPut("Quote", quotes).Run()
+	require.Nil(err)
+
+	// Scan all segments
+	it := c.Query("Quote").
+		Filter("Topic = :topic", topic).
+		Parallel(4).
+		Run()
+
+	var fetched []tQuote
+	for it.HasNext() {
+		var q tQuote
+		err := it.Next(&q)
+		require.Nil(err)
+		fetched = append(fetched, q)
+	}
+	require.Nil(it.Err())
+	assert.Len(fetched, len(quotes))
+	for _, q := range fetched {
+		assert.Contains(quotes, q)
+	}
+
+	// Limit total results
+	it = c.Query("Quote").
+		Filter("Topic = :topic", topic).
+		Limit(10).
+		Parallel(4).
+		Run()
+
+	n := 0
+	for it.HasNext() {
+		it.Next(nil)
+		n++
+	}
+	require.Nil(it.Err())
+	assert.True(n <= 10)
+
+	// Stop early
+	it = c.Query("Quote").Parallel(8).Run()
+	assert.True(it.HasNext())
+	it.Close()
+	assert.False(it.HasNext())
+
+	// Parallel query
+	it = c.Query("Quote").
+		HashFilter("Author", quotes[0].Author).
+		Parallel(4).
+		Run()
+	assert.NotNil(it.Err())
+
+	// Invalid segments
+	it = c.Query("Quote").Parallel(0).Run()
+	assert.NotNil(it.Err())
+
+	// Unknown table
+	it = c.Query("UnknownTable").Parallel(4).Run()
+	assert.False(it.HasNext())
+	assert.NotNil(it.Err())
+}
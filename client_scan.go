@@ -0,0 +1,160 @@
+package dynami
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	db "github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// maxScanWorkers is the maximum number
+// of segments that are scanned concurrently.
+const maxScanWorkers = 16
+
+// scanPage contains the items
+// of a scan segment page.
+type scanPage struct {
+	items []map[string]*db.AttributeValue
+	stats Stats
+	err   error
+}
+
+// Parallel divides a scan into the given number of segments
+// which are scanned concurrently. At most 16 segments are
+// scanned at a time. The results of all segments are returned
+// by the same iterator in no particular order. Parallel only
+// applies to queries without a hash filter and can't be used
+// with StartFrom. Limit is applied to the total number of
+// results. If the iteration is stopped early, call
+// ItemIterator.Close to stop the remaining segments.
+func (q *Query) Parallel(segments int) *Query {
+	if q.err != nil {
+		return q
+	} else if segments <= 0 {
+		q.err = fmt.Errorf("dynami: segments must be greater than zero")
+		return q
+	}
+
+	q.segments = segments
+	return q
+}
+
+// runParallel scans each segment in its own goroutine and
+// sends the pages to the returned iterator. If a segment
+// fails, the remaining segments are stopped.
+func (q *Query) runParallel(ctx context.Context, input *db.ScanInput) *ItemIterator {
+	sctx, cancel := context.WithCancel(ctx)
+	pages := make(chan scanPage, maxScanWorkers)
+	segments := make(chan int, q.segments)
+	for i := 0; i < q.segments; i++ {
+		segments <- i
+	}
+	close(segments)
+
+	var wg sync.WaitGroup
+	workers := min(q.segments, maxScanWorkers)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for segment := range segments {
+				if !q.scanSegment(sctx, input, segment, pages) {
+					return
+				}
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(pages)
+	}()
+
+	return &ItemIterator{
+		db:        q.db,
+		ctx:       ctx,
+		table:     q.table,
+		indexName: q.index,
+		limit:     q.limit,
+		pages:     pages,
+		cancel:    cancel,
+	}
+}
+
+// scanSegment sends every page of the given segment to
+// pages. This returns false if the scan should be stopped.
+func (q *Query) scanSegment(
+	ctx context.Context,
+	input *db.ScanInput,
+	segment int,
+	pages chan<- scanPage) bool {
+
+	// Copy the input since
+	// the start key changes
+	sinput := *input
+	sinput.Segment = aws.Int64(int64(segment))
+	sinput.TotalSegments = aws.Int64(int64(q.segments))
+
+	for {
+		var page scanPage
+		out, err := q.db.ScanWithContext(ctx, &sinput)
+		if err != nil {
+			page.err = newError("cannot run query", err)
+		} else {
+			page.items = out.Items
+			page.stats = pageStats(out.ConsumedCapacity, out.Count, out.ScannedCount)
+		}
+
+		select {
+		case pages <- page:
+		case <-ctx.Done():
+			return false
+		}
+
+		if err != nil {
+			return false
+		} else if len(out.LastEvaluatedKey) == 0 {
+			return true
+		}
+		sinput.ExclusiveStartKey = out.LastEvaluatedKey
+	}
+}
+
+// hasNextPage is the HasNext
+// implementation of parallel scans.
+func (it *ItemIterator) hasNextPage() bool {
+	if it.closed {
+		return false
+	} else if it.limit != -1 && it.count >= it.limit {
+		it.Close()
+		return false
+	} else if it.index < len(it.items) {
+		return true
+	}
+
+	for page := range it.pages {
+		if page.err != nil {
+			it.err = page.err
+			it.Close()
+			return false
+		}
+
+		it.index = 0
+		it.items = page.items
+		it.stats.Add(page.stats)
+		if len(page.items) > 0 {
+			return true
+		}
+	}
+
+	// Every segment is done unless
+	// the context is canceled
+	if err := it.ctx.Err(); err != nil {
+		it.err = fmt.Errorf("dynami: cannot run query (%w)", err)
+	}
+
+	it.Close()
+	return false
+}
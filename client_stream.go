@@ -1,14 +1,14 @@
 package dynami
 
 import (
+	"context"
 	"fmt"
 	"sort"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/awserr"
-	dbattribute "github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
 	"github.com/aws/aws-sdk-go/service/dynamodbstreams"
+	"github.com/aws/aws-sdk-go/service/dynamodbstreams/dynamodbstreamsiface"
 )
 
 const errResourceNotFound = "ResourceNotFoundException"
@@ -82,7 +82,8 @@ type shard struct {
 }
 
 type shardIterator struct {
-	dbs *dynamodbstreams.DynamoDBStreams
+	dbs dynamodbstreamsiface.DynamoDBStreamsAPI
+	ctx context.Context
 
 	id    string
 	arn   string
@@ -90,19 +91,21 @@ type shardIterator struct {
 }
 
 func newShardIterator(
-	dbs *dynamodbstreams.DynamoDBStreams,
+	ctx context.Context,
+	dbs dynamodbstreamsiface.DynamoDBStreamsAPI,
 	arn string,
 	shard *shard,
 	exclStartSeqNum *seqNum) (*shardIterator, error) {
 
 	st := &shardIterator{
 		dbs:   dbs,
+		ctx:   ctx,
 		arn:   arn,
 		shard: shard,
 	}
 
 	if shard.seqRange.has(exclStartSeqNum) {
-		resp, err := dbs.GetShardIterator(&dynamodbstreams.GetShardIteratorInput{
+		resp, err := dbs.GetShardIteratorWithContext(ctx, &dynamodbstreams.GetShardIteratorInput{
 			StreamArn:         aws.String(arn),
 			ShardId:           aws.String(shard.id),
 			SequenceNumber:    (*string)(exclStartSeqNum),
@@ -114,7 +117,7 @@ func newShardIterator(
 		}
 	}
 
-	resp, err := dbs.GetShardIterator(&dynamodbstreams.GetShardIteratorInput{
+	resp, err := dbs.GetShardIteratorWithContext(ctx, &dynamodbstreams.GetShardIteratorInput{
 		StreamArn:         aws.String(arn),
 		ShardId:           aws.String(shard.id),
 		ShardIteratorType: aws.String(dynamodbstreams.ShardIteratorTypeTrimHorizon),
@@ -128,20 +131,36 @@ func newShardIterator(
 }
 
 func (st *shardIterator) refresh(exclStartSeqNum *seqNum) error {
-	st, err := newShardIterator(st.dbs, st.arn, st.shard, exclStartSeqNum)
+	st, err := newShardIterator(st.ctx, st.dbs, st.arn, st.shard, exclStartSeqNum)
 	return err
 }
 
 // GetStream returns the stream record iterator for the given table.
 func (c *Client) GetStream(tableName string) (*RecordIterator, error) {
-	table, err := c.DescribeTable(tableName)
+	return c.GetStreamWithContext(aws.BackgroundContext(), tableName)
+}
+
+// GetStreamWithContext is the same as GetStream with the addition
+// of a context. The context is used by the returned iterator for all
+// its requests. Once the context is canceled, HasNext and WaitNext
+// return false.
+func (c *Client) GetStreamWithContext(
+	ctx context.Context,
+	tableName string) (*RecordIterator, error) {
+
+	if c.dbs == nil {
+		return nil, fmt.Errorf("dynami: cannot get stream (nil DynamoDB Streams API)")
+	}
+
+	table, err := c.DescribeTableWithContext(ctx, tableName)
 	if err != nil {
-		return nil, fmt.Errorf("dynami: cannot get stream (%v)", err)
+		return nil, fmt.Errorf("dynami: cannot get stream (%w)", err)
 	}
 
 	it := &RecordIterator{
 		arn:               table.PStreamARN,
 		dbs:               c.dbs,
+		ctx:               withTable(ctx, tableName),
 		processedShardIDs: map[string]bool{},
 		lastRecSeqNum:     (*seqNum)(aws.String("")),
 	}
@@ -160,7 +179,9 @@ type RecordIterator struct {
 	lastShardID       *string
 	processedShardIDs map[string]bool
 
-	dbs *dynamodbstreams.DynamoDBStreams
+	dbs dynamodbstreamsiface.DynamoDBStreamsAPI
+	ctx context.Context
+	err error
 }
 
 // HasNext returns true if there are
@@ -188,9 +209,9 @@ func (it *RecordIterator) Next(record interface{}) (RecordType, error) {
 
 	rec := it.records[it.index]
 	if record != nil {
-		err := dbattribute.UnmarshalMap(rec.dbitem, record)
+		err := unmarshalItem(rec.dbitem, record)
 		if err != nil {
-			return unknownRecord, fmt.Errorf("dynami: invalid record (%v)", err)
+			return unknownRecord, fmt.Errorf("dynami: invalid record (%w)", err)
 		}
 	}
 
@@ -198,8 +219,16 @@ func (it *RecordIterator) Next(record interface{}) (RecordType, error) {
 	return rec.recordType, nil
 }
 
+// Err returns the error that stopped the iteration.
+// This returns nil if the iteration has not stopped,
+// or if it stopped because the stream was closed or
+// the context is done.
+func (it *RecordIterator) Err() error {
+	return it.err
+}
+
 func (it *RecordIterator) getNext(wait bool) bool {
-	if it.arn == "" {
+	if it.arn == "" || it.err != nil {
 		return false
 	} else if it.index < len(it.records) {
 		return true
@@ -211,13 +240,24 @@ func (it *RecordIterator) getNext(wait bool) bool {
 
 	dbs := it.dbs
 	ticker := time.NewTicker(200 * time.Millisecond)
-	for range ticker.C {
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-it.ctx.Done():
+			return false
+		case <-ticker.C:
+		}
+
 		// Describe streams
-		resp, err := dbs.DescribeStream(&dynamodbstreams.DescribeStreamInput{
+		resp, err := dbs.DescribeStreamWithContext(it.ctx, &dynamodbstreams.DescribeStreamInput{
 			StreamArn:             aws.String(it.arn),
 			ExclusiveStartShardId: it.lastShardID,
 		})
-		if err != nil && err.(awserr.Error).Code() == errResourceNotFound {
+		if err != nil && (it.ctx.Err() != nil || awsErrCode(err) == errResourceNotFound) {
+			return false
+		} else if err != nil {
+			it.err = newError("cannot describe stream", err)
 			return false
 		}
 
@@ -234,9 +274,9 @@ func (it *RecordIterator) getNext(wait bool) bool {
 		// Process each shard
 		shards := it.getShards(desc.Shards)
 		for _, s := range shards {
-			st, err := newShardIterator(it.dbs, it.arn, &s, it.lastRecSeqNum)
+			st, err := newShardIterator(it.ctx, it.dbs, it.arn, &s, it.lastRecSeqNum)
 			if err != nil {
-				if err.(awserr.Error).Code() == errResourceNotFound {
+				if it.ctx.Err() != nil || awsErrCode(err) == errResourceNotFound {
 					return false
 				}
 
@@ -246,7 +286,7 @@ func (it *RecordIterator) getNext(wait bool) bool {
 			recs, err := it.getRecords(st)
 			it.records = append(it.records, recs...)
 			if err != nil {
-				if err.(awserr.Error).Code() == errResourceNotFound {
+				if it.ctx.Err() != nil || awsErrCode(err) == errResourceNotFound {
 					return false
 				}
 			}
@@ -300,12 +340,21 @@ func (it *RecordIterator) getRecords(st *shardIterator) ([]record, error) {
 	dbs := st.dbs
 	shardIt := &st.id
 	ticker := time.NewTicker(200 * time.Millisecond)
-	for range ticker.C {
-		resp, err := dbs.GetRecords(&dynamodbstreams.GetRecordsInput{
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-it.ctx.Done():
+			return records, it.ctx.Err()
+		case <-ticker.C:
+		}
+
+		ctx := withShard(it.ctx, st.shard.id)
+		resp, err := dbs.GetRecordsWithContext(ctx, &dynamodbstreams.GetRecordsInput{
 			ShardIterator: shardIt,
 		})
 		if err != nil {
-			if err.(awserr.Error).Code() == errResourceNotFound {
+			if it.ctx.Err() != nil || awsErrCode(err) == errResourceNotFound {
 				return records, err
 			}
 
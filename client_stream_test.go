@@ -1,12 +1,18 @@
 package dynami
 
 import (
+	"context"
 	"testing"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
 	db "github.com/aws/aws-sdk-go/service/dynamodb"
 	dbattribute "github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	dbiface "github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/aws/aws-sdk-go/service/dynamodbstreams"
+	"github.com/aws/aws-sdk-go/service/dynamodbstreams/dynamodbstreamsiface"
 )
 
 type tItem struct {
@@ -15,7 +21,7 @@ type tItem struct {
 }
 
 func createStreamTable(
-	dbc *db.DynamoDB,
+	dbc dbiface.DynamoDBAPI,
 	tableName string,
 	streamViewType string) error {
 
@@ -362,3 +368,51 @@ func (suite *DatabaseTestSuite) TestGetStreamBackCompat() {
 	assert.Equal(DeletedRecord, rt)
 	assert.Equal(item, fetched)
 }
+
+func (suite *DatabaseTestSuite) TestGetStreamWithContext() {
+	assert := suite.Assert()
+	require := suite.Require()
+
+	tableName := "StreamContextTestTable"
+	err := createStreamTable(suite.db, tableName, db.StreamViewTypeNewImage)
+	require.Nil(err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	c := suite.client
+	it, err := c.GetStreamWithContext(ctx, tableName)
+	require.Nil(err)
+
+	// WaitNext should return once the context expires
+	start := time.Now()
+	assert.False(it.WaitNext())
+	assert.True(time.Since(start) < 10*time.Second)
+	assert.Nil(it.Err())
+
+	// Other errors stop the iteration
+	fc, err := NewClientFromAPI(suite.db, &tFailingStreams{})
+	require.Nil(err)
+
+	it, err = fc.GetStream(tableName)
+	require.Nil(err)
+	assert.False(it.HasNext())
+	assert.NotNil(it.Err())
+}
+
+// tFailingStreams fails every DescribeStream request.
+type tFailingStreams struct {
+	dynamodbstreamsiface.DynamoDBStreamsAPI
+}
+
+func (f *tFailingStreams) DescribeStreamWithContext(
+	ctx aws.Context,
+	input *dynamodbstreams.DescribeStreamInput,
+	opts ...request.Option) (*dynamodbstreams.DescribeStreamOutput, error) {
+
+	return &dynamodbstreams.DescribeStreamOutput{}, awserr.New(
+		dynamodbstreams.ErrCodeInternalServerError,
+		"internal error",
+		nil,
+	)
+}
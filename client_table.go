@@ -1,6 +1,7 @@
 package dynami
 
 import (
+	"context"
 	"fmt"
 	"reflect"
 
@@ -9,14 +10,22 @@ import (
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/aws/request"
 	db "github.com/aws/aws-sdk-go/service/dynamodb"
+	dbiface "github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
 )
 
 // CreateTable adds a new table to the current account.
 // This waits for the table to become useable or active
 // before returning.
 func (c *Client) CreateTable(table *schema.Table) error {
+	return c.CreateTableWithContext(aws.BackgroundContext(), table)
+}
+
+// CreateTableWithContext is the same as CreateTable with the addition
+// of a request context. This stops waiting if the context is canceled.
+func (c *Client) CreateTableWithContext(ctx context.Context, table *schema.Table) error {
 	input := &db.CreateTableInput{
 		TableName:              aws.String(table.Name),
 		AttributeDefinitions:   dbAttributeDefinitions(table.Attributes),
@@ -36,16 +45,16 @@ func (c *Client) CreateTable(table *schema.Table) error {
 	}
 
 	cdb := c.db
-	_, err := cdb.CreateTable(input)
+	_, err := cdb.CreateTableWithContext(ctx, input)
 	if err != nil {
-		return fmt.Errorf("dynami: cannot create table (%v)", err)
+		return newError("cannot create table", err)
 	}
 
-	err = cdb.WaitUntilTableExists(&db.DescribeTableInput{
+	err = cdb.WaitUntilTableExistsWithContext(ctx, &db.DescribeTableInput{
 		TableName: aws.String(table.Name),
 	})
 	if err != nil {
-		return fmt.Errorf("dynami: waiting for table creation failed (%v)", err)
+		return newError("waiting for table creation failed", err)
 	}
 	return err
 }
@@ -54,10 +63,16 @@ func (c *Client) CreateTable(table *schema.Table) error {
 // indices. It can create and delete global secondary indices or update
 // their throughputs. This method waits until all updates are finished.
 func (c *Client) UpdateTable(table *schema.Table) error {
+	return c.UpdateTableWithContext(aws.BackgroundContext(), table)
+}
+
+// UpdateTableWithContext is the same as UpdateTable with the addition
+// of a request context. This stops waiting if the context is canceled.
+func (c *Client) UpdateTableWithContext(ctx context.Context, table *schema.Table) error {
 	// Get unmodified table schema
-	origt, err := c.DescribeTable(table.Name)
+	origt, err := c.DescribeTableWithContext(ctx, table.Name)
 	if err != nil {
-		return fmt.Errorf("dynami: cannot update table (%v)", err)
+		return newError("cannot update table", err)
 	}
 
 	// Update table stream
@@ -71,39 +86,39 @@ func (c *Client) UpdateTable(table *schema.Table) error {
 			dbStreamSpec.StreamViewType = nil
 		}
 
-		_, err := cdb.UpdateTable(&db.UpdateTableInput{
+		_, err := cdb.UpdateTableWithContext(ctx, &db.UpdateTableInput{
 			TableName:           aws.String(table.Name),
 			StreamSpecification: dbStreamSpec,
 		})
 		if err != nil {
-			return fmt.Errorf("dynami: cannot update stream (%v)", err)
+			return newError("cannot update stream", err)
 		}
 
 		// Wait until table is finished updating
-		err = cdb.WaitUntilTableExists(&db.DescribeTableInput{
+		err = cdb.WaitUntilTableExistsWithContext(ctx, &db.DescribeTableInput{
 			TableName: aws.String(table.Name),
 		})
 		if err != nil {
-			return fmt.Errorf("dynami: waiting for table update failed (%v)", err)
+			return newError("waiting for table update failed", err)
 		}
 	}
 
 	// Update table's provisioned throughput
 	if table.Throughput != origt.Throughput {
-		_, err := cdb.UpdateTable(&db.UpdateTableInput{
+		_, err := cdb.UpdateTableWithContext(ctx, &db.UpdateTableInput{
 			TableName:             aws.String(table.Name),
 			ProvisionedThroughput: dbProvisionedThroughput(table.Throughput),
 		})
 		if err != nil {
-			return fmt.Errorf("dynami: cannot update table (%v)", err)
+			return newError("cannot update table", err)
 		}
 
 		// Wait until table is finished updating
-		err = cdb.WaitUntilTableExists(&db.DescribeTableInput{
+		err = cdb.WaitUntilTableExistsWithContext(ctx, &db.DescribeTableInput{
 			TableName: aws.String(table.Name),
 		})
 		if err != nil {
-			return fmt.Errorf("dynami: waiting for table update failed (%v)", err)
+			return newError("waiting for table update failed", err)
 		}
 	}
 
@@ -130,7 +145,7 @@ func (c *Client) UpdateTable(table *schema.Table) error {
 				IndexName: aws.String(name),
 			}
 
-			_, err := cdb.UpdateTable(&db.UpdateTableInput{
+			_, err := cdb.UpdateTableWithContext(ctx, &db.UpdateTableInput{
 				TableName: aws.String(table.Name),
 				GlobalSecondaryIndexUpdates: []*db.GlobalSecondaryIndexUpdate{
 					{
@@ -139,13 +154,13 @@ func (c *Client) UpdateTable(table *schema.Table) error {
 				},
 			})
 			if err != nil {
-				return fmt.Errorf("dynami: cannot delete global secondary index (%v)", err)
+				return newError("cannot delete global secondary index", err)
 			}
 
 			// Wait until all gsi's are active
-			err = waitUntilIndicesAreActive(cdb, table.Name)
+			err = waitUntilIndicesAreActive(ctx, cdb, table.Name)
 			if err != nil {
-				return fmt.Errorf("dynami: waiting for index update failed (%v)", err)
+				return newError("waiting for index update failed", err)
 			}
 		}
 	}
@@ -177,7 +192,7 @@ func (c *Client) UpdateTable(table *schema.Table) error {
 				})
 			}
 
-			_, err := cdb.UpdateTable(&db.UpdateTableInput{
+			_, err := cdb.UpdateTableWithContext(ctx, &db.UpdateTableInput{
 				TableName:            aws.String(table.Name),
 				AttributeDefinitions: attrDefs,
 				GlobalSecondaryIndexUpdates: []*db.GlobalSecondaryIndexUpdate{
@@ -187,13 +202,13 @@ func (c *Client) UpdateTable(table *schema.Table) error {
 				},
 			})
 			if err != nil {
-				return fmt.Errorf("dynami: cannot create global secondary index (%v)", err)
+				return newError("cannot create global secondary index", err)
 			}
 
 			// Wait until all gsi's are active
-			err = waitUntilIndicesAreActive(cdb, table.Name)
+			err = waitUntilIndicesAreActive(ctx, cdb, table.Name)
 			if err != nil {
-				return fmt.Errorf("dynami: waiting for index update failed (%v)", err)
+				return newError("waiting for index update failed", err)
 			}
 		} else if idx.Throughput != oidx.Throughput { // Update GSI
 			updateAction := &db.UpdateGlobalSecondaryIndexAction{
@@ -209,18 +224,18 @@ func (c *Client) UpdateTable(table *schema.Table) error {
 
 	// Perform update actions
 	if len(gsiUpdateActs) > 0 {
-		_, err := cdb.UpdateTable(&db.UpdateTableInput{
+		_, err := cdb.UpdateTableWithContext(ctx, &db.UpdateTableInput{
 			TableName:                   aws.String(table.Name),
 			GlobalSecondaryIndexUpdates: gsiUpdateActs,
 		})
 		if err != nil {
-			return fmt.Errorf("dynami: cannot update global secondary index (%v)", err)
+			return newError("cannot update global secondary index", err)
 		}
 
 		// Wait until all gsi's are active
-		err = waitUntilIndicesAreActive(cdb, table.Name)
+		err = waitUntilIndicesAreActive(ctx, cdb, table.Name)
 		if err != nil {
-			return fmt.Errorf("dynami: waiting for index update failed (%v)", err)
+			return newError("waiting for index update failed", err)
 		}
 	}
 
@@ -231,12 +246,21 @@ func (c *Client) UpdateTable(table *schema.Table) error {
 // This includes the table's creation date, size in bytes, and the number
 // of items it contains.
 func (c *Client) DescribeTable(tableName string) (*schema.Table, error) {
+	return c.DescribeTableWithContext(aws.BackgroundContext(), tableName)
+}
+
+// DescribeTableWithContext is the same as DescribeTable
+// with the addition of a request context.
+func (c *Client) DescribeTableWithContext(
+	ctx context.Context,
+	tableName string) (*schema.Table, error) {
+
 	cdb := c.db
-	resp, err := cdb.DescribeTable(&db.DescribeTableInput{
+	resp, err := cdb.DescribeTableWithContext(ctx, &db.DescribeTableInput{
 		TableName: aws.String(tableName),
 	})
 	if err != nil {
-		return nil, fmt.Errorf("dynami: cannot describe table (%v)", err)
+		return nil, newError("cannot describe table", err)
 	}
 
 	desc := resp.Table
@@ -264,12 +288,21 @@ func (c *Client) DescribeTable(tableName string) (*schema.Table, error) {
 // DeleteTable removes a table from the current account.
 // This blocks until the table no longer exists.
 func (c *Client) DeleteTable(tableName string) (*schema.Table, error) {
+	return c.DeleteTableWithContext(aws.BackgroundContext(), tableName)
+}
+
+// DeleteTableWithContext is the same as DeleteTable with the addition
+// of a request context. This stops waiting if the context is canceled.
+func (c *Client) DeleteTableWithContext(
+	ctx context.Context,
+	tableName string) (*schema.Table, error) {
+
 	cdb := c.db
-	resp, err := cdb.DeleteTable(&db.DeleteTableInput{
+	resp, err := cdb.DeleteTableWithContext(ctx, &db.DeleteTableInput{
 		TableName: aws.String(tableName),
 	})
 	if err != nil {
-		return nil, fmt.Errorf("dynami: cannot delete table (%v)", err)
+		return nil, newError("cannot delete table", err)
 	}
 
 	desc := resp.TableDescription
@@ -293,11 +326,11 @@ func (c *Client) DeleteTable(tableName string) (*schema.Table, error) {
 		table.PStreamARN = *desc.LatestStreamArn
 	}
 
-	err = cdb.WaitUntilTableNotExists(&db.DescribeTableInput{
+	err = cdb.WaitUntilTableNotExistsWithContext(ctx, &db.DescribeTableInput{
 		TableName: aws.String(tableName),
 	})
 	if err != nil {
-		return table, fmt.Errorf("dynami: waiting for table deletion failed (%v)", err)
+		return table, newError("waiting for table deletion failed", err)
 	}
 	return table, nil
 }
@@ -305,14 +338,32 @@ func (c *Client) DeleteTable(tableName string) (*schema.Table, error) {
 // ClearTable removes all items from a table.
 // This is achieved by deleting items by batch.
 func (c *Client) ClearTable(tableName string) error {
-	desc, err := c.DescribeTable(tableName)
+	return c.ClearTableWithContext(aws.BackgroundContext(), tableName)
+}
+
+// ClearTableWithContext is the same as ClearTable
+// with the addition of a request context.
+func (c *Client) ClearTableWithContext(ctx context.Context, tableName string) error {
+	desc, err := c.DescribeTableWithContext(ctx, tableName)
 	if err != nil {
 		return fmt.Errorf("dynami: cannot clear table")
 	}
 
 	const keysPerBatch = 25
+	const clearSegments = 4
 	keySchema := desc.Key
-	it := c.Query(tableName).Consistent().Run()
+	keyNames := make([]string, len(keySchema))
+	for i, k := range keySchema {
+		keyNames[i] = k.Name
+	}
+
+	it := c.Query(tableName).
+		Consistent().
+		Select(keyNames...).
+		Parallel(clearSegments).
+		RunWithContext(ctx)
+	defer it.Close()
+
 	keys := make([]map[string]interface{}, 0, keysPerBatch)
 	for it.HasNext() {
 		var item map[string]interface{}
@@ -325,18 +376,22 @@ func (c *Client) ClearTable(tableName string) error {
 		keys = append(keys, key)
 
 		if len(keys) == keysPerBatch {
-			err = c.BatchDelete(tableName, keys).Run()
+			err = c.BatchDelete(tableName, keys).RunWithContext(ctx)
 			if err != nil {
-				return fmt.Errorf("dynami: cannot clear table (%v)", err)
+				return fmt.Errorf("dynami: cannot clear table (%w)", err)
 			}
-			keys = keys[0:]
+			keys = keys[:0]
 		}
 	}
 
+	if err = it.Err(); err != nil {
+		return fmt.Errorf("dynami: cannot clear table (%w)", err)
+	}
+
 	if len(keys) > 0 {
-		err = c.BatchDelete(tableName, keys).Run()
+		err = c.BatchDelete(tableName, keys).RunWithContext(ctx)
 		if err != nil {
-			return fmt.Errorf("dynami: cannot clear table (%v)", err)
+			return fmt.Errorf("dynami: cannot clear table (%w)", err)
 		}
 	}
 
@@ -346,24 +401,31 @@ func (c *Client) ClearTable(tableName string) error {
 // ListTables returns all table names
 // associated with the current account.
 func (c *Client) ListTables() ([]string, error) {
+	return c.ListTablesWithContext(aws.BackgroundContext())
+}
+
+// ListTablesWithContext is the same as ListTables
+// with the addition of a request context.
+func (c *Client) ListTablesWithContext(ctx context.Context) ([]string, error) {
 	cdb := c.db
 	tables := []string{}
 
 	inp := &db.ListTablesInput{}
-	resp, err := cdb.ListTables(inp)
-	for _, t := range resp.TableNames {
-		tables = append(tables, *t)
-	}
+	for {
+		resp, err := cdb.ListTablesWithContext(ctx, inp)
+		if err != nil {
+			return tables, newError("cannot list tables", err)
+		}
 
-	for err == nil && resp.LastEvaluatedTableName != nil {
-		inp.ExclusiveStartTableName = resp.LastEvaluatedTableName
-		resp, err = cdb.ListTables(inp)
 		for _, t := range resp.TableNames {
 			tables = append(tables, *t)
 		}
-	}
 
-	return tables, err
+		if resp.LastEvaluatedTableName == nil {
+			return tables, nil
+		}
+		inp.ExclusiveStartTableName = resp.LastEvaluatedTableName
+	}
 }
 
 func dbKeySchema(ks []schema.Key) []*db.KeySchemaElement {
@@ -547,27 +609,54 @@ func streamEnabled(dbStreamSpec *db.StreamSpecification) bool {
 	return *dbStreamSpec.StreamEnabled
 }
 
-func waitUntilIndicesAreActive(c *db.DynamoDB, tableName string) error {
-	w := request.Waiter{
-		Name:        "WaitUntilIndicesAreActive",
-		MaxAttempts: 25,
-		Delay:       request.ConstantWaiterDelay(20 * time.Second),
-		Acceptors: []request.WaiterAcceptor{
-			{
-				State:    request.SuccessWaiterState,
-				Matcher:  request.PathWaiterMatch,
-				Argument: "Table.GlobalSecondaryIndexes[*].IndexStatus",
-				Expected: string(schema.ActiveStatus),
-			},
-		},
-		Logger: c.Config.Logger,
-		NewRequest: func(opts []request.Option) (*request.Request, error) {
-			req, _ := c.DescribeTableRequest(&db.DescribeTableInput{
-				TableName: aws.String(tableName),
-			})
-			return req, nil
-		},
+// waitUntilIndicesAreActive polls the table until all of its
+// global secondary indexes are active. Each poll is a regular
+// DescribeTable request so that it passes through the client's
+// middleware.
+func waitUntilIndicesAreActive(ctx context.Context, c dbiface.DynamoDBAPI, tableName string) error {
+	const (
+		maxAttempts = 25
+		delay       = 20 * time.Second
+	)
+
+	for attempt := 1; ; attempt++ {
+		resp, err := c.DescribeTableWithContext(ctx, &db.DescribeTableInput{
+			TableName: aws.String(tableName),
+		})
+		if err != nil {
+			return err
+		} else if indicesAreActive(resp.Table) {
+			return nil
+		} else if attempt >= maxAttempts {
+			return awserr.New(
+				request.WaiterResourceNotReadyErrorCode,
+				"exceeded wait attempts",
+				nil,
+			)
+		}
+
+		t := time.NewTimer(delay)
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			t.Stop()
+			return awserr.New(request.CanceledErrorCode, "waiter context canceled", ctx.Err())
+		}
+	}
+}
+
+// indicesAreActive returns true if all of
+// the global secondary indexes of t are active.
+func indicesAreActive(t *db.TableDescription) bool {
+	if t == nil {
+		return false
+	}
+
+	for _, idx := range t.GlobalSecondaryIndexes {
+		if aws.StringValue(idx.IndexStatus) != string(schema.ActiveStatus) {
+			return false
+		}
 	}
 
-	return w.WaitWithContext(aws.BackgroundContext())
+	return true
 }
@@ -1,6 +1,8 @@
 package dynami
 
 import (
+	"context"
+	"errors"
 	"strconv"
 	"testing"
 
@@ -9,8 +11,11 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
 	db "github.com/aws/aws-sdk-go/service/dynamodb"
 	dbattribute "github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	dbiface "github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
 )
 
 func assertEqualIndices(
@@ -430,6 +435,31 @@ func (suite *DatabaseTestSuite) TestListTable() {
 	for _, t := range tables {
 		assert.Contains(actualTables, t)
 	}
+
+	// Failed requests return an error
+	fc, err := NewClientFromAPI(&tFailingDB{DynamoDBAPI: sdb}, nil)
+	require.Nil(err)
+
+	_, err = fc.ListTables()
+	assert.NotNil(err)
+	assert.True(errors.Is(err, ErrThrottled))
+}
+
+// tFailingDB fails every ListTables request.
+type tFailingDB struct {
+	dbiface.DynamoDBAPI
+}
+
+func (f *tFailingDB) ListTablesWithContext(
+	ctx aws.Context,
+	input *db.ListTablesInput,
+	opts ...request.Option) (*db.ListTablesOutput, error) {
+
+	return &db.ListTablesOutput{}, awserr.New(
+		db.ErrCodeProvisionedThroughputExceededException,
+		"throttled",
+		nil,
+	)
 }
 
 func (suite *DatabaseTestSuite) TestUpdateTable() {
@@ -574,11 +604,33 @@ func (suite *DatabaseTestSuite) TestUpdateTable() {
 		Write: 2,
 	}
 
-	// Perform update
-	c := suite.client
+	// Perform update, recording the operations
+	// that pass through the client's middleware
+	c, err := NewClientFromAPI(suite.db, nil)
+	require.Nil(err)
+
+	var ops []string
+	c.Use(func(next Handler) Handler {
+		return func(ctx context.Context, op *Operation) error {
+			ops = append(ops, op.Name)
+			return next(ctx, op)
+		}
+	})
+
 	err = c.UpdateTable(table)
 	require.Nil(err)
 
+	// Index status polls after the last
+	// update go through the middleware
+	last := -1
+	for i, name := range ops {
+		if name == "UpdateTable" {
+			last = i
+		}
+	}
+	require.True(last >= 0)
+	assert.Contains(ops[last+1:], "DescribeTable")
+
 	resp, err := sdb.DescribeTable(&db.DescribeTableInput{
 		TableName: aws.String(table.Name),
 	})
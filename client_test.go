@@ -3,17 +3,22 @@ package dynami
 import (
 	"flag"
 	"log"
+	"net/http"
 	"os"
 	"os/exec"
 	"os/user"
 	"testing"
+	"time"
 
+	"github.com/robskie/dynami/dynamitest/memdb"
 	"github.com/stretchr/testify/suite"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/awstesting/unit"
 	db "github.com/aws/aws-sdk-go/service/dynamodb"
+	dbiface "github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
 )
 
 type tInfo struct {
@@ -42,8 +47,9 @@ type DatabaseTestSuite struct {
 
 	proc *os.Process
 
-	db     *db.DynamoDB
+	db     dbiface.DynamoDBAPI
 	client *Client
+	region *Region
 }
 
 func (suite *DatabaseTestSuite) deleteAllTablesExcept(except ...string) {
@@ -267,7 +273,16 @@ func (suite *DatabaseTestSuite) createBookTable() {
 }
 
 func (suite *DatabaseTestSuite) SetupSuite() {
-	if *onlineFlag == false {
+	if *memoryFlag {
+		mdb := memdb.New()
+		suite.db = mdb
+
+		var err error
+		suite.client, err = NewClientFromAPI(mdb, mdb.Streams())
+		if err != nil {
+			log.Fatal(err)
+		}
+	} else if *onlineFlag == false {
 		// Start local dynamoDB instance
 		usr, _ := user.Current()
 		cmd := &exec.Cmd{
@@ -295,12 +310,12 @@ func (suite *DatabaseTestSuite) SetupSuite() {
 		)
 		suite.db = db.New(session)
 
-		testRegion := &Region{
+		suite.region = &Region{
 			"test-region",
 			"http://localhost:8000",
 			"http://localhost:8000",
 		}
-		suite.client = NewClient(testRegion, "test-id", "test-key")
+		suite.client = NewClient(suite.region, "test-id", "test-key")
 	} else {
 		testRegion := os.Getenv("DYNAMI_TEST_REGION")
 		if testRegion == "" {
@@ -315,14 +330,11 @@ func (suite *DatabaseTestSuite) SetupSuite() {
 		}
 		suite.db = db.New(session)
 
-		creds, err := session.Config.Credentials.Get()
+		suite.region = GetRegion(testRegion)
+		suite.client, err = NewClientWithOptions(WithRegion(suite.region))
 		if err != nil {
 			log.Fatal(err)
 		}
-		suite.client = NewClient(
-			GetRegion(testRegion),
-			creds.AccessKeyID,
-			creds.SecretAccessKey)
 	}
 
 	suite.createBookTable()
@@ -343,8 +355,151 @@ func (suite *DatabaseTestSuite) TearDownTest() {
 }
 
 var onlineFlag = flag.Bool("online", false, "runs the tests on a remote database")
+var memoryFlag = flag.Bool("memory", false, "runs the tests on an in-memory database")
 
 func TestDatabaseTestSuite(t *testing.T) {
 	flag.Parse()
 	suite.Run(t, new(DatabaseTestSuite))
 }
+
+func (suite *DatabaseTestSuite) TestNewClientWithOptions() {
+	assert := suite.Assert()
+	require := suite.Require()
+
+	if *memoryFlag {
+		suite.T().Skip("requires a DynamoDB endpoint")
+	}
+
+	opts := []Option{
+		WithRegion(suite.region),
+		WithMaxRetries(0),
+		WithHTTPClient(&http.Client{Timeout: 10 * time.Second}),
+	}
+	if *onlineFlag == false {
+		opts = append(opts, WithStaticCredentials("test-id", "test-key", ""))
+	}
+
+	c, err := NewClientWithOptions(opts...)
+	require.Nil(err)
+
+	tables, err := c.ListTables()
+	require.Nil(err)
+	assert.Contains(tables, "Book")
+
+	// Invalid options
+	_, err = NewClientWithOptions(WithRegion(nil))
+	assert.NotNil(err)
+
+	_, err = NewClientWithOptions(WithMaxRetries(-1))
+	assert.NotNil(err)
+}
+
+// tFakeDB counts the GetItem requests
+// that pass through a DynamoDB API.
+type tFakeDB struct {
+	dbiface.DynamoDBAPI
+
+	gets int
+}
+
+func (f *tFakeDB) GetItemWithContext(
+	ctx aws.Context,
+	input *db.GetItemInput,
+	opts ...request.Option) (*db.GetItemOutput, error) {
+
+	f.gets++
+	return f.DynamoDBAPI.GetItemWithContext(ctx, input, opts...)
+}
+
+// tThrottledDB throttles the first throttles batch
+// requests, or all of them if throttles is negative.
+// A throttled request returns all of its items as
+// unprocessed. If partial is set, a throttled write
+// request still writes the first item of each table
+// and only returns the rest as unprocessed.
+type tThrottledDB struct {
+	dbiface.DynamoDBAPI
+
+	throttles int
+	requests  int
+	partial   bool
+}
+
+func (f *tThrottledDB) BatchWriteItemWithContext(
+	ctx aws.Context,
+	input *db.BatchWriteItemInput,
+	opts ...request.Option) (*db.BatchWriteItemOutput, error) {
+
+	f.requests++
+	if f.throttles != 0 && f.partial {
+		f.throttles--
+
+		first := map[string][]*db.WriteRequest{}
+		unproc := map[string][]*db.WriteRequest{}
+		for table, reqs := range input.RequestItems {
+			first[table] = reqs[:1]
+			if len(reqs) > 1 {
+				unproc[table] = reqs[1:]
+			}
+		}
+
+		_, err := f.DynamoDBAPI.BatchWriteItemWithContext(ctx, &db.BatchWriteItemInput{
+			RequestItems: first,
+		}, opts...)
+		if err != nil {
+			return nil, err
+		}
+		return &db.BatchWriteItemOutput{UnprocessedItems: unproc}, nil
+	} else if f.throttles != 0 {
+		f.throttles--
+		return &db.BatchWriteItemOutput{UnprocessedItems: input.RequestItems}, nil
+	}
+
+	return f.DynamoDBAPI.BatchWriteItemWithContext(ctx, input, opts...)
+}
+
+func (f *tThrottledDB) BatchGetItemWithContext(
+	ctx aws.Context,
+	input *db.BatchGetItemInput,
+	opts ...request.Option) (*db.BatchGetItemOutput, error) {
+
+	f.requests++
+	if f.throttles != 0 {
+		f.throttles--
+		return &db.BatchGetItemOutput{UnprocessedKeys: input.RequestItems}, nil
+	}
+
+	return f.DynamoDBAPI.BatchGetItemWithContext(ctx, input, opts...)
+}
+
+func (suite *DatabaseTestSuite) TestNewClientFromAPI() {
+	assert := suite.Assert()
+	require := suite.Require()
+
+	fake := &tFakeDB{DynamoDBAPI: suite.db}
+	c, err := NewClientFromAPI(fake, nil)
+	require.Nil(err)
+
+	quote := tQuote{
+		Author: "Oscar Wilde",
+		Text:   "Be yourself; everyone else is already taken.",
+	}
+	err = c.PutItem("Quote", quote)
+	require.Nil(err)
+
+	fetched := tQuote{Author: quote.Author, Text: quote.Text}
+	err = c.GetItem("Quote", &fetched)
+	require.Nil(err)
+	assert.Equal(quote, fetched)
+	assert.Equal(1, fake.gets)
+
+	// Streams are not available
+	_, err = c.GetStream("Quote")
+	assert.NotNil(err)
+
+	_, err = NewClientFromAPI(nil, nil)
+	assert.NotNil(err)
+
+	_, err = NewClientFromAPI(fake, nil, WithBatchRetry(RetryPolicy{Jitter: 2}))
+	assert.NotNil(err)
+}
@@ -0,0 +1,434 @@
+package dynami
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/aws/aws-sdk-go/aws"
+	db "github.com/aws/aws-sdk-go/service/dynamodb"
+	dbiface "github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+)
+
+// TransactionCanceledError is returned when a transaction is
+// canceled. It maps the index of each step that caused the
+// cancellation to its error. Steps are indexed in the order
+// they are added to the transaction.
+type TransactionCanceledError map[int]error
+
+func (e TransactionCanceledError) Error() string {
+	return "dynami: transaction canceled"
+}
+
+// Unwrap returns the errors of the
+// steps sorted by their index.
+func (e TransactionCanceledError) Unwrap() []error {
+	return unwrapErrors(e)
+}
+
+// transactStep contains a transaction step and the
+// information needed to interpret its cancellation.
+type transactStep struct {
+	item *db.TransactWriteItem
+
+	// condFailed is returned if the
+	// condition of this step has failed.
+	condFailed error
+
+	// version is incremented after
+	// the transaction succeeds.
+	version *itemVersion
+}
+
+// Transaction represents a write transaction. It can put,
+// delete, update, and check the condition of multiple items
+// in multiple tables in one atomic operation. Either all of
+// its steps succeed or none of them do.
+type Transaction struct {
+	db    dbiface.DynamoDBAPI
+	steps []transactStep
+
+	// emptyValues returns how empty values
+	// are written to the given table.
+	emptyValues func(tableName string) EmptyValues
+
+	err error
+}
+
+// Transaction returns a new empty write transaction.
+func (c *Client) Transaction() *Transaction {
+	return &Transaction{db: c.db, emptyValues: c.emptyValuesOf}
+}
+
+// Put adds a put step to the transaction. item must satisfy
+// the same conditions as that in PutItem, including versioning.
+func (t *Transaction) Put(tableName string, item interface{}) *Transaction {
+	return t.put(tableName, item, nil)
+}
+
+// PutIf adds a conditional put step to the
+// transaction. See PutItemIf for more details.
+func (t *Transaction) PutIf(
+	tableName string,
+	item interface{},
+	expr string,
+	values ...interface{}) *Transaction {
+
+	if t.err != nil {
+		return t
+	}
+
+	cond, err := parseCondition(expr, values)
+	if err != nil {
+		t.err = err
+		return t
+	}
+
+	return t.put(tableName, item, cond)
+}
+
+func (t *Transaction) put(tableName string, item interface{}, cond *condition) *Transaction {
+	if t.err != nil {
+		return t
+	}
+
+	empty := t.emptyValues(tableName)
+	input, version, err := newPutItemInput(tableName, item, cond, empty)
+	if err != nil {
+		t.err = err
+		return t
+	}
+
+	t.steps = append(t.steps, transactStep{
+		item: &db.TransactWriteItem{
+			Put: &db.Put{
+				TableName:                 input.TableName,
+				Item:                      input.Item,
+				ConditionExpression:       input.ConditionExpression,
+				ExpressionAttributeNames:  input.ExpressionAttributeNames,
+				ExpressionAttributeValues: input.ExpressionAttributeValues,
+			},
+		},
+		condFailed: conditionFailed(cond, version),
+		version:    version,
+	})
+	return t
+}
+
+// Delete adds a delete step to the transaction. item must satisfy
+// the same conditions as that in DeleteItem, including versioning.
+func (t *Transaction) Delete(tableName string, item interface{}) *Transaction {
+	return t.delete(tableName, item, nil)
+}
+
+// DeleteIf adds a conditional delete step to the
+// transaction. See DeleteItemIf for more details.
+func (t *Transaction) DeleteIf(
+	tableName string,
+	item interface{},
+	expr string,
+	values ...interface{}) *Transaction {
+
+	if t.err != nil {
+		return t
+	}
+
+	cond, err := parseCondition(expr, values)
+	if err != nil {
+		t.err = err
+		return t
+	}
+
+	return t.delete(tableName, item, cond)
+}
+
+func (t *Transaction) delete(tableName string, item interface{}, cond *condition) *Transaction {
+	if t.err != nil {
+		return t
+	}
+
+	input, version, err := newDeleteItemInput(tableName, item, cond)
+	if err != nil {
+		t.err = err
+		return t
+	}
+
+	t.steps = append(t.steps, transactStep{
+		item: &db.TransactWriteItem{
+			Delete: &db.Delete{
+				TableName:                 input.TableName,
+				Key:                       input.Key,
+				ConditionExpression:       input.ConditionExpression,
+				ExpressionAttributeNames:  input.ExpressionAttributeNames,
+				ExpressionAttributeValues: input.ExpressionAttributeValues,
+			},
+		},
+		condFailed: conditionFailed(cond, version),
+	})
+	return t
+}
+
+// Update adds an update step to the transaction. update is
+// created using Client.Update and may have conditions. Its
+// return values are ignored.
+func (t *Transaction) Update(update *Update) *Transaction {
+	if t.err != nil {
+		return t
+	} else if update.err != nil {
+		t.err = update.err
+		return t
+	}
+
+	input, err := update.input()
+	if err != nil {
+		t.err = err
+		return t
+	}
+
+	t.steps = append(t.steps, transactStep{
+		item: &db.TransactWriteItem{
+			Update: &db.Update{
+				TableName:                 input.TableName,
+				Key:                       input.Key,
+				UpdateExpression:          input.UpdateExpression,
+				ConditionExpression:       input.ConditionExpression,
+				ExpressionAttributeNames:  input.ExpressionAttributeNames,
+				ExpressionAttributeValues: input.ExpressionAttributeValues,
+			},
+		},
+		condFailed: update.conditionFailed(),
+	})
+	return t
+}
+
+// ConditionCheck adds a step that checks whether the condition
+// expression expr holds for the item with the given key without
+// modifying it. If it doesn't, the whole transaction is canceled.
+// key must satisfy the same conditions as that in Client.Update.
+func (t *Transaction) ConditionCheck(
+	tableName string,
+	key interface{},
+	expr string,
+	values ...interface{}) *Transaction {
+
+	if t.err != nil {
+		return t
+	}
+
+	err := checkType(key, reflect.Struct, map[string]interface{}{})
+	if err != nil {
+		t.err = err
+		return t
+	}
+
+	k, err := getPrimaryKey(key)
+	if err != nil {
+		t.err = err
+		return t
+	}
+
+	cond, err := parseCondition(expr, values)
+	if err != nil {
+		t.err = err
+		return t
+	}
+
+	t.steps = append(t.steps, transactStep{
+		item: &db.TransactWriteItem{
+			ConditionCheck: &db.ConditionCheck{
+				TableName:                 aws.String(tableName),
+				Key:                       k.value,
+				ConditionExpression:       aws.String(cond.expr),
+				ExpressionAttributeNames:  cond.attributeNames,
+				ExpressionAttributeValues: cond.attributeValues,
+			},
+		},
+		condFailed: ErrConditionFailed,
+	})
+	return t
+}
+
+// Run executes all the steps of this transaction
+// atomically. This may return a TransactionCanceledError.
+func (t *Transaction) Run() error {
+	return t.RunWithContext(aws.BackgroundContext())
+}
+
+// RunWithContext is the same as Run with
+// the addition of a request context.
+func (t *Transaction) RunWithContext(ctx context.Context) error {
+	const maxStepsPerOp = 100
+
+	if t.err != nil {
+		return t.err
+	} else if len(t.steps) == 0 {
+		return fmt.Errorf("dynami: empty transaction")
+	} else if len(t.steps) > maxStepsPerOp {
+		return fmt.Errorf("dynami: transaction has more than %d steps", maxStepsPerOp)
+	}
+
+	items := make([]*db.TransactWriteItem, len(t.steps))
+	for i, step := range t.steps {
+		items[i] = step.item
+	}
+
+	_, err := t.db.TransactWriteItemsWithContext(ctx, &db.TransactWriteItemsInput{
+		TransactItems: items,
+	})
+	var cerr *db.TransactionCanceledException
+	if errors.As(err, &cerr) {
+		return t.canceled(cerr.CancellationReasons)
+	} else if err != nil {
+		return newError("transaction failed", err)
+	}
+
+	for _, step := range t.steps {
+		step.version.increment()
+	}
+
+	return nil
+}
+
+// canceled maps each cancellation
+// reason to its step's error.
+func (t *Transaction) canceled(reasons []*db.CancellationReason) error {
+	terr := TransactionCanceledError{}
+	for i, r := range reasons {
+		if i >= len(t.steps) {
+			break
+		} else if r == nil || r.Code == nil {
+			continue
+		}
+
+		switch code := *r.Code; code {
+		case "None":
+			continue
+		case "ConditionalCheckFailed":
+			terr[i] = t.steps[i].condFailed
+		default:
+			terr[i] = reasonError("transaction step failed", r)
+		}
+	}
+
+	return terr
+}
+
+// TransactGetError is returned when some of the items in
+// a TransactGet can't be fetched. It maps the index of each
+// failed item, in the order they are added, to its error.
+type TransactGetError map[int]error
+
+func (e TransactGetError) Error() string {
+	return "dynami: an error occurred in one of the items"
+}
+
+// Unwrap returns the errors of the
+// items sorted by their index.
+func (e TransactGetError) Unwrap() []error {
+	return unwrapErrors(e)
+}
+
+// TransactGet represents a read transaction. It fetches
+// multiple items from multiple tables as a single atomic
+// snapshot.
+type TransactGet struct {
+	db dbiface.DynamoDBAPI
+
+	gets  []*db.TransactGetItem
+	items []interface{}
+
+	err error
+}
+
+// TransactGet returns a new empty read transaction.
+func (c *Client) TransactGet() *TransactGet {
+	return &TransactGet{db: c.db}
+}
+
+// Get adds an item to fetch. item must be a pointer to a
+// map[string]interface{} or a pointer to a struct with a
+// nonempty primary key. The fetched item is loaded into it.
+func (t *TransactGet) Get(tableName string, item interface{}) *TransactGet {
+	if t.err != nil {
+		return t
+	}
+
+	err := checkPtrType(item, reflect.Struct, map[string]interface{}{})
+	if err != nil {
+		t.err = err
+		return t
+	}
+
+	key, err := getPrimaryKey(item)
+	if err != nil {
+		t.err = err
+		return t
+	}
+
+	t.gets = append(t.gets, &db.TransactGetItem{
+		Get: &db.Get{
+			TableName: aws.String(tableName),
+			Key:       key.value,
+		},
+	})
+	t.items = append(t.items, item)
+	return t
+}
+
+// Run fetches all the items in this transaction. This may
+// return a TransactGetError containing ErrNoSuchItem for
+// items that don't exist, or a TransactionCanceledError if
+// the transaction is canceled.
+func (t *TransactGet) Run() error {
+	return t.RunWithContext(aws.BackgroundContext())
+}
+
+// RunWithContext is the same as Run with
+// the addition of a request context.
+func (t *TransactGet) RunWithContext(ctx context.Context) error {
+	const maxGetsPerOp = 100
+
+	if t.err != nil {
+		return t.err
+	} else if len(t.gets) == 0 {
+		return fmt.Errorf("dynami: empty transaction")
+	} else if len(t.gets) > maxGetsPerOp {
+		return fmt.Errorf("dynami: transaction has more than %d items", maxGetsPerOp)
+	}
+
+	resp, err := t.db.TransactGetItemsWithContext(ctx, &db.TransactGetItemsInput{
+		TransactItems: t.gets,
+	})
+	var cerr *db.TransactionCanceledException
+	if errors.As(err, &cerr) {
+		terr := TransactionCanceledError{}
+		for i, r := range cerr.CancellationReasons {
+			if r.Code != nil && *r.Code != "None" {
+				terr[i] = reasonError("transaction item failed", r)
+			}
+		}
+		return terr
+	} else if err != nil {
+		return newError("transaction failed", err)
+	}
+
+	terr := TransactGetError{}
+	for i, item := range t.items {
+		if i >= len(resp.Responses) || len(resp.Responses[i].Item) == 0 {
+			terr[i] = ErrNoSuchItem
+			continue
+		}
+
+		err = unmarshalItem(resp.Responses[i].Item, item)
+		if err != nil {
+			terr[i] = fmt.Errorf("dynami: invalid item (%w)", err)
+		}
+	}
+
+	if len(terr) > 0 {
+		return terr
+	}
+
+	return nil
+}
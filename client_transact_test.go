@@ -0,0 +1,213 @@
+package dynami
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	db "github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+func (suite *DatabaseTestSuite) TestTransaction() {
+	assert := suite.Assert()
+	require := suite.Require()
+
+	book := tBook{
+		Title:  "Dune",
+		Author: "Frank Herbert",
+		Genre:  "Science Fiction",
+	}
+	quote := tQuote{
+		Author: "Frank Herbert",
+		Text:   "Fear is the mind-killer.",
+		Topic:  "Fear",
+	}
+
+	c := suite.client
+	err := c.Transaction().
+		PutIf("Book", book, "attribute_not_exists(Title)").
+		Put("Quote", quote).
+		Run()
+	require.Nil(err)
+
+	fetchedBook := tBook{Title: book.Title, Author: book.Author}
+	err = c.GetItem("Book", &fetchedBook, true)
+	require.Nil(err)
+	assert.Equal(book, fetchedBook)
+
+	fetchedQuote := tQuote{Author: quote.Author, Text: quote.Text}
+	err = c.GetItem("Quote", &fetchedQuote, true)
+	require.Nil(err)
+	assert.Equal(quote, fetchedQuote)
+
+	// Cancel the transaction using a failing condition
+	// check on an item that is not written. DynamoDB
+	// doesn't allow multiple steps on the same item.
+	other := tBook{
+		Title:  "Dune Messiah",
+		Author: "Frank Herbert",
+		Genre:  "Science Fiction",
+	}
+	err = c.PutItem("Book", other)
+	require.Nil(err)
+
+	err = c.Transaction().
+		Update(c.Update("Book", book).Set("Genre", "Fiction")).
+		Delete("Quote", quote).
+		ConditionCheck("Book", other, "Genre = :genre", "Fantasy").
+		Run()
+	require.NotNil(err)
+
+	terr, ok := err.(TransactionCanceledError)
+	require.True(ok)
+	require.Len(terr, 1)
+	assert.Equal(ErrConditionFailed, terr[2])
+
+	// Nothing should have changed
+	err = c.GetItem("Book", &fetchedBook, true)
+	require.Nil(err)
+	assert.Equal(book, fetchedBook)
+
+	err = c.GetItem("Quote", &fetchedQuote, true)
+	require.Nil(err)
+	assert.Equal(quote, fetchedQuote)
+
+	// Cancellations are found in wrapped errors
+	wc, err := NewClientFromAPI(suite.db, nil)
+	require.Nil(err)
+	wc.Use(func(next Handler) Handler {
+		return func(ctx context.Context, op *Operation) error {
+			if err := next(ctx, op); err != nil {
+				return fmt.Errorf("wrapped (%w)", err)
+			}
+			return nil
+		}
+	})
+
+	err = wc.Transaction().
+		Update(wc.Update("Book", book).Set("Genre", "Fiction")).
+		ConditionCheck("Book", other, "Genre = :genre", "Fantasy").
+		Run()
+	require.NotNil(err)
+
+	terr, ok = err.(TransactionCanceledError)
+	require.True(ok)
+	require.Len(terr, 1)
+	assert.Equal(ErrConditionFailed, terr[1])
+
+	// Reasons without a code are skipped
+	tr := c.Transaction().
+		ConditionCheck("Book", book, "Genre = :genre", "Fantasy").
+		ConditionCheck("Book", other, "Genre = :genre", "Fantasy")
+	terr, ok = tr.canceled([]*db.CancellationReason{
+		{},
+		{Code: aws.String("ConditionalCheckFailed")},
+	}).(TransactionCanceledError)
+	require.True(ok)
+	require.Len(terr, 1)
+	assert.Equal(ErrConditionFailed, terr[1])
+
+	// Multiple steps on the same item are rejected
+	// even if one of their conditions fails
+	err = c.Transaction().
+		Update(c.Update("Book", book).Set("Genre", "Fiction")).
+		ConditionCheck("Book", book, "Genre = :genre", "Fantasy").
+		Run()
+	require.NotNil(err)
+	assert.True(errors.Is(err, ErrValidation))
+
+	err = c.Transaction().
+		Update(c.Update("Book", book).Set("Genre", "Fiction")).
+		DeleteIf("Quote", quote, "Topic = :topic", "Fear").
+		Run()
+	require.Nil(err)
+
+	err = c.GetItem("Book", &fetchedBook, true)
+	require.Nil(err)
+	assert.Equal("Fiction", fetchedBook.Genre)
+
+	err = c.GetItem("Quote", &fetchedQuote, true)
+	assert.Equal(ErrNoSuchItem, err)
+
+	// Empty transaction
+	err = c.Transaction().Run()
+	assert.NotNil(err)
+}
+
+func (suite *DatabaseTestSuite) TestTransactGet() {
+	assert := suite.Assert()
+	require := suite.Require()
+
+	book := tBook{
+		Title:  "Dune",
+		Author: "Frank Herbert",
+		Genre:  "Science Fiction",
+	}
+	quote := tQuote{
+		Author: "Frank Herbert",
+		Text:   "Fear is the mind-killer.",
+		Topic:  "Fear",
+	}
+
+	c := suite.client
+	err := c.PutItem("Book", book)
+	require.Nil(err)
+	err = c.PutItem("Quote", quote)
+	require.Nil(err)
+
+	fetchedBook := tBook{Title: book.Title, Author: book.Author}
+	fetchedQuote := map[string]interface{}{
+		"Author": quote.Author,
+		"Text":   quote.Text,
+	}
+	err = c.TransactGet().
+		Get("Book", &fetchedBook).
+		Get("Quote", &fetchedQuote).
+		Run()
+	require.Nil(err)
+	assert.Equal(book, fetchedBook)
+	assert.Equal(quote.Topic, fetchedQuote["Topic"])
+
+	// Fetch unknown item
+	unknown := tQuote{Author: randString(15), Text: randString(20)}
+	err = c.TransactGet().
+		Get("Book", &fetchedBook).
+		Get("Quote", &unknown).
+		Run()
+	require.NotNil(err)
+
+	terr, ok := err.(TransactGetError)
+	require.True(ok)
+	require.Len(terr, 1)
+	assert.Equal(ErrNoSuchItem, terr[1])
+
+	// Cancellations are found in wrapped errors
+	wc, err := NewClientFromAPI(suite.db, nil)
+	require.Nil(err)
+	wc.Use(func(next Handler) Handler {
+		return func(ctx context.Context, op *Operation) error {
+			return fmt.Errorf("wrapped (%w)", &db.TransactionCanceledException{
+				CancellationReasons: []*db.CancellationReason{
+					{Code: aws.String("None")},
+					{Code: aws.String("TransactionConflict")},
+				},
+			})
+		}
+	})
+
+	err = wc.TransactGet().
+		Get("Book", &fetchedBook).
+		Get("Quote", &fetchedQuote).
+		Run()
+	require.NotNil(err)
+
+	cerr, ok := err.(TransactionCanceledError)
+	require.True(ok)
+	require.Len(cerr, 1)
+	assert.NotNil(cerr[1])
+
+	// Item must be a pointer
+	err = c.TransactGet().Get("Book", fetchedBook).Run()
+	assert.NotNil(err)
+}
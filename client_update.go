@@ -0,0 +1,433 @@
+package dynami
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	db "github.com/aws/aws-sdk-go/service/dynamodb"
+	dbiface "github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+)
+
+// ReturnValue specifies which item attributes
+// are returned after an update operation.
+type ReturnValue string
+
+// These are the valid return values. ReturnNone is the default
+// unless an output is passed to Update.Run, in which case it is
+// ReturnAllNew.
+const (
+	ReturnNone       ReturnValue = db.ReturnValueNone
+	ReturnAllOld     ReturnValue = db.ReturnValueAllOld
+	ReturnUpdatedOld ReturnValue = db.ReturnValueUpdatedOld
+	ReturnAllNew     ReturnValue = db.ReturnValueAllNew
+	ReturnUpdatedNew ReturnValue = db.ReturnValueUpdatedNew
+)
+
+// Update represents an update item operation. It modifies
+// the attributes of an existing item, or adds a new item if
+// it doesn't exist, without replacing the whole item.
+type Update struct {
+	db dbiface.DynamoDBAPI
+
+	table string
+	key   dbitem
+
+	sets    []string
+	removes []string
+	adds    []string
+	deletes []string
+
+	nvalues         int
+	attributeNames  map[string]*string
+	attributeValues map[string]*db.AttributeValue
+
+	conds       []*condition
+	vcond       *condition
+	returnValue ReturnValue
+
+	err error
+}
+
+// Update returns a new update operation for the item with the
+// given key. key must be a map[string]interface{}, struct, or a
+// pointer to any of those with nonempty primary key. Only the
+// key attributes are used, all other attributes are ignored
+// except for the version field. If key has a version field,
+// the stored version is incremented and, if the version is
+// nonzero, checked against the stored item as in PutItem.
+func (c *Client) Update(tableName string, key interface{}) *Update {
+	u := &Update{
+		db:    c.db,
+		table: tableName,
+	}
+
+	if tableName == "" {
+		u.err = fmt.Errorf("dynami: empty table name")
+		return u
+	}
+
+	err := checkType(key, reflect.Struct, map[string]interface{}{})
+	if err != nil {
+		u.err = err
+		return u
+	}
+
+	k, err := getPrimaryKey(key)
+	if err != nil {
+		u.err = err
+		return u
+	}
+	u.key = k.value
+
+	// Increment the item version and only check
+	// it if it's nonzero. Zero means it's unknown.
+	if vname, vfield := getVersion(key); vname != "" {
+		if version := versionValue(vfield); version != 0 {
+			u.vcond = versionCondition(vname, version)
+		}
+		u.Add(vname, 1)
+	}
+
+	return u
+}
+
+// Set replaces the value of an attribute. name can
+// be a nested attribute, eg. "Info.Publisher".
+func (u *Update) Set(name string, value interface{}) *Update {
+	if u.err != nil {
+		return u
+	}
+
+	n := u.addName(name)
+	v := u.addValue(value)
+	u.sets = append(u.sets, n+" = "+v)
+	return u
+}
+
+// SetIfNotExists sets the value of an
+// attribute only if it doesn't exist yet.
+func (u *Update) SetIfNotExists(name string, value interface{}) *Update {
+	if u.err != nil {
+		return u
+	}
+
+	n := u.addName(name)
+	v := u.addValue(value)
+	u.sets = append(u.sets, fmt.Sprintf("%s = if_not_exists(%s, %s)", n, n, v))
+	return u
+}
+
+// Append adds values to the end of a list attribute.
+// values must be a slice. If the attribute doesn't
+// exist, it is created.
+func (u *Update) Append(name string, values interface{}) *Update {
+	if u.err != nil {
+		return u
+	} else if err := checkType(values, reflect.Slice); err != nil {
+		u.err = err
+		return u
+	}
+
+	n := u.addName(name)
+	v := u.addValue(values)
+	e := u.addValue([]interface{}{})
+	u.sets = append(u.sets, fmt.Sprintf("%s = list_append(if_not_exists(%s, %s), %s)", n, n, e, v))
+	return u
+}
+
+// Prepend adds values to the beginning of a list
+// attribute. values must be a slice. If the attribute
+// doesn't exist, it is created.
+func (u *Update) Prepend(name string, values interface{}) *Update {
+	if u.err != nil {
+		return u
+	} else if err := checkType(values, reflect.Slice); err != nil {
+		u.err = err
+		return u
+	}
+
+	n := u.addName(name)
+	v := u.addValue(values)
+	e := u.addValue([]interface{}{})
+	u.sets = append(u.sets, fmt.Sprintf("%s = list_append(%s, if_not_exists(%s, %s))", n, v, n, e))
+	return u
+}
+
+// Add increments a number attribute by value or adds value
+// to a set attribute. A slice or array value, eg. []string,
+// is added as a set. If the attribute doesn't exist, it is
+// created with value as its initial value.
+func (u *Update) Add(name string, value interface{}) *Update {
+	if u.err != nil {
+		return u
+	}
+
+	n := u.addName(name)
+	v := u.addSet(value)
+	u.adds = append(u.adds, n+" "+v)
+	return u
+}
+
+// Remove removes the given attributes from the item.
+func (u *Update) Remove(names ...string) *Update {
+	if u.err != nil {
+		return u
+	}
+
+	for _, name := range names {
+		u.removes = append(u.removes, u.addName(name))
+	}
+	return u
+}
+
+// Delete removes the elements of value from a set
+// attribute. Like Add, a slice or array value is
+// treated as a set.
+func (u *Update) Delete(name string, value interface{}) *Update {
+	if u.err != nil {
+		return u
+	}
+
+	n := u.addName(name)
+	v := u.addSet(value)
+	u.deletes = append(u.deletes, n+" "+v)
+	return u
+}
+
+// If makes the update conditional. The update is only performed
+// if the condition expression expr evaluates to true for the stored
+// item. Multiple conditions are AND'ed together. Value placeholders
+// must not be ":dbversion" or of the form ":uN" as these are used
+// internally. Run
+// returns ErrConditionFailed if the condition is not satisfied.
+func (u *Update) If(expr string, values ...interface{}) *Update {
+	if u.err != nil {
+		return u
+	}
+
+	cond, err := parseCondition(expr, values)
+	if err != nil {
+		u.err = err
+		return u
+	}
+
+	u.conds = append(u.conds, cond)
+	return u
+}
+
+// Return specifies which attributes are loaded
+// into the output of Run after the update.
+func (u *Update) Return(value ReturnValue) *Update {
+	if u.err != nil {
+		return u
+	}
+
+	u.returnValue = value
+	return u
+}
+
+// Run executes the update operation. If out is not nil, the
+// attributes specified by Return are loaded into it. out must
+// be a pointer to a map[string]interface{} or a pointer to a
+// struct.
+func (u *Update) Run(out interface{}) error {
+	return u.RunWithContext(aws.BackgroundContext(), out)
+}
+
+// RunWithContext is the same as Run with
+// the addition of a request context.
+func (u *Update) RunWithContext(ctx context.Context, out interface{}) error {
+	if u.err != nil {
+		return u.err
+	}
+
+	returnValue := u.returnValue
+	if out != nil {
+		err := checkPtrType(out, reflect.Struct, map[string]interface{}{})
+		if err != nil {
+			return err
+		}
+
+		if returnValue == "" {
+			returnValue = ReturnAllNew
+		}
+	}
+
+	input, err := u.input()
+	if err != nil {
+		return err
+	}
+	if returnValue != "" {
+		input.ReturnValues = aws.String(string(returnValue))
+	}
+
+	resp, err := u.db.UpdateItemWithContext(ctx, input)
+	if awsErrCode(err) == db.ErrCodeConditionalCheckFailedException {
+		return u.conditionFailed()
+	} else if err != nil {
+		return newError("cannot update item", err)
+	}
+
+	if out != nil && len(resp.Attributes) > 0 {
+		err = unmarshalItem(resp.Attributes, out)
+		if err != nil {
+			return fmt.Errorf("dynami: invalid item (%w)", err)
+		}
+	}
+
+	return nil
+}
+
+// input creates the update request
+// without the return values.
+func (u *Update) input() (*db.UpdateItemInput, error) {
+	expr := u.expression()
+	if expr == "" {
+		return nil, fmt.Errorf("dynami: empty update expression")
+	}
+
+	input := &db.UpdateItemInput{
+		TableName:        aws.String(u.table),
+		Key:              u.key,
+		UpdateExpression: aws.String(expr),
+	}
+
+	// Merge update and condition placeholders
+	attributeNames := map[string]*string{}
+	attributeValues := map[string]*db.AttributeValue{}
+	for ph, n := range u.attributeNames {
+		attributeNames[ph] = n
+	}
+	for ph, v := range u.attributeValues {
+		attributeValues[ph] = v
+	}
+
+	conds := append([]*condition{u.vcond}, u.conds...)
+	cond, err := mergeConditions(conds...)
+	if err != nil {
+		return nil, err
+	} else if cond != nil {
+		input.ConditionExpression = aws.String(cond.expr)
+		for ph, n := range cond.attributeNames {
+			attributeNames[ph] = n
+		}
+		for ph, v := range cond.attributeValues {
+			if _, ok := attributeValues[ph]; ok {
+				return nil, fmt.Errorf("dynami: duplicate placeholder (%v)", ph)
+			}
+			attributeValues[ph] = v
+		}
+	}
+
+	input.ExpressionAttributeNames = attributeNames
+	if len(attributeValues) > 0 {
+		input.ExpressionAttributeValues = attributeValues
+	}
+
+	return input, nil
+}
+
+// conditionFailed returns the error for
+// a failed update condition or version check.
+func (u *Update) conditionFailed() error {
+	if len(u.conds) == 0 && u.vcond != nil {
+		return ErrVersionConflict
+	}
+
+	return ErrConditionFailed
+}
+
+// expression returns the update expression
+// composed of all the queued actions.
+func (u *Update) expression() string {
+	clauses := []string{}
+	if len(u.sets) > 0 {
+		clauses = append(clauses, "SET "+strings.Join(u.sets, ", "))
+	}
+	if len(u.removes) > 0 {
+		clauses = append(clauses, "REMOVE "+strings.Join(u.removes, ", "))
+	}
+	if len(u.adds) > 0 {
+		clauses = append(clauses, "ADD "+strings.Join(u.adds, ", "))
+	}
+	if len(u.deletes) > 0 {
+		clauses = append(clauses, "DELETE "+strings.Join(u.deletes, ", "))
+	}
+
+	return strings.Join(clauses, " ")
+}
+
+// addName returns the placeholder expression for the
+// given attribute name. Each part of a nested name gets
+// its own placeholder while list indices are kept as is.
+func (u *Update) addName(name string) string {
+	if u.attributeNames == nil {
+		u.attributeNames = map[string]*string{}
+	}
+
+	parts := strings.Split(name, ".")
+	for i, p := range parts {
+		// Separate list indices from the name
+		n, index := p, ""
+		if j := strings.IndexByte(p, '['); j >= 0 {
+			n, index = p[:j], p[j:]
+		}
+
+		if n == "" && u.err == nil {
+			u.err = fmt.Errorf("dynami: invalid attribute name (%v)", name)
+		}
+
+		ph := "#" + n + "_PH"
+		u.attributeNames[ph] = aws.String(n)
+		parts[i] = ph + index
+	}
+
+	return strings.Join(parts, ".")
+}
+
+// addValue returns the value
+// placeholder for the given value.
+func (u *Update) addValue(value interface{}) string {
+	return u.addAttrValue(marshalValue(value))
+}
+
+// addSet is the same as addValue except that slice and
+// array values are marshaled into sets. Empty sets are
+// not allowed.
+func (u *Update) addSet(value interface{}) string {
+	v := reflect.ValueOf(value)
+	if !isSetValue(v) {
+		return u.addValue(value)
+	}
+
+	av, err := marshalSet(v)
+	if err == nil && isEmptySet(av) {
+		err = fmt.Errorf("empty set")
+	}
+	return u.addAttrValue(av, err)
+}
+
+// addAttrValue returns the placeholder for the given
+// marshaled value. If err is not nil, it is set as the
+// error of the update instead.
+func (u *Update) addAttrValue(av *db.AttributeValue, err error) string {
+	if u.attributeValues == nil {
+		u.attributeValues = map[string]*db.AttributeValue{}
+	}
+
+	u.nvalues++
+	ph := ":u" + strconv.Itoa(u.nvalues)
+	if err != nil {
+		if u.err == nil {
+			u.err = fmt.Errorf("dynami: invalid expression value (%w)", err)
+		}
+		return ph
+	}
+
+	u.attributeValues[ph] = av
+	return ph
+}
@@ -0,0 +1,127 @@
+package dynami
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	db "github.com/aws/aws-sdk-go/service/dynamodb"
+	dbattribute "github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+)
+
+func (suite *DatabaseTestSuite) TestUpdate() {
+	assert := suite.Assert()
+	require := suite.Require()
+
+	book := tBook{
+		Title:  "The Hobbit",
+		Author: "J.R.R. Tolkien",
+		Genre:  "Adventure",
+		Info: tInfo{
+			Publisher:     "George Allen & Unwin",
+			DatePublished: 1937,
+			Characters: []string{
+				"Bilbo",
+			},
+		},
+	}
+	item, err := dbattribute.MarshalMap(book)
+	require.Nil(err)
+
+	sdb := suite.db
+	_, err = sdb.PutItem(&db.PutItemInput{
+		Item:      item,
+		TableName: aws.String("Book"),
+	})
+	require.Nil(err)
+
+	c := suite.client
+	key := tBook{
+		Title:  book.Title,
+		Author: book.Author,
+	}
+
+	var updated tBook
+	err = c.Update("Book", key).
+		Set("Genre", "Fantasy").
+		Set("Info.Publisher", "HarperCollins").
+		Add("Info.DatePublished", 1).
+		Append("Info.Characters", []string{"Smaug"}).
+		Run(nil)
+	require.Nil(err)
+
+	err = c.Update("Book", key).
+		Prepend("Info.Characters", []string{"Gandalf"}).
+		Run(&updated)
+	require.Nil(err)
+
+	expected := book
+	expected.Genre = "Fantasy"
+	expected.Info.Publisher = "HarperCollins"
+	expected.Info.DatePublished = 1938
+	expected.Info.Characters = []string{
+		"Gandalf",
+		"Bilbo",
+		"Smaug",
+	}
+	assert.Equal(expected, updated)
+
+	// Return the old values
+	var old map[string]interface{}
+	err = c.Update("Book", key).
+		Remove("Genre").
+		SetIfNotExists("Info.Publisher", "Scholastic").
+		Return(ReturnAllOld).
+		Run(&old)
+	require.Nil(err)
+	assert.Equal("Fantasy", old["Genre"])
+
+	fetched := key
+	err = c.GetItem("Book", &fetched, true)
+	require.Nil(err)
+	assert.Equal("", fetched.Genre)
+	assert.Equal("HarperCollins", fetched.Info.Publisher)
+
+	// Nested names that contain each other and list indices
+	err = c.Update("Book", key).
+		Set("Data", map[string]int{"D": 1}).
+		Run(nil)
+	require.Nil(err)
+
+	var nested map[string]interface{}
+	err = c.Update("Book", key).
+		Add("Data.D", 2).
+		Set("Info.Characters[0]", "Thorin").
+		Run(&nested)
+	require.Nil(err)
+	assert.Equal(map[string]interface{}{"D": 3.0}, nested["Data"])
+	assert.Equal("Thorin", nested["Info"].(map[string]interface{})["Characters"].([]interface{})[0])
+
+	err = c.Update("Book", key).Remove("Data").Run(nil)
+	require.Nil(err)
+
+	// Conditional update
+	err = c.Update("Book", key).
+		Set("Genre", "Fiction").
+		If("attribute_exists(Genre)").
+		Run(nil)
+	assert.Equal(ErrConditionFailed, err)
+
+	err = c.Update("Book", key).
+		Set("Genre", "Fiction").
+		If("attribute_not_exists(Genre)").
+		If("Info.DatePublished = :date", 1938).
+		Run(nil)
+	require.Nil(err)
+
+	// Invalid attribute name
+	err = c.Update("Book", key).Set("Info..Publisher", "").Run(nil)
+	assert.NotNil(err)
+
+	// Update without any actions
+	err = c.Update("Book", key).Run(nil)
+	assert.NotNil(err)
+
+	// Update with incomplete key
+	err = c.Update("Book", tBook{Title: book.Title}).
+		Set("Genre", "Fantasy").
+		Run(nil)
+	assert.NotNil(err)
+}
@@ -0,0 +1,99 @@
+package dynami
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	db "github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// cursor is the decoded form of an ItemIterator cursor.
+// It records the table and index it came from so that it
+// can't be used to resume a different query.
+type cursor struct {
+	Table string                `json:"t"`
+	Index string                `json:"i,omitempty"`
+	Key   map[string]cursorAttr `json:"k,omitempty"`
+}
+
+// cursorAttr is a key attribute value. Key
+// attributes can only be strings, numbers,
+// and binary data.
+type cursorAttr struct {
+	S *string `json:"s,omitempty"`
+	N *string `json:"n,omitempty"`
+	B []byte  `json:"b,omitempty"`
+}
+
+// encodeCursor returns the URL-safe encoding of
+// the position key in the given table and index.
+func encodeCursor(table, index string, key dbitem) (string, error) {
+	c := cursor{Table: table, Index: index}
+	for name, value := range key {
+		if value.S == nil && value.N == nil && value.B == nil {
+			return "", fmt.Errorf("dynami: invalid key attribute type (%v)", name)
+		}
+
+		if c.Key == nil {
+			c.Key = map[string]cursorAttr{}
+		}
+		c.Key[name] = cursorAttr{S: value.S, N: value.N, B: value.B}
+	}
+
+	b, err := json.Marshal(c)
+	if err != nil {
+		return "", fmt.Errorf("dynami: cannot encode cursor (%w)", err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func decodeCursor(s string) (*cursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("dynami: invalid cursor (%w)", err)
+	}
+
+	c := &cursor{}
+	err = json.Unmarshal(b, c)
+	if err != nil {
+		return nil, fmt.Errorf("dynami: invalid cursor (%w)", err)
+	} else if c.Table == "" {
+		return nil, fmt.Errorf("dynami: invalid cursor (missing table)")
+	}
+
+	for name, value := range c.Key {
+		n := 0
+		if value.S != nil {
+			n++
+		}
+		if value.N != nil {
+			n++
+		}
+		if value.B != nil {
+			n++
+		}
+
+		if n != 1 {
+			return nil, fmt.Errorf("dynami: invalid cursor (bad key attribute %v)", name)
+		}
+	}
+
+	return c, nil
+}
+
+// key returns the start key
+// represented by this cursor.
+func (c *cursor) key() dbitem {
+	if len(c.Key) == 0 {
+		return nil
+	}
+
+	key := dbitem{}
+	for name, value := range c.Key {
+		key[name] = &db.AttributeValue{S: value.S, N: value.N, B: value.B}
+	}
+
+	return key
+}
@@ -54,6 +54,78 @@ Example code:
 Note that for local secondary indices, only the range attribute is tagged as
 shown in struct field C.
 
+Key fields can also be pointers, types that implement
+dynamodbattribute.Marshaler, or types that implement encoding.TextMarshaler,
+eg. UUIDs, which are stored as strings. Text key types must also implement
+encoding.TextUnmarshaler to be fetched. The attribute type of a custom
+marshaled key is inferred from its kind, or else from its marshaled zero value.
+If neither gives a string, number, or binary, eg. if its zero value is
+marshaled into null, the type must be given using `dbtype:"type"` where "type"
+is "string", "number", or "binary". The tag also overrides the inferred type.
+
+Example code:
+
+  type Item struct {
+    ID   uuid.UUID `dbkey:"hash"`
+    Code Code      `dbindex:"hash,CodeIndex" dbtype:"string"`
+  }
+
+An integer field can also be tagged with `dbversion:"true"` to enable
+optimistic locking. PutItem, DeleteItem and Update then only write the item if
+its version matches the stored item's version and return ErrVersionConflict
+otherwise. The stored version is incremented on every write.
+
+Slices and arrays are stored as lists by default. To store one as a string,
+number, or binary set instead, tag it with `dbset:"true"` or with one of the
+set options of the dynamodbav tag, eg. `dynamodbav:",stringset"`. The set type
+is derived from the marshaled elements and duplicates are removed. Map items
+can use StringSet, NumberSet, and BinarySet values for the same purpose. Since
+DynamoDB doesn't allow empty sets, empty sets are not stored. Sets can be
+filtered using the contains and size functions, and Update.Add and
+Update.Delete add and remove slices of elements as sets.
+
+Example code:
+
+  type Article struct {
+    ID    string   `dbkey:"hash"`
+    Tags  []string `dbset:"true"`
+    Votes []int    `dynamodbav:",numberset"`
+  }
+
+  client.Update("Articles", Article{ID: "id"}).
+    Add("Tags", []string{"go", "aws"}).
+    Delete("Votes", []int{42}).
+    Run(nil)
+
+  it := client.Query("Articles").
+    Filter("contains(Tags, :tag)", "go").
+    Run()
+
+
+Clients
+
+NewClient creates a client from a region and static credentials. For other
+credential providers, custom endpoints, retries, HTTP clients, and logging, use
+NewClientWithOptions.
+
+Example code:
+
+  client, err := dynami.NewClientWithOptions(
+    dynami.WithRegion(dynami.USEast1),
+    dynami.WithCredentials(credentials.NewEnvCredentials()),
+    dynami.WithMaxRetries(5),
+  )
+
+Regions other than the predefined ones can be obtained using LookupRegion which
+derives their endpoints from the region name. Custom regions, eg. for DynamoDB
+Local, can be added using RegisterRegion.
+
+A client can also be created from any implementation of the SDK's DynamoDB and
+DynamoDB Streams interfaces using NewClientFromAPI. This is useful for wrapping
+the SDK clients or for replacing them with fakes in tests. For tests, package
+dynamitest provides clients that are backed by an in-memory database.
+
+
 Item Operations
 
 There are three basic item operations: PutItem, GetItem, and DeleteItem. Each of
@@ -80,6 +152,79 @@ Example code:
 
   client.DeleteItem("ItemTable", fetched)
 
+PutItem and DeleteItem also have conditional versions, PutItemIf and
+DeleteItemIf, which only write if the given condition expression is satisfied.
+Otherwise, ErrConditionFailed is returned. Condition expressions have the same
+syntax as query filter expressions described below.
+
+Example code:
+
+  // Add item only if it doesn't exist yet
+  err := client.PutItemIf("ItemTable", item, "attribute_not_exists(Key)")
+  if err == dynami.ErrConditionFailed {
+    // Item already exists
+  }
+
+By default, empty strings and null values, eg. nil pointers, are omitted from
+written items so an empty string is fetched the same as a missing attribute.
+WithEmptyValues changes this for PutItem, BatchPut, and transactions. With
+KeepEmptyStrings, empty strings are stored while null values are still omitted,
+and with KeepNulls, both are stored. WithTableEmptyValues does the same for a
+single table. Empty key and index key attributes of struct items are always
+omitted since DynamoDB rejects them.
+
+Example code:
+
+  client, err := dynami.NewClientWithOptions(
+    dynami.WithRegion(dynami.USEast1),
+    dynami.WithEmptyValues(dynami.KeepEmptyStrings),
+  )
+
+
+Update Operations
+
+Instead of replacing a whole item with PutItem, individual attributes can be
+modified using Update. Like queries, updates are built by chaining actions and
+are executed when Run is called. If Run is given an output, the updated item is
+loaded into it.
+
+Example code:
+
+  type Item struct {
+    Key    string `dbkey:"hash"`
+    Value  string
+    Count  int
+    Labels []string
+  }
+
+  var updated Item
+  client := dynami.NewClient(dynami.USEast1, "id", "key")
+  client.Update("ItemTable", Item{Key: "key"}).
+    Set("Value", "newvalue").
+    Add("Count", 1).
+    Append("Labels", []string{"label"}).
+    Run(&updated)
+
+
+Typed Tables
+
+NewTable returns a handle to a table whose items are of a given struct type.
+Its operations accept and return items of that type instead of interface{}
+values, and its queries and streams are iterated using typed iterators.
+
+Example code:
+
+  items := dynami.NewTable[Item](client, "ItemTable")
+  err := items.Put(ctx, Item{"key", "somevalue"})
+
+  fetched, err := items.Get(ctx, Item{Key: "key"})
+
+  it := items.Query().HashFilter("Key", "key").Run()
+  for it.HasNext() {
+    item, err := it.Next()
+    // ...
+  }
+
 
 Batch Operations
 
@@ -134,6 +279,60 @@ Example code:
     Delete("ItemTableB", fetchedB).
     Run()
 
+Items that DynamoDB leaves unprocessed, eg. when a table is throttled, are
+retried with exponential backoff and jitter as specified by a RetryPolicy. The
+default policy of a client can be set using WithBatchRetry, and each batch
+operation can override it using Retry. Items that are still unprocessed when
+the policy gives up are marked with ErrUnprocessed in the returned BatchError so
+that they can be resubmitted later.
+
+Example code:
+
+  err := client.BatchPut("ItemTable", items).
+    Retry(dynami.RetryPolicy{
+      BaseDelay:      100 * time.Millisecond,
+      MaxDelay:       10 * time.Second,
+      Jitter:         1,
+      MaxAttempts:    20,
+      MaxElapsedTime: 5 * time.Minute,
+    }).
+    Run()
+
+  if berr, ok := err.(dynami.BatchError); ok {
+    for i, err := range berr["ItemTable"] {
+      if err == dynami.ErrUnprocessed {
+        // Requeue items[i]
+      }
+    }
+  }
+
+
+Transactions
+
+Unlike batch operations, transactions are atomic. A transaction can put,
+delete, update, and check the condition of up to 100 items in multiple tables
+where either all steps succeed or none of them do. If a transaction is
+canceled, a TransactionCanceledError is returned which contains the error of
+each failed step.
+
+Example code:
+
+  err := client.Transaction().
+    PutIf("ItemTableA", itemA, "attribute_not_exists(Key)").
+    Update(client.Update("ItemTableB", itemB).Add("Count", 1)).
+    Delete("ItemTableC", itemC).
+    Run()
+
+Items can also be fetched atomically using TransactGet. This returns a
+TransactGetError if some of the items don't exist.
+
+Example code:
+
+  err := client.TransactGet().
+    Get("ItemTableA", &itemA).
+    Get("ItemTableB", &itemB).
+    Run()
+
 
 Queries
 
@@ -181,5 +380,169 @@ Example code:
     }
   }
 
+  if err := it.Err(); err != nil {
+    // Query failed
+  }
+
+By default, whole items are fetched. To fetch only some of the attributes, use
+Query.Select, GetItemSelect, or BatchGet.Select. Nested attributes are selected
+using a dot separator, and the attributes of a struct can be listed using
+Attributes.
+
+Example code:
+
+  it := client.Query("ItemTable").
+    HashFilter("Hash", "somehashvalue").
+    Select("Range", "Info.Publisher").
+    Run()
+
+A query can be paused and resumed later, eg. to paginate results across
+requests. ItemIterator.Cursor returns an opaque cursor that points after the
+last returned item, and Query.StartFrom resumes a query from it.
+
+Example code:
+
+  cursor, err := it.Cursor()
+
+  // In a later request...
+
+  it := client.Query("ItemTable").
+    HashFilter("Hash", "somehashvalue").
+    StartFrom(cursor).
+    Run()
+
+Large scans can be divided into segments that are scanned concurrently using
+Query.Parallel. If a parallel scan is not iterated until the end, call
+ItemIterator.Close to stop the remaining segments.
+
+Example code:
+
+  it := client.Query("ItemTable").Parallel(8).Run()
+  defer it.Close()
+
+
+Contexts
+
+Every operation has a WithContext variant, eg. PutItemWithContext, that accepts
+a context which is used to cancel its requests or bound them by a deadline. For
+builders such as Query and BatchPut, the context is passed to RunWithContext.
+Iterators returned by Query.RunWithContext and GetStreamWithContext use their
+context for all subsequent requests.
+
+Example code:
+
+  ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+  defer cancel()
+
+  err := client.GetItemWithContext(ctx, "ItemTable", &item)
+
+
+Errors
+
+Failed DynamoDB requests return an *Error which wraps the error of the SDK. It
+can be matched against ErrTableNotFound, ErrThrottled, ErrConditionFailed,
+ErrValidation and ErrItemTooLarge using errors.Is, and the underlying
+awserr.Error can be retrieved using errors.As. IsRetryable reports whether an
+error is transient. BatchError, TransactionCanceledError and TransactGetError
+unwrap to the errors of their items.
+
+Example code:
+
+  err := client.PutItem("ItemTable", item)
+  if errors.Is(err, dynami.ErrTableNotFound) {
+    // Create the table
+  } else if dynami.IsRetryable(err) {
+    // Try again later
+  }
+
+
+Stats
+
+Every request made by dynami returns its consumed capacity. Query iterators and
+batch operations accumulate the read and write capacity units consumed on each
+table and index, plus the number of returned and scanned items, which can be
+read using their Stats method. To collect the stats of every request sent by a
+client, eg. for item operations, use WithStatsCollector.
+
+Example code:
+
+  it := client.Query("ItemTable").
+    HashFilter("Hash", "somehashvalue").
+    Filter("Value = :fval", 42).
+    Run()
+
+  for it.HasNext() {
+    // ...
+  }
+
+  stats := it.Stats()
+  fmt.Println(stats.Total().Read, stats.Count, stats.ScannedCount)
+
+  collector := dynami.StatsCollectorFunc(func(op string, stats dynami.Stats) {
+    log.Println(op, stats.Total())
+  })
+  client, err := dynami.NewClientWithOptions(
+    dynami.WithRegion(dynami.USEast1),
+    dynami.WithStatsCollector(collector),
+  )
+
+
+Rate Limiting
+
+A client can be limited to a number of read and write capacity units per second
+on each table and global secondary index using WithRateLimit and
+WithIndexRateLimit. This keeps background work, eg. batch jobs and scans, from
+starving other users of the same tables. Before each request, the client waits
+until the table or index has enough capacity left, and afterwards, it is
+charged with the capacity that DynamoDB reports as consumed. If a context is
+given, it also bounds the time spent waiting.
+
+Example code:
+
+  client, err := dynami.NewClientWithOptions(
+    dynami.WithRegion(dynami.USEast1),
+    dynami.WithRateLimit("ItemTable", dynami.ThroughputLimit(table.Throughput, 0.3)),
+    dynami.WithIndexRateLimit("ItemTable", "ValueIndex", dynami.RateLimit{Read: 10}),
+  )
+
+
+Middleware
+
+Every DynamoDB and DynamoDB Streams request sent by a client, including those
+of batch operations, queries, table management and stream iterators, can be
+wrapped using Client.Use. A middleware receives the operation's name, table,
+index and input, and after calling the next handler, its output, duration and
+retry counts. This can be used for tracing, logging or injecting faults.
+
+Example code:
+
+  client.Use(func(next dynami.Handler) dynami.Handler {
+    return func(ctx context.Context, op *dynami.Operation) error {
+      err := next(ctx, op)
+      log.Println(op.Name, op.Table, op.Index, op.Duration, err)
+      return err
+    }
+  })
+
+
+Metrics
+
+Metrics records a latency histogram and the number of errors by error code,
+throttled requests, SDK retries and batch retry rounds of each operation and
+table, and the lag of each table's stream. It's added to a client using
+WithMetrics and can be exposed through expvar or served over HTTP in the
+Prometheus text format.
+
+Example code:
+
+  metrics := dynami.NewMetrics()
+  client, err := dynami.NewClientWithOptions(
+    dynami.WithRegion(dynami.USEast1),
+    dynami.WithMetrics(metrics),
+  )
+
+  expvar.Publish("dynami", metrics)
+  http.Handle("/metrics", metrics)
+
 */
 package dynami // import "github.com/robskie/dynami"
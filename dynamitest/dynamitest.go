@@ -0,0 +1,45 @@
+/*
+Package dynamitest provides dynami clients that are backed by an in-memory
+database instead of DynamoDB. This allows code that uses dynami to be tested
+without network access or DynamoDB Local.
+
+The in-memory database is implemented in package memdb. It supports the
+operations used by dynami: tables, basic and conditional item operations,
+updates, batch operations, transactions, queries, scans, and streams. Tables
+and indices are active as soon as they are created and have no throughput
+limits. Consumed capacity is computed from item sizes like DynamoDB does.
+
+Example code:
+
+	func TestSomething(t *testing.T) {
+	  client := dynamitest.NewClient()
+	  err := client.CreateTable(table)
+	  // ...
+	}
+
+To test how unprocessed batch items are handled, limit the number of items
+processed by each batch request using memdb.WithBatchLimit.
+
+Example code:
+
+	client := dynamitest.NewClient(memdb.WithBatchLimit(3))
+*/
+package dynamitest // import "github.com/robskie/dynami/dynamitest"
+
+import (
+	"github.com/robskie/dynami"
+	"github.com/robskie/dynami/dynamitest/memdb"
+)
+
+// NewClient returns a client that is backed by a new
+// and empty in-memory database. Each client has its
+// own database.
+func NewClient(opts ...memdb.Option) *dynami.Client {
+	mdb := memdb.New(opts...)
+	client, err := dynami.NewClientFromAPI(mdb, mdb.Streams())
+	if err != nil {
+		panic(err)
+	}
+
+	return client
+}
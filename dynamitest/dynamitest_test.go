@@ -0,0 +1,167 @@
+package dynamitest
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/robskie/dynami"
+	"github.com/robskie/dynami/dynamitest/memdb"
+	sc "github.com/robskie/dynami/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type tItem struct {
+	Hash  string `dbkey:"hash"`
+	Range int    `dbkey:"range"`
+	Value string `dbindex:"hash,ValueIndex"`
+	Count int
+}
+
+func createTable(t *testing.T, c *dynami.Client) {
+	table := sc.NewTable("TestTable", tItem{}, map[string]sc.Throughput{
+		"TestTable":  {Read: 1, Write: 1},
+		"ValueIndex": {Read: 1, Write: 1},
+	})
+	table.StreamEnabled = true
+	require.Nil(t, c.CreateTable(table))
+}
+
+func TestNewClient(t *testing.T) {
+	c := NewClient()
+	createTable(t, c)
+
+	tables, err := c.ListTables()
+	require.Nil(t, err)
+	assert.Equal(t, []string{"TestTable"}, tables)
+
+	// Each client has its own database
+	tables, err = NewClient().ListTables()
+	require.Nil(t, err)
+	assert.Empty(t, tables)
+}
+
+func TestItemOperations(t *testing.T) {
+	c := NewClient()
+	createTable(t, c)
+
+	item := tItem{"hash", 1, "value", 0}
+	require.Nil(t, c.PutItem("TestTable", item))
+
+	fetched := tItem{Hash: "hash", Range: 1}
+	require.Nil(t, c.GetItem("TestTable", &fetched))
+	assert.Equal(t, item, fetched)
+
+	// Fetch using a global secondary index
+	fetched = tItem{Value: "value"}
+	require.Nil(t, c.GetItem("TestTable", &fetched))
+	assert.Equal(t, item, fetched)
+
+	err := c.PutItemIf("TestTable", item, "attribute_not_exists(Hash)")
+	assert.Equal(t, dynami.ErrConditionFailed, err)
+
+	var updated tItem
+	err = c.Update("TestTable", item).
+		Add("Count", 2).
+		Set("Value", "updated").
+		Return(dynami.ReturnAllNew).
+		Run(&updated)
+	require.Nil(t, err)
+	assert.Equal(t, 2, updated.Count)
+	assert.Equal(t, "updated", updated.Value)
+
+	require.Nil(t, c.DeleteItem("TestTable", item))
+	err = c.GetItem("TestTable", &tItem{Hash: "hash", Range: 1})
+	assert.Equal(t, dynami.ErrNoSuchItem, err)
+}
+
+func TestBatchLimit(t *testing.T) {
+	c := NewClient(memdb.WithBatchLimit(3))
+	createTable(t, c)
+
+	items := make([]tItem, 40)
+	for i := range items {
+		items[i] = tItem{"hash", i + 1, strconv.Itoa(i), i}
+	}
+	policy := dynami.RetryPolicy{
+		BaseDelay:   time.Millisecond,
+		MaxDelay:    4 * time.Millisecond,
+		MaxAttempts: 3,
+	}
+	require.Nil(t, c.BatchPut("TestTable", items).Retry(policy).Run())
+
+	fetched := make([]tItem, len(items))
+	for i := range fetched {
+		fetched[i] = tItem{Hash: "hash", Range: i + 1}
+	}
+	require.Nil(t, c.BatchGet("TestTable", fetched).Retry(policy).Run())
+	assert.Equal(t, items, fetched)
+}
+
+func TestQuery(t *testing.T) {
+	c := NewClient()
+	createTable(t, c)
+
+	items := make([]tItem, 20)
+	for i := range items {
+		items[i] = tItem{"hash", i + 1, strconv.Itoa(i % 2), i + 1}
+	}
+	require.Nil(t, c.BatchPut("TestTable", items).Run())
+
+	it := c.Query("TestTable").
+		HashFilter("Hash", "hash").
+		RangeFilter("Range BETWEEN :lo AND :hi", 5, 14).
+		Filter("Count < :count", 13).
+		Desc().
+		Run()
+
+	var ranges []int
+	for it.HasNext() {
+		var item tItem
+		require.Nil(t, it.Next(&item))
+		ranges = append(ranges, item.Range)
+	}
+	require.Nil(t, it.Err())
+	assert.Equal(t, []int{12, 11, 10, 9, 8, 7, 6, 5}, ranges)
+
+	// Scan with a filter
+	it = c.Query("TestTable").Filter("Value = :v", "1").Run()
+	count := 0
+	for it.HasNext() {
+		var item tItem
+		require.Nil(t, it.Next(&item))
+		assert.Equal(t, "1", item.Value)
+		count++
+	}
+	require.Nil(t, it.Err())
+	assert.Equal(t, 10, count)
+}
+
+func TestStream(t *testing.T) {
+	c := NewClient()
+	createTable(t, c)
+
+	item := tItem{"hash", 1, "value", 0}
+	require.Nil(t, c.PutItem("TestTable", item))
+	item.Count = 1
+	require.Nil(t, c.PutItem("TestTable", item))
+	require.Nil(t, c.DeleteItem("TestTable", item))
+
+	it, err := c.GetStream("TestTable")
+	require.Nil(t, err)
+
+	var types []dynami.RecordType
+	for it.HasNext() {
+		var rec tItem
+		rtype, err := it.Next(&rec)
+		require.Nil(t, err)
+		assert.Equal(t, item.Hash, rec.Hash)
+		types = append(types, rtype)
+	}
+	assert.Equal(t, []dynami.RecordType{
+		dynami.AddedRecord,
+		dynami.UpdatedRecord,
+		dynami.DeletedRecord,
+	}, types)
+}
@@ -0,0 +1,226 @@
+package memdb
+
+import (
+	"sort"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	db "github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+func sortedKeys(m interface{}) []string {
+	var names []string
+	switch m := m.(type) {
+	case map[string]*db.KeysAndAttributes:
+		for name := range m {
+			names = append(names, name)
+		}
+	case map[string][]*db.WriteRequest:
+		for name := range m {
+			names = append(names, name)
+		}
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+// BatchGetItem returns up to 100 items from one or more
+// tables. If the database was created with WithBatchLimit,
+// the keys over the limit are returned as unprocessed.
+func (d *DB) BatchGetItem(input *db.BatchGetItemInput) (*db.BatchGetItemOutput, error) {
+	return d.BatchGetItemWithContext(aws.BackgroundContext(), input)
+}
+
+// BatchGetItemWithContext is the same as BatchGetItem
+// with the addition of a request context.
+func (d *DB) BatchGetItemWithContext(
+	ctx aws.Context,
+	input *db.BatchGetItemInput,
+	opts ...request.Option) (*db.BatchGetItemOutput, error) {
+
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	} else if err := input.Validate(); err != nil {
+		return nil, err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	// Validate the whole request first
+	total := 0
+	for _, name := range sortedKeys(input.RequestItems) {
+		t, err := d.table(aws.String(name))
+		if err != nil {
+			return nil, notFoundErr("Requested resource not found")
+		}
+
+		seen := map[string]bool{}
+		for _, key := range input.RequestItems[name].Keys {
+			if err := t.checkKey(key, true); err != nil {
+				return nil, err
+			}
+
+			ekey := t.encodeKey(key)
+			if seen[ekey] {
+				return nil, validationErr("Provided list of item keys contains duplicates")
+			}
+			seen[ekey] = true
+		}
+		total += len(seen)
+	}
+
+	if total > maxBatchGetItems {
+		return nil, validationErr("Too many items requested for the BatchGetItem call")
+	}
+
+	output := &db.BatchGetItemOutput{
+		Responses:       map[string][]map[string]*db.AttributeValue{},
+		UnprocessedKeys: map[string]*db.KeysAndAttributes{},
+	}
+
+	processed := 0
+	cs := map[string]*consumed{}
+	for _, name := range sortedKeys(input.RequestItems) {
+		t := d.tables[name]
+		ka := input.RequestItems[name]
+		output.Responses[name] = []map[string]*db.AttributeValue{}
+
+		for _, key := range ka.Keys {
+			if d.batchLimit > 0 && processed >= d.batchLimit {
+				unproc, ok := output.UnprocessedKeys[name]
+				if !ok {
+					unproc = &db.KeysAndAttributes{
+						ConsistentRead:           ka.ConsistentRead,
+						ProjectionExpression:     ka.ProjectionExpression,
+						AttributesToGet:          ka.AttributesToGet,
+						ExpressionAttributeNames: ka.ExpressionAttributeNames,
+					}
+					output.UnprocessedKeys[name] = unproc
+				}
+				unproc.Keys = append(unproc.Keys, key)
+				continue
+			}
+			processed++
+
+			it, err := d.getItem(t, key, ka.ProjectionExpression, ka.AttributesToGet, ka.ExpressionAttributeNames)
+			if err != nil {
+				return nil, err
+			} else if it != nil {
+				output.Responses[name] = append(output.Responses[name], it)
+			}
+
+			if cs[name] == nil {
+				cs[name] = newConsumed(t)
+			}
+			stored := t.items[t.encodeKey(key)]
+			cs[name].add(nil, readUnits(itemSize(stored), aws.BoolValue(ka.ConsistentRead)))
+		}
+	}
+
+	output.ConsumedCapacity = outputs(cs, input.ReturnConsumedCapacity)
+	return output, nil
+}
+
+// BatchWriteItem puts or deletes up to 25 items in one or
+// more tables. If the database was created with WithBatchLimit,
+// the requests over the limit are returned as unprocessed.
+func (d *DB) BatchWriteItem(input *db.BatchWriteItemInput) (*db.BatchWriteItemOutput, error) {
+	return d.BatchWriteItemWithContext(aws.BackgroundContext(), input)
+}
+
+// BatchWriteItemWithContext is the same as BatchWriteItem
+// with the addition of a request context.
+func (d *DB) BatchWriteItemWithContext(
+	ctx aws.Context,
+	input *db.BatchWriteItemInput,
+	opts ...request.Option) (*db.BatchWriteItemOutput, error) {
+
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	} else if err := input.Validate(); err != nil {
+		return nil, err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	// Validate the whole request first
+	total := 0
+	for _, name := range sortedKeys(input.RequestItems) {
+		t, err := d.table(aws.String(name))
+		if err != nil {
+			return nil, notFoundErr("Requested resource not found")
+		}
+
+		seen := map[string]bool{}
+		for _, wr := range input.RequestItems[name] {
+			var key item
+			switch {
+			case wr.PutRequest != nil && wr.DeleteRequest == nil:
+				key = wr.PutRequest.Item
+				if err := t.checkKey(key, false); err != nil {
+					return nil, err
+				} else if err := t.checkItem(key); err != nil {
+					return nil, err
+				}
+			case wr.DeleteRequest != nil && wr.PutRequest == nil:
+				key = wr.DeleteRequest.Key
+				if err := t.checkKey(key, true); err != nil {
+					return nil, err
+				}
+			default:
+				return nil, validationErr("A WriteRequest must contain exactly one of PutRequest or DeleteRequest")
+			}
+
+			ekey := t.encodeKey(key)
+			if seen[ekey] {
+				return nil, validationErr("Provided list of item keys contains duplicates")
+			}
+			seen[ekey] = true
+		}
+		total += len(seen)
+	}
+
+	if total > maxBatchWriteItems {
+		return nil, validationErr("Too many items requested for the BatchWriteItem call")
+	}
+
+	output := &db.BatchWriteItemOutput{
+		UnprocessedItems: map[string][]*db.WriteRequest{},
+	}
+
+	processed := 0
+	cs := map[string]*consumed{}
+	for _, name := range sortedKeys(input.RequestItems) {
+		t := d.tables[name]
+		for _, wr := range input.RequestItems[name] {
+			if d.batchLimit > 0 && processed >= d.batchLimit {
+				output.UnprocessedItems[name] = append(output.UnprocessedItems[name], wr)
+				continue
+			}
+			processed++
+
+			var w *write
+			var err error
+			if wr.PutRequest != nil {
+				w, err = d.preparePut(t, wr.PutRequest.Item, nil, nil, nil)
+			} else {
+				w, err = d.prepareDelete(t, wr.DeleteRequest.Key, nil, nil, nil)
+			}
+			if err != nil {
+				return nil, err
+			}
+			d.apply(w)
+
+			if cs[name] == nil {
+				cs[name] = newConsumed(t)
+			}
+			cs[name].write(w, false)
+		}
+	}
+
+	output.ConsumedCapacity = outputs(cs, input.ReturnConsumedCapacity)
+	return output, nil
+}
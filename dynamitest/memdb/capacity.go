@@ -0,0 +1,132 @@
+package memdb
+
+import (
+	"math"
+	"sort"
+
+	"github.com/aws/aws-sdk-go/aws"
+	db "github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// Capacity units are computed like DynamoDB does but the
+// sizes of items in indexes include all of their attributes.
+const (
+	readUnitSize  = 4 * 1024
+	writeUnitSize = 1024
+)
+
+// readUnits returns the read capacity units consumed
+// by reading the given number of bytes.
+func readUnits(size int, consistent bool) float64 {
+	units := math.Ceil(float64(max(size, 1)) / readUnitSize)
+	if !consistent {
+		units /= 2
+	}
+	return units
+}
+
+// writeUnits returns the write capacity units
+// consumed by writing the given number of bytes.
+func writeUnits(size int) float64 {
+	return math.Ceil(float64(max(size, 1)) / writeUnitSize)
+}
+
+// consumed accumulates the capacity
+// consumed by a request on a table.
+type consumed struct {
+	t       *table
+	units   float64
+	indexes map[string]float64
+}
+
+func newConsumed(t *table) *consumed {
+	return &consumed{t: t, indexes: map[string]float64{}}
+}
+
+// add adds units to the given index
+// or to the table if idx is nil.
+func (c *consumed) add(idx *index, units float64) {
+	if idx == nil {
+		c.units += units
+	} else {
+		c.indexes[idx.name] += units
+	}
+}
+
+// write adds the capacity consumed by a write. Indexes
+// are only charged if they contain the old or new item.
+func (c *consumed) write(w *write, transactional bool) {
+	scale := 1.0
+	if transactional {
+		scale = 2
+	}
+
+	size := max(itemSize(w.oldItem), itemSize(w.newItem))
+	c.units += writeUnits(size) * scale
+	for _, idx := range c.t.indexes {
+		if hasKeys(w.oldItem, idx.keySchema) || hasKeys(w.newItem, idx.keySchema) {
+			c.indexes[idx.name] += writeUnits(size) * scale
+		}
+	}
+}
+
+// output returns the consumed capacity
+// in the format of the given mode.
+func (c *consumed) output(mode *string) *db.ConsumedCapacity {
+	total := c.units
+	for _, units := range c.indexes {
+		total += units
+	}
+
+	cc := &db.ConsumedCapacity{
+		TableName:     aws.String(c.t.name),
+		CapacityUnits: aws.Float64(total),
+	}
+	switch aws.StringValue(mode) {
+	case db.ReturnConsumedCapacityTotal:
+		return cc
+	case db.ReturnConsumedCapacityIndexes:
+	default:
+		return nil
+	}
+
+	cc.Table = &db.Capacity{CapacityUnits: aws.Float64(c.units)}
+	for _, idx := range c.t.indexes {
+		units, ok := c.indexes[idx.name]
+		if !ok {
+			continue
+		}
+
+		capacity := &db.Capacity{CapacityUnits: aws.Float64(units)}
+		if idx.global {
+			if cc.GlobalSecondaryIndexes == nil {
+				cc.GlobalSecondaryIndexes = map[string]*db.Capacity{}
+			}
+			cc.GlobalSecondaryIndexes[idx.name] = capacity
+		} else {
+			if cc.LocalSecondaryIndexes == nil {
+				cc.LocalSecondaryIndexes = map[string]*db.Capacity{}
+			}
+			cc.LocalSecondaryIndexes[idx.name] = capacity
+		}
+	}
+
+	return cc
+}
+
+// outputs returns the consumed capacities of a batch
+// request sorted by table name. It returns nil if the
+// consumed capacity is not requested.
+func outputs(cs map[string]*consumed, mode *string) []*db.ConsumedCapacity {
+	var ccs []*db.ConsumedCapacity
+	for _, c := range cs {
+		if cc := c.output(mode); cc != nil {
+			ccs = append(ccs, cc)
+		}
+	}
+
+	sort.Slice(ccs, func(i, j int) bool {
+		return *ccs[i].TableName < *ccs[j].TableName
+	})
+	return ccs
+}
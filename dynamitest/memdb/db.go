@@ -0,0 +1,143 @@
+// Package memdb implements the DynamoDB and DynamoDB Streams
+// APIs in memory. It supports the operations used by dynami and
+// is meant for tests only. Operations that are not implemented
+// panic when called.
+package memdb
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	db "github.com/aws/aws-sdk-go/service/dynamodb"
+	dbiface "github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+)
+
+const (
+	// These are the maximum number of
+	// items per batch and transaction.
+	maxBatchGetItems   = 100
+	maxBatchWriteItems = 25
+	maxTransactItems   = 100
+
+	maxItemSize = 400 * 1024
+	maxPageSize = 1024 * 1024
+
+	// region is used in ARNs and stream records.
+	region = "memdb"
+)
+
+// DB is an in-memory DynamoDB database. It is
+// safe for concurrent use by multiple goroutines.
+type DB struct {
+	// DynamoDBAPI is nil so that
+	// unsupported operations panic.
+	dbiface.DynamoDBAPI
+
+	mu      sync.Mutex
+	tables  map[string]*table
+	streams map[string]*stream
+	seq     uint64
+
+	batchLimit int
+}
+
+// Option configures a database created by New.
+type Option func(*DB)
+
+// WithBatchLimit sets the maximum number of items processed by
+// each BatchGetItem and BatchWriteItem request. The rest of the
+// items are returned as unprocessed. This is useful for testing
+// how unprocessed items are handled.
+func WithBatchLimit(n int) Option {
+	return func(d *DB) {
+		d.batchLimit = n
+	}
+}
+
+// New creates an empty database.
+func New(opts ...Option) *DB {
+	d := &DB{
+		tables:  map[string]*table{},
+		streams: map[string]*stream{},
+	}
+
+	for _, opt := range opts {
+		opt(d)
+	}
+
+	return d
+}
+
+// nextSeqNum returns a new stream sequence number.
+// Sequence numbers are increasing and have the same
+// length so that they can be compared as strings.
+func (d *DB) nextSeqNum() string {
+	d.seq++
+	return fmt.Sprintf("%021d", d.seq)
+}
+
+// now returns the current time truncated to
+// seconds like the timestamps of DynamoDB.
+func now() time.Time {
+	return time.Now().Truncate(time.Second)
+}
+
+// checkContext returns an error if the context is
+// done like the SDK does for canceled requests.
+func checkContext(ctx context.Context) error {
+	if ctx == nil {
+		return nil
+	}
+
+	select {
+	case <-ctx.Done():
+		return awserr.New(request.CanceledErrorCode, "request context canceled", ctx.Err())
+	default:
+		return nil
+	}
+}
+
+func validationErr(format string, args ...interface{}) error {
+	return awserr.New("ValidationException", fmt.Sprintf(format, args...), nil)
+}
+
+func notFoundErr(format string, args ...interface{}) error {
+	return &db.ResourceNotFoundException{
+		Message_: aws.String(fmt.Sprintf(format, args...)),
+	}
+}
+
+func tableNotFoundErr() error {
+	return notFoundErr("Cannot do operations on a non-existent table")
+}
+
+func inUseErr(format string, args ...interface{}) error {
+	return &db.ResourceInUseException{
+		Message_: aws.String(fmt.Sprintf(format, args...)),
+	}
+}
+
+func conditionFailedErr() error {
+	return &db.ConditionalCheckFailedException{
+		Message_: aws.String("The conditional request failed"),
+	}
+}
+
+// table returns the table with the given
+// name. This must be called with d.mu held.
+func (d *DB) table(name *string) (*table, error) {
+	if name == nil || *name == "" {
+		return nil, validationErr("1 validation error detected: Value null at 'tableName' failed to satisfy constraint: Member must not be null")
+	}
+
+	t, ok := d.tables[*name]
+	if !ok {
+		return nil, tableNotFoundErr()
+	}
+	return t, nil
+}
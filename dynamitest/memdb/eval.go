@@ -0,0 +1,939 @@
+package memdb
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/aws/aws-sdk-go/aws"
+	db "github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+type item map[string]*db.AttributeValue
+
+// operand is evaluated to an attribute value. A nil
+// value means that the operand's path doesn't exist.
+type operand interface {
+	eval(it item) (*db.AttributeValue, error)
+}
+
+type valueOperand struct {
+	value *db.AttributeValue
+}
+
+func (o valueOperand) eval(it item) (*db.AttributeValue, error) {
+	return o.value, nil
+}
+
+type pathOperand struct {
+	path path
+}
+
+func (o pathOperand) eval(it item) (*db.AttributeValue, error) {
+	return getPath(it, o.path), nil
+}
+
+type sizeOperand struct {
+	path path
+}
+
+func (o sizeOperand) eval(it item) (*db.AttributeValue, error) {
+	v := getPath(it, o.path)
+	if v == nil {
+		return nil, nil
+	}
+
+	var n int
+	switch {
+	case v.S != nil:
+		n = utf8.RuneCountInString(*v.S)
+	case v.B != nil:
+		n = len(v.B)
+	case v.SS != nil:
+		n = len(v.SS)
+	case v.NS != nil:
+		n = len(v.NS)
+	case v.BS != nil:
+		n = len(v.BS)
+	case v.L != nil:
+		n = len(v.L)
+	case v.M != nil:
+		n = len(v.M)
+	default:
+		return nil, fmt.Errorf("Invalid ConditionExpression: Incorrect operand type for operator or function; operator or function: size, operand type: %v", attrType(v))
+	}
+
+	return &db.AttributeValue{N: aws.String(fmt.Sprint(n))}, nil
+}
+
+type ifNotExistsOperand struct {
+	path path
+	def  operand
+}
+
+func (o ifNotExistsOperand) eval(it item) (*db.AttributeValue, error) {
+	if v := getPath(it, o.path); v != nil {
+		return v, nil
+	}
+	return o.def.eval(it)
+}
+
+type listAppendOperand struct {
+	a, b operand
+}
+
+func (o listAppendOperand) eval(it item) (*db.AttributeValue, error) {
+	a, err := o.a.eval(it)
+	if err != nil {
+		return nil, err
+	}
+	b, err := o.b.eval(it)
+	if err != nil {
+		return nil, err
+	}
+
+	if a == nil || b == nil {
+		return nil, fmt.Errorf("The provided expression refers to an attribute that does not exist in the item")
+	} else if a.L == nil || b.L == nil {
+		return nil, fmt.Errorf("Invalid UpdateExpression: Incorrect operand type for operator or function; operator or function: list_append, operand type: %v", attrType(a))
+	}
+
+	l := make([]*db.AttributeValue, 0, len(a.L)+len(b.L))
+	l = append(l, a.L...)
+	l = append(l, b.L...)
+	return &db.AttributeValue{L: l}, nil
+}
+
+type arithOperand struct {
+	op   string
+	a, b operand
+}
+
+func (o arithOperand) eval(it item) (*db.AttributeValue, error) {
+	a, err := o.a.eval(it)
+	if err != nil {
+		return nil, err
+	}
+	b, err := o.b.eval(it)
+	if err != nil {
+		return nil, err
+	}
+
+	if a == nil || b == nil {
+		return nil, fmt.Errorf("The provided expression refers to an attribute that does not exist in the item")
+	} else if a.N == nil || b.N == nil {
+		return nil, fmt.Errorf("An operand in the update expression has an incorrect data type")
+	}
+
+	x, _ := parseNumber(*a.N)
+	y, _ := parseNumber(*b.N)
+	if o.op == "+" {
+		x.Add(x, y)
+	} else {
+		x.Sub(x, y)
+	}
+
+	return &db.AttributeValue{N: aws.String(formatNumber(x))}, nil
+}
+
+// condition is a parsed condition expression.
+type condition interface {
+	eval(it item) (bool, error)
+}
+
+type andCond struct {
+	a, b condition
+}
+
+func (c andCond) eval(it item) (bool, error) {
+	ok, err := c.a.eval(it)
+	if err != nil || !ok {
+		return false, err
+	}
+	return c.b.eval(it)
+}
+
+type orCond struct {
+	a, b condition
+}
+
+func (c orCond) eval(it item) (bool, error) {
+	ok, err := c.a.eval(it)
+	if err != nil || ok {
+		return ok, err
+	}
+	return c.b.eval(it)
+}
+
+type notCond struct {
+	c condition
+}
+
+func (c notCond) eval(it item) (bool, error) {
+	ok, err := c.c.eval(it)
+	return !ok, err
+}
+
+type compareCond struct {
+	op   string
+	a, b operand
+}
+
+func (c compareCond) eval(it item) (bool, error) {
+	a, err := c.a.eval(it)
+	if err != nil {
+		return false, err
+	}
+	b, err := c.b.eval(it)
+	if err != nil {
+		return false, err
+	}
+
+	switch c.op {
+	case "=":
+		return a != nil && b != nil && equal(a, b), nil
+	case "<>":
+		return a == nil || b == nil || !equal(a, b), nil
+	}
+
+	cmp, ok := compare(a, b)
+	if !ok {
+		return false, nil
+	}
+
+	switch c.op {
+	case "<":
+		return cmp < 0, nil
+	case "<=":
+		return cmp <= 0, nil
+	case ">":
+		return cmp > 0, nil
+	default:
+		return cmp >= 0, nil
+	}
+}
+
+type betweenCond struct {
+	a, lo, hi operand
+}
+
+func (c betweenCond) eval(it item) (bool, error) {
+	a, err := c.a.eval(it)
+	if err != nil {
+		return false, err
+	}
+	lo, err := c.lo.eval(it)
+	if err != nil {
+		return false, err
+	}
+	hi, err := c.hi.eval(it)
+	if err != nil {
+		return false, err
+	}
+
+	if cmp, ok := compare(lo, hi); ok && cmp > 0 {
+		return false, fmt.Errorf("Invalid ConditionExpression: The BETWEEN operator requires upper bound to be greater than or equal to lower bound")
+	}
+
+	clo, ok := compare(a, lo)
+	if !ok {
+		return false, nil
+	}
+	chi, ok := compare(a, hi)
+	if !ok {
+		return false, nil
+	}
+
+	return clo >= 0 && chi <= 0, nil
+}
+
+type inCond struct {
+	a    operand
+	list []operand
+}
+
+func (c inCond) eval(it item) (bool, error) {
+	a, err := c.a.eval(it)
+	if err != nil || a == nil {
+		return false, err
+	}
+
+	for _, op := range c.list {
+		v, err := op.eval(it)
+		if err != nil {
+			return false, err
+		}
+		if v != nil && equal(a, v) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+type existsCond struct {
+	path   path
+	exists bool
+}
+
+func (c existsCond) eval(it item) (bool, error) {
+	return (getPath(it, c.path) != nil) == c.exists, nil
+}
+
+type funcCond struct {
+	name string
+	path path
+	arg  operand
+}
+
+func (c funcCond) eval(it item) (bool, error) {
+	v := getPath(it, c.path)
+	arg, err := c.arg.eval(it)
+	if err != nil || v == nil || arg == nil {
+		return false, err
+	}
+
+	switch c.name {
+	case "attribute_type":
+		if arg.S == nil {
+			return false, fmt.Errorf("Invalid ConditionExpression: Incorrect operand type for operator or function; operator or function: attribute_type, operand type: %v", attrType(arg))
+		}
+		return attrType(v) == *arg.S, nil
+
+	case "begins_with":
+		switch {
+		case v.S != nil && arg.S != nil:
+			return strings.HasPrefix(*v.S, *arg.S), nil
+		case v.B != nil && arg.B != nil:
+			return bytes.HasPrefix(v.B, arg.B), nil
+		}
+		return false, nil
+
+	default: // contains
+		switch {
+		case v.S != nil && arg.S != nil:
+			return strings.Contains(*v.S, *arg.S), nil
+		case v.B != nil && arg.B != nil:
+			return bytes.Contains(v.B, arg.B), nil
+		case v.SS != nil && arg.S != nil:
+			for _, s := range v.SS {
+				if *s == *arg.S {
+					return true, nil
+				}
+			}
+		case v.NS != nil && arg.N != nil:
+			for _, n := range v.NS {
+				if numberEqual(*n, *arg.N) {
+					return true, nil
+				}
+			}
+		case v.BS != nil && arg.B != nil:
+			for _, b := range v.BS {
+				if bytes.Equal(b, arg.B) {
+					return true, nil
+				}
+			}
+		case v.L != nil:
+			for _, e := range v.L {
+				if equal(e, arg) {
+					return true, nil
+				}
+			}
+		}
+		return false, nil
+	}
+}
+
+// getPath returns the value at the given
+// path or nil if the path doesn't exist.
+func getPath(it item, p path) *db.AttributeValue {
+	if len(p) == 0 || p[0].isIndex {
+		return nil
+	}
+
+	v := it[p[0].name]
+	for _, e := range p[1:] {
+		if v == nil {
+			return nil
+		}
+
+		if e.isIndex {
+			if v.L == nil || e.index >= len(v.L) {
+				return nil
+			}
+			v = v.L[e.index]
+		} else {
+			if v.M == nil {
+				return nil
+			}
+			v = v.M[e.name]
+		}
+	}
+
+	return v
+}
+
+// setPath sets the value at the given path. The parent
+// of the path must exist. Setting a list index beyond
+// the end of the list appends the value.
+func setPath(it item, p path, v *db.AttributeValue) error {
+	if len(p) == 1 {
+		it[p[0].name] = v
+		return nil
+	}
+
+	parent := getPath(it, p[:len(p)-1])
+	last := p[len(p)-1]
+	switch {
+	case parent == nil:
+	case last.isIndex && parent.L != nil:
+		if last.index < len(parent.L) {
+			parent.L[last.index] = v
+		} else {
+			parent.L = append(parent.L, v)
+		}
+		return nil
+	case !last.isIndex && parent.M != nil:
+		parent.M[last.name] = v
+		return nil
+	}
+
+	return fmt.Errorf("The document path provided in the update expression is invalid for update")
+}
+
+// removePath removes the value at the given path.
+// This does nothing if the path doesn't exist.
+func removePath(it item, p path) {
+	if len(p) == 1 {
+		delete(it, p[0].name)
+		return
+	}
+
+	parent := getPath(it, p[:len(p)-1])
+	last := p[len(p)-1]
+	switch {
+	case parent == nil:
+	case last.isIndex && parent.L != nil:
+		if last.index < len(parent.L) {
+			parent.L = append(parent.L[:last.index], parent.L[last.index+1:]...)
+		}
+	case !last.isIndex && parent.M != nil:
+		delete(parent.M, last.name)
+	}
+}
+
+// attrType returns the DynamoDB
+// data type name of an attribute.
+func attrType(v *db.AttributeValue) string {
+	switch {
+	case v.S != nil:
+		return "S"
+	case v.N != nil:
+		return "N"
+	case v.B != nil:
+		return "B"
+	case v.BOOL != nil:
+		return "BOOL"
+	case v.NULL != nil:
+		return "NULL"
+	case v.SS != nil:
+		return "SS"
+	case v.NS != nil:
+		return "NS"
+	case v.BS != nil:
+		return "BS"
+	case v.L != nil:
+		return "L"
+	case v.M != nil:
+		return "M"
+	}
+
+	return ""
+}
+
+// compare compares two scalar values of the
+// same type. ok is false if they can't be compared.
+func compare(a, b *db.AttributeValue) (cmp int, ok bool) {
+	if a == nil || b == nil {
+		return 0, false
+	}
+
+	switch {
+	case a.N != nil && b.N != nil:
+		x, err := parseNumber(*a.N)
+		if err != nil {
+			return 0, false
+		}
+		y, err := parseNumber(*b.N)
+		if err != nil {
+			return 0, false
+		}
+		return x.Cmp(y), true
+	case a.S != nil && b.S != nil:
+		return strings.Compare(*a.S, *b.S), true
+	case a.B != nil && b.B != nil:
+		return bytes.Compare(a.B, b.B), true
+	}
+
+	return 0, false
+}
+
+// equal returns true if two values have the same
+// type and value. Sets are compared regardless of
+// the order of their elements.
+func equal(a, b *db.AttributeValue) bool {
+	if attrType(a) != attrType(b) {
+		return false
+	}
+
+	switch {
+	case a.S != nil:
+		return *a.S == *b.S
+	case a.N != nil:
+		return numberEqual(*a.N, *b.N)
+	case a.B != nil:
+		return bytes.Equal(a.B, b.B)
+	case a.BOOL != nil:
+		return *a.BOOL == *b.BOOL
+	case a.NULL != nil:
+		return true
+	case a.SS != nil:
+		return setEqual(len(a.SS), len(b.SS), func(i, j int) bool {
+			return *a.SS[i] == *b.SS[j]
+		})
+	case a.NS != nil:
+		return setEqual(len(a.NS), len(b.NS), func(i, j int) bool {
+			return numberEqual(*a.NS[i], *b.NS[j])
+		})
+	case a.BS != nil:
+		return setEqual(len(a.BS), len(b.BS), func(i, j int) bool {
+			return bytes.Equal(a.BS[i], b.BS[j])
+		})
+	case a.L != nil:
+		if len(a.L) != len(b.L) {
+			return false
+		}
+		for i := range a.L {
+			if !equal(a.L[i], b.L[i]) {
+				return false
+			}
+		}
+		return true
+	case a.M != nil:
+		if len(a.M) != len(b.M) {
+			return false
+		}
+		for k, v := range a.M {
+			w, ok := b.M[k]
+			if !ok || !equal(v, w) {
+				return false
+			}
+		}
+		return true
+	}
+
+	return false
+}
+
+func setEqual(m, n int, eq func(i, j int) bool) bool {
+	if m != n {
+		return false
+	}
+
+	for i := 0; i < m; i++ {
+		found := false
+		for j := 0; j < n && !found; j++ {
+			found = eq(i, j)
+		}
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
+
+func parseNumber(s string) (*big.Rat, error) {
+	r, ok := new(big.Rat).SetString(strings.TrimSpace(s))
+	if !ok {
+		return nil, fmt.Errorf("The parameter cannot be converted to a numeric value: %v", s)
+	}
+	return r, nil
+}
+
+// formatNumber returns the shortest decimal representation
+// of a number. Numbers in DynamoDB have at most 38 digits.
+func formatNumber(r *big.Rat) string {
+	if r.IsInt() {
+		return r.Num().String()
+	}
+
+	s := r.FloatString(38)
+	s = strings.TrimRight(s, "0")
+	return strings.TrimSuffix(s, ".")
+}
+
+func numberEqual(a, b string) bool {
+	x, err := parseNumber(a)
+	if err != nil {
+		return false
+	}
+	y, err := parseNumber(b)
+	if err != nil {
+		return false
+	}
+
+	return x.Cmp(y) == 0
+}
+
+// copyValue returns a deep copy of v.
+func copyValue(v *db.AttributeValue) *db.AttributeValue {
+	if v == nil {
+		return nil
+	}
+
+	cpy := *v
+	if v.B != nil {
+		cpy.B = append([]byte{}, v.B...)
+	}
+	if v.SS != nil {
+		cpy.SS = append([]*string{}, v.SS...)
+	}
+	if v.NS != nil {
+		cpy.NS = append([]*string{}, v.NS...)
+	}
+	if v.BS != nil {
+		cpy.BS = make([][]byte, len(v.BS))
+		for i, b := range v.BS {
+			cpy.BS[i] = append([]byte{}, b...)
+		}
+	}
+	if v.L != nil {
+		cpy.L = make([]*db.AttributeValue, len(v.L))
+		for i, e := range v.L {
+			cpy.L[i] = copyValue(e)
+		}
+	}
+	if v.M != nil {
+		cpy.M = copyItem(v.M)
+	}
+
+	return &cpy
+}
+
+// copyItem returns a deep copy of it.
+func copyItem(it map[string]*db.AttributeValue) item {
+	if it == nil {
+		return nil
+	}
+
+	cpy := item{}
+	for k, v := range it {
+		cpy[k] = copyValue(v)
+	}
+	return cpy
+}
+
+// validateValue checks that exactly one data type is
+// set in v, that numbers are valid, and that sets are
+// nonempty and have no duplicates.
+func validateValue(v *db.AttributeValue) error {
+	if v == nil {
+		return fmt.Errorf("Supplied AttributeValue is empty, must contain exactly one of the supported datatypes")
+	}
+
+	n := 0
+	for _, set := range []bool{
+		v.S != nil, v.N != nil, v.B != nil,
+		v.BOOL != nil, v.NULL != nil,
+		v.SS != nil, v.NS != nil, v.BS != nil,
+		v.L != nil, v.M != nil,
+	} {
+		if set {
+			n++
+		}
+	}
+
+	if n == 0 {
+		return fmt.Errorf("Supplied AttributeValue is empty, must contain exactly one of the supported datatypes")
+	} else if n > 1 {
+		return fmt.Errorf("Supplied AttributeValue has more than one datatypes set, must contain exactly one of the supported datatypes")
+	}
+
+	switch {
+	case v.N != nil:
+		if _, err := parseNumber(*v.N); err != nil {
+			return err
+		}
+	case v.NULL != nil && !*v.NULL:
+		return fmt.Errorf("One or more parameter values were invalid: Null attribute value types must have the value of true")
+	case v.SS != nil:
+		if len(v.SS) == 0 {
+			return fmt.Errorf("One or more parameter values were invalid: An string set  may not be empty")
+		}
+		for i, s := range v.SS {
+			for _, t := range v.SS[:i] {
+				if *s == *t {
+					return fmt.Errorf("One or more parameter values were invalid: Input collection contains duplicates")
+				}
+			}
+		}
+	case v.NS != nil:
+		if len(v.NS) == 0 {
+			return fmt.Errorf("One or more parameter values were invalid: An number set  may not be empty")
+		}
+		for i, s := range v.NS {
+			if _, err := parseNumber(*s); err != nil {
+				return err
+			}
+			for _, t := range v.NS[:i] {
+				if numberEqual(*s, *t) {
+					return fmt.Errorf("One or more parameter values were invalid: Input collection contains duplicates")
+				}
+			}
+		}
+	case v.BS != nil:
+		if len(v.BS) == 0 {
+			return fmt.Errorf("One or more parameter values were invalid: Binary sets should not be empty")
+		}
+		for i, b := range v.BS {
+			for _, c := range v.BS[:i] {
+				if bytes.Equal(b, c) {
+					return fmt.Errorf("One or more parameter values were invalid: Input collection contains duplicates")
+				}
+			}
+		}
+	case v.L != nil:
+		for _, e := range v.L {
+			if err := validateValue(e); err != nil {
+				return err
+			}
+		}
+	case v.M != nil:
+		for _, e := range v.M {
+			if err := validateValue(e); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// itemSize approximates the size of an item in bytes
+// by adding the lengths of its names and values.
+func itemSize(it item) int {
+	size := 0
+	for k, v := range it {
+		size += len(k) + valueSize(v)
+	}
+	return size
+}
+
+func valueSize(v *db.AttributeValue) int {
+	switch {
+	case v.S != nil:
+		return len(*v.S)
+	case v.N != nil:
+		return len(*v.N)
+	case v.B != nil:
+		return len(v.B)
+	case v.SS != nil:
+		n := 0
+		for _, s := range v.SS {
+			n += len(*s)
+		}
+		return n
+	case v.NS != nil:
+		n := 0
+		for _, s := range v.NS {
+			n += len(*s)
+		}
+		return n
+	case v.BS != nil:
+		n := 0
+		for _, b := range v.BS {
+			n += len(b)
+		}
+		return n
+	case v.L != nil:
+		n := 3
+		for _, e := range v.L {
+			n += 1 + valueSize(e)
+		}
+		return n
+	case v.M != nil:
+		return 3 + itemSize(v.M)
+	}
+
+	return 1
+}
+
+var errMissingAttr = fmt.Errorf("The provided expression refers to an attribute that does not exist in the item")
+
+func errIncorrectOperand(action string, v *db.AttributeValue) error {
+	return fmt.Errorf("Invalid UpdateExpression: Incorrect operand type for operator or function; operator: %v, operand type: %v", action, attrType(v))
+}
+
+// add returns the sum of two numbers or the union
+// of two sets of the same type for ADD actions.
+func add(a, b *db.AttributeValue) (*db.AttributeValue, error) {
+	switch {
+	case a.N != nil && b.N != nil:
+		x, _ := parseNumber(*a.N)
+		y, _ := parseNumber(*b.N)
+		return &db.AttributeValue{N: aws.String(formatNumber(x.Add(x, y)))}, nil
+
+	case a.SS != nil && b.SS != nil:
+		sum := copyValue(a)
+		for _, s := range b.SS {
+			if !containsString(sum.SS, *s) {
+				sum.SS = append(sum.SS, s)
+			}
+		}
+		return sum, nil
+
+	case a.NS != nil && b.NS != nil:
+		sum := copyValue(a)
+		for _, n := range b.NS {
+			if !containsNumber(sum.NS, *n) {
+				sum.NS = append(sum.NS, n)
+			}
+		}
+		return sum, nil
+
+	case a.BS != nil && b.BS != nil:
+		sum := copyValue(a)
+		for _, bs := range b.BS {
+			if !containsBinary(sum.BS, bs) {
+				sum.BS = append(sum.BS, bs)
+			}
+		}
+		return sum, nil
+	}
+
+	return nil, fmt.Errorf("An operand in the update expression has an incorrect data type")
+}
+
+// subtract returns the difference of two sets of the same
+// type for DELETE actions. This returns nil if the result
+// is empty since sets can't be empty.
+func subtract(a, b *db.AttributeValue) (*db.AttributeValue, error) {
+	diff := &db.AttributeValue{}
+	switch {
+	case a.SS != nil && b.SS != nil:
+		for _, s := range a.SS {
+			if !containsString(b.SS, *s) {
+				diff.SS = append(diff.SS, s)
+			}
+		}
+	case a.NS != nil && b.NS != nil:
+		for _, n := range a.NS {
+			if !containsNumber(b.NS, *n) {
+				diff.NS = append(diff.NS, n)
+			}
+		}
+	case a.BS != nil && b.BS != nil:
+		for _, bs := range a.BS {
+			if !containsBinary(b.BS, bs) {
+				diff.BS = append(diff.BS, bs)
+			}
+		}
+	default:
+		return nil, fmt.Errorf("An operand in the update expression has an incorrect data type")
+	}
+
+	if diff.SS == nil && diff.NS == nil && diff.BS == nil {
+		return nil, nil
+	}
+	return diff, nil
+}
+
+func containsString(set []*string, s string) bool {
+	for _, e := range set {
+		if *e == s {
+			return true
+		}
+	}
+	return false
+}
+
+func containsNumber(set []*string, n string) bool {
+	for _, e := range set {
+		if numberEqual(*e, n) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsBinary(set [][]byte, b []byte) bool {
+	for _, e := range set {
+		if bytes.Equal(e, b) {
+			return true
+		}
+	}
+	return false
+}
+
+// project returns a copy of an item that only contains
+// the given paths. The whole item is copied if there
+// are no paths.
+func project(it item, paths []path) item {
+	if len(paths) == 0 {
+		return copyItem(it)
+	}
+
+	projected := item{}
+	for _, p := range paths {
+		v := getPath(it, p)
+		if v == nil {
+			continue
+		}
+		mergePath(projected, p, copyValue(v))
+	}
+
+	return projected
+}
+
+// mergePath sets the value at the given path creating
+// the intermediate maps and lists as needed. Projected
+// list elements are compacted like in DynamoDB.
+func mergePath(it item, p path, v *db.AttributeValue) {
+	parent := &db.AttributeValue{M: it}
+	for i, e := range p {
+		last := i == len(p)-1
+		if e.isIndex {
+			if last {
+				parent.L = append(parent.L, v)
+				return
+			}
+
+			next := p[i+1]
+			child := &db.AttributeValue{M: map[string]*db.AttributeValue{}}
+			if next.isIndex {
+				child = &db.AttributeValue{L: []*db.AttributeValue{}}
+			}
+			parent.L = append(parent.L, child)
+			parent = child
+			continue
+		}
+
+		if last {
+			parent.M[e.name] = v
+			return
+		}
+
+		child, ok := parent.M[e.name]
+		if !ok {
+			child = &db.AttributeValue{M: map[string]*db.AttributeValue{}}
+			if p[i+1].isIndex {
+				child = &db.AttributeValue{L: []*db.AttributeValue{}}
+			}
+			parent.M[e.name] = child
+		}
+		parent = child
+	}
+}
@@ -0,0 +1,699 @@
+package memdb
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	db "github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokName
+	tokValue
+	tokNumber
+	tokSymbol
+)
+
+type token struct {
+	kind tokenKind
+	text string
+}
+
+// tokenize splits an expression into tokens. Identifiers
+// include attribute names, keywords, and function names.
+func tokenize(expr string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(expr); {
+		c := expr[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+
+		case c == '#' || c == ':':
+			j := i + 1
+			for j < len(expr) && isIdentChar(expr[j]) {
+				j++
+			}
+			if j == i+1 {
+				return nil, fmt.Errorf("invalid placeholder at position %d", i)
+			}
+
+			kind := tokName
+			if c == ':' {
+				kind = tokValue
+			}
+			tokens = append(tokens, token{kind, expr[i:j]})
+			i = j
+
+		case c >= '0' && c <= '9':
+			j := i
+			for j < len(expr) && expr[j] >= '0' && expr[j] <= '9' {
+				j++
+			}
+			tokens = append(tokens, token{tokNumber, expr[i:j]})
+			i = j
+
+		case isIdentChar(c):
+			j := i
+			for j < len(expr) && isIdentChar(expr[j]) {
+				j++
+			}
+			tokens = append(tokens, token{tokIdent, expr[i:j]})
+			i = j
+
+		default:
+			sym := string(c)
+			if i+1 < len(expr) {
+				switch expr[i : i+2] {
+				case "<>", "<=", ">=":
+					sym = expr[i : i+2]
+				}
+			}
+
+			if !strings.Contains("()[],.=<>+-", string(c)) {
+				return nil, fmt.Errorf("invalid character %q at position %d", c, i)
+			}
+			tokens = append(tokens, token{tokSymbol, sym})
+			i += len(sym)
+		}
+	}
+
+	return append(tokens, token{kind: tokEOF}), nil
+}
+
+func isIdentChar(c byte) bool {
+	return c == '_' ||
+		(c >= 'a' && c <= 'z') ||
+		(c >= 'A' && c <= 'Z') ||
+		(c >= '0' && c <= '9')
+}
+
+// pathElem is an element of a document path. It's
+// either an attribute name or a list index.
+type pathElem struct {
+	name    string
+	index   int
+	isIndex bool
+}
+
+type path []pathElem
+
+func (p path) String() string {
+	var b strings.Builder
+	for i, e := range p {
+		if e.isIndex {
+			fmt.Fprintf(&b, "[%d]", e.index)
+			continue
+		}
+
+		if i > 0 {
+			b.WriteByte('.')
+		}
+		b.WriteString(e.name)
+	}
+
+	return b.String()
+}
+
+// overlaps returns true if p is a prefix of q or vice versa.
+func (p path) overlaps(q path) bool {
+	n := len(p)
+	if len(q) < n {
+		n = len(q)
+	}
+
+	for i := 0; i < n; i++ {
+		if p[i] != q[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// parser parses condition, key condition,
+// update, and projection expressions.
+type parser struct {
+	tokens []token
+	pos    int
+
+	names  map[string]*string
+	values map[string]*db.AttributeValue
+
+	// usedNames and usedValues record the placeholders
+	// used so that unused ones can be reported.
+	usedNames  map[string]bool
+	usedValues map[string]bool
+
+	// paths contains every path in the
+	// expressions parsed so far.
+	paths []path
+}
+
+func newParser(
+	names map[string]*string,
+	values map[string]*db.AttributeValue) *parser {
+
+	return &parser{
+		names:      names,
+		values:     values,
+		usedNames:  map[string]bool{},
+		usedValues: map[string]bool{},
+	}
+}
+
+// checkUnused returns an error if some of the
+// placeholders are not used in any expression.
+func (p *parser) checkUnused() error {
+	for name := range p.names {
+		if !p.usedNames[name] {
+			return fmt.Errorf("Value provided in ExpressionAttributeNames unused in expressions: keys: {%v}", name)
+		}
+	}
+	for value := range p.values {
+		if !p.usedValues[value] {
+			return fmt.Errorf("Value provided in ExpressionAttributeValues unused in expressions: keys: {%v}", value)
+		}
+	}
+
+	return nil
+}
+
+func (p *parser) reset(expr string) error {
+	tokens, err := tokenize(expr)
+	if err != nil {
+		return fmt.Errorf("Invalid expression: %v", err)
+	}
+
+	p.tokens = tokens
+	p.pos = 0
+	return nil
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) isSymbol(sym string) bool {
+	t := p.peek()
+	return t.kind == tokSymbol && t.text == sym
+}
+
+func (p *parser) isKeyword(kw string) bool {
+	t := p.peek()
+	return t.kind == tokIdent && strings.EqualFold(t.text, kw)
+}
+
+func (p *parser) expectSymbol(sym string) error {
+	if !p.isSymbol(sym) {
+		return p.unexpected()
+	}
+	p.next()
+	return nil
+}
+
+func (p *parser) expectEOF() error {
+	if p.peek().kind != tokEOF {
+		return p.unexpected()
+	}
+	return nil
+}
+
+func (p *parser) unexpected() error {
+	t := p.peek()
+	if t.kind == tokEOF {
+		return fmt.Errorf("Invalid expression: unexpected end of expression")
+	}
+	return fmt.Errorf("Invalid expression: syntax error; token: %q", t.text)
+}
+
+// parsePath parses a document path, eg. #a.b[1].#c
+func (p *parser) parsePath() (path, error) {
+	var pth path
+	for {
+		t := p.next()
+		switch t.kind {
+		case tokIdent:
+			if isReserved(t.text) {
+				return nil, fmt.Errorf("Invalid expression: attribute name is a reserved keyword; reserved keyword: %v", t.text)
+			}
+			pth = append(pth, pathElem{name: t.text})
+		case tokName:
+			name, ok := p.names[t.text]
+			if !ok || name == nil {
+				return nil, fmt.Errorf("Invalid expression: An expression attribute name used in the document path is not defined; attribute name: %v", t.text)
+			}
+			p.usedNames[t.text] = true
+			pth = append(pth, pathElem{name: *name})
+		default:
+			p.pos--
+			return nil, p.unexpected()
+		}
+
+		// Parse list indices
+		for p.isSymbol("[") {
+			p.next()
+			t := p.next()
+			if t.kind != tokNumber {
+				p.pos--
+				return nil, p.unexpected()
+			}
+			idx, err := strconv.Atoi(t.text)
+			if err != nil {
+				return nil, fmt.Errorf("Invalid expression: invalid list index %v", t.text)
+			}
+			pth = append(pth, pathElem{index: idx, isIndex: true})
+
+			if err := p.expectSymbol("]"); err != nil {
+				return nil, err
+			}
+		}
+
+		if !p.isSymbol(".") {
+			break
+		}
+		p.next()
+	}
+
+	p.paths = append(p.paths, pth)
+	return pth, nil
+}
+
+func (p *parser) parseValue() (*db.AttributeValue, error) {
+	t := p.next()
+	if t.kind != tokValue {
+		p.pos--
+		return nil, p.unexpected()
+	}
+
+	v, ok := p.values[t.text]
+	if !ok || v == nil {
+		return nil, fmt.Errorf("Invalid expression: An expression attribute value used in expression is not defined; attribute value: %v", t.text)
+	}
+	p.usedValues[t.text] = true
+
+	return v, nil
+}
+
+// parseCondition parses a condition or filter expression.
+func (p *parser) parseCondition(expr string) (condition, error) {
+	if err := p.reset(expr); err != nil {
+		return nil, err
+	}
+
+	cond, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	} else if err := p.expectEOF(); err != nil {
+		return nil, err
+	}
+
+	return cond, nil
+}
+
+func (p *parser) parseOr() (condition, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.isKeyword("OR") {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orCond{left, right}
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseAnd() (condition, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.isKeyword("AND") {
+		p.next()
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = andCond{left, right}
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseNot() (condition, error) {
+	if p.isKeyword("NOT") {
+		p.next()
+		cond, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return notCond{cond}, nil
+	}
+
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (condition, error) {
+	if p.isSymbol("(") {
+		p.next()
+		cond, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		return cond, p.expectSymbol(")")
+	}
+
+	// Condition functions
+	t := p.peek()
+	if t.kind == tokIdent && p.tokens[p.pos+1].text == "(" {
+		switch t.text {
+		case "attribute_exists", "attribute_not_exists":
+			p.next()
+			p.next()
+			pth, err := p.parsePath()
+			if err != nil {
+				return nil, err
+			}
+			return existsCond{pth, t.text == "attribute_exists"}, p.expectSymbol(")")
+
+		case "attribute_type", "begins_with", "contains":
+			p.next()
+			p.next()
+			pth, err := p.parsePath()
+			if err != nil {
+				return nil, err
+			} else if err := p.expectSymbol(","); err != nil {
+				return nil, err
+			}
+
+			arg, err := p.parseOperand()
+			if err != nil {
+				return nil, err
+			}
+			return funcCond{t.text, pth, arg}, p.expectSymbol(")")
+		}
+	}
+
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case p.isKeyword("BETWEEN"):
+		p.next()
+		lo, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+
+		if !p.isKeyword("AND") {
+			return nil, p.unexpected()
+		}
+		p.next()
+
+		hi, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		return betweenCond{left, lo, hi}, nil
+
+	case p.isKeyword("IN"):
+		p.next()
+		if err := p.expectSymbol("("); err != nil {
+			return nil, err
+		}
+
+		var list []operand
+		for {
+			op, err := p.parseOperand()
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, op)
+
+			if !p.isSymbol(",") {
+				break
+			}
+			p.next()
+		}
+		return inCond{left, list}, p.expectSymbol(")")
+	}
+
+	t = p.next()
+	if t.kind != tokSymbol {
+		p.pos--
+		return nil, p.unexpected()
+	}
+
+	switch t.text {
+	case "=", "<>", "<", "<=", ">", ">=":
+		right, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		return compareCond{t.text, left, right}, nil
+	}
+
+	p.pos--
+	return nil, p.unexpected()
+}
+
+// parseOperand parses an operand of a condition.
+func (p *parser) parseOperand() (operand, error) {
+	t := p.peek()
+	switch {
+	case t.kind == tokValue:
+		v, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		return valueOperand{v}, nil
+
+	case t.kind == tokIdent && t.text == "size" && p.tokens[p.pos+1].text == "(":
+		p.next()
+		p.next()
+		pth, err := p.parsePath()
+		if err != nil {
+			return nil, err
+		}
+		return sizeOperand{pth}, p.expectSymbol(")")
+	}
+
+	pth, err := p.parsePath()
+	if err != nil {
+		return nil, err
+	}
+	return pathOperand{pth}, nil
+}
+
+// updateAction is a single action in an update expression.
+type updateAction struct {
+	kind  string
+	path  path
+	value operand
+}
+
+// parseUpdate parses an update expression.
+func (p *parser) parseUpdate(expr string) ([]updateAction, error) {
+	if err := p.reset(expr); err != nil {
+		return nil, err
+	}
+
+	var actions []updateAction
+	clauses := map[string]bool{}
+	for p.peek().kind != tokEOF {
+		t := p.next()
+		kind := strings.ToUpper(t.text)
+		if t.kind != tokIdent {
+			p.pos--
+			return nil, p.unexpected()
+		}
+
+		switch kind {
+		case "SET", "REMOVE", "ADD", "DELETE":
+		default:
+			p.pos--
+			return nil, p.unexpected()
+		}
+
+		if clauses[kind] {
+			return nil, fmt.Errorf("Invalid UpdateExpression: The \"%v\" section can only be used once in an update expression", kind)
+		}
+		clauses[kind] = true
+
+		for {
+			pth, err := p.parsePath()
+			if err != nil {
+				return nil, err
+			}
+
+			action := updateAction{kind: kind, path: pth}
+			switch kind {
+			case "SET":
+				if err := p.expectSymbol("="); err != nil {
+					return nil, err
+				}
+				action.value, err = p.parseSetValue()
+			case "ADD", "DELETE":
+				var v *db.AttributeValue
+				v, err = p.parseValue()
+				action.value = valueOperand{v}
+			}
+			if err != nil {
+				return nil, err
+			}
+			actions = append(actions, action)
+
+			if !p.isSymbol(",") {
+				break
+			}
+			p.next()
+		}
+	}
+
+	if len(actions) == 0 {
+		return nil, fmt.Errorf("Invalid UpdateExpression: The expression can not be empty")
+	}
+
+	// Paths can't overlap
+	for i := range actions {
+		for j := i + 1; j < len(actions); j++ {
+			if actions[i].path.overlaps(actions[j].path) {
+				return nil, fmt.Errorf(
+					"Invalid UpdateExpression: Two document paths overlap with each other; must remove or rewrite one of these paths; path one: [%v], path two: [%v]",
+					actions[i].path,
+					actions[j].path,
+				)
+			}
+		}
+	}
+
+	return actions, nil
+}
+
+// parseSetValue parses the value of a SET action
+// which may add or subtract two operands.
+func (p *parser) parseSetValue() (operand, error) {
+	left, err := p.parseSetOperand()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.isSymbol("+") || p.isSymbol("-") {
+		op := p.next().text
+		right, err := p.parseSetOperand()
+		if err != nil {
+			return nil, err
+		}
+		return arithOperand{op, left, right}, nil
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseSetOperand() (operand, error) {
+	t := p.peek()
+	switch {
+	case t.kind == tokValue:
+		v, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		return valueOperand{v}, nil
+
+	case t.kind == tokIdent && t.text == "if_not_exists" && p.tokens[p.pos+1].text == "(":
+		p.next()
+		p.next()
+		pth, err := p.parsePath()
+		if err != nil {
+			return nil, err
+		} else if err := p.expectSymbol(","); err != nil {
+			return nil, err
+		}
+
+		def, err := p.parseSetOperand()
+		if err != nil {
+			return nil, err
+		}
+		return ifNotExistsOperand{pth, def}, p.expectSymbol(")")
+
+	case t.kind == tokIdent && t.text == "list_append" && p.tokens[p.pos+1].text == "(":
+		p.next()
+		p.next()
+		a, err := p.parseSetOperand()
+		if err != nil {
+			return nil, err
+		} else if err := p.expectSymbol(","); err != nil {
+			return nil, err
+		}
+
+		b, err := p.parseSetOperand()
+		if err != nil {
+			return nil, err
+		}
+		return listAppendOperand{a, b}, p.expectSymbol(")")
+	}
+
+	pth, err := p.parsePath()
+	if err != nil {
+		return nil, err
+	}
+	return pathOperand{pth}, nil
+}
+
+// parseProjection parses a projection expression.
+func (p *parser) parseProjection(expr string) ([]path, error) {
+	if err := p.reset(expr); err != nil {
+		return nil, err
+	}
+
+	var paths []path
+	for {
+		pth, err := p.parsePath()
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, pth)
+
+		if !p.isSymbol(",") {
+			break
+		}
+		p.next()
+	}
+
+	return paths, p.expectEOF()
+}
+
+// isReserved returns true if name is one of the keywords
+// used in expressions. DynamoDB reserves many more words
+// but these are the ones that would make parsing ambiguous.
+func isReserved(name string) bool {
+	switch strings.ToUpper(name) {
+	case "AND", "OR", "NOT", "BETWEEN", "IN",
+		"SET", "REMOVE", "ADD", "DELETE":
+		return true
+	}
+
+	return false
+}
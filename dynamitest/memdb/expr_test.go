@@ -0,0 +1,170 @@
+package memdb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aws/aws-sdk-go/aws"
+	db "github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+func TestCondition(t *testing.T) {
+	it := item{
+		"S":    {S: aws.String("hello")},
+		"N":    {N: aws.String("10")},
+		"SS":   {SS: aws.StringSlice([]string{"a", "b"})},
+		"L":    {L: []*db.AttributeValue{{N: aws.String("1")}, {S: aws.String("x")}}},
+		"M":    {M: map[string]*db.AttributeValue{"Nested": {S: aws.String("y")}}},
+		"Name": {S: aws.String("named")},
+	}
+
+	names := map[string]*string{"#name": aws.String("Name")}
+	values := map[string]*db.AttributeValue{
+		":s":  {S: aws.String("hel")},
+		":n":  {N: aws.String("10.0")},
+		":lo": {N: aws.String("5")},
+		":hi": {N: aws.String("15")},
+		":a":  {S: aws.String("a")},
+		":y":  {S: aws.String("y")},
+		":x":  {S: aws.String("x")},
+		":t":  {S: aws.String("SS")},
+		":nm": {S: aws.String("named")},
+	}
+
+	tests := []struct {
+		expr     string
+		expected bool
+	}{
+		{"N = :n", true},
+		{"N <> :n", false},
+		{"N BETWEEN :lo AND :hi", true},
+		{"N < :lo OR N > :hi", false},
+		{"NOT (N < :lo)", true},
+		{"begins_with(S, :s)", true},
+		{"contains(SS, :a)", true},
+		{"contains(S, :a)", false},
+		{"attribute_type(SS, :t)", true},
+		{"attribute_exists(M.Nested) AND attribute_not_exists(M.Other)", true},
+		{"M.Nested = :y AND L[1] = :x", true},
+		{"size(L) < :lo", true},
+		{"S IN (:a, :x)", false},
+		{"#name IN (:a, :nm)", true},
+		{"Missing = :a", false},
+		{"Missing <> :a", true},
+	}
+
+	for _, test := range tests {
+		p := newParser(names, values)
+		cond, err := p.parseCondition(test.expr)
+		require.Nil(t, err, test.expr)
+
+		ok, err := cond.eval(it)
+		require.Nil(t, err, test.expr)
+		assert.Equal(t, test.expected, ok, test.expr)
+	}
+
+	// Invalid expressions
+	p := newParser(names, values)
+	for _, expr := range []string{
+		"N =",
+		"N = :undefined",
+		"#undefined = :n",
+		"N = :n AND",
+		"unknown_func(N)",
+		"(N = :n",
+	} {
+		_, err := p.parseCondition(expr)
+		assert.NotNil(t, err, expr)
+	}
+
+	// Unused placeholders
+	p = newParser(names, values)
+	_, err := p.parseCondition("N = :n")
+	require.Nil(t, err)
+	assert.NotNil(t, p.checkUnused())
+}
+
+func TestUpdate(t *testing.T) {
+	it := item{
+		"Key":   {S: aws.String("key")},
+		"Count": {N: aws.String("1")},
+		"Tags":  {SS: aws.StringSlice([]string{"a", "b"})},
+		"List":  {L: []*db.AttributeValue{{N: aws.String("1")}, {N: aws.String("2")}, {N: aws.String("3")}}},
+		"Old":   {S: aws.String("old")},
+	}
+
+	values := map[string]*db.AttributeValue{
+		":one":  {N: aws.String("1")},
+		":tags": {SS: aws.StringSlice([]string{"b", "c"})},
+		":del":  {SS: aws.StringSlice([]string{"a"})},
+		":list": {L: []*db.AttributeValue{{N: aws.String("4")}}},
+		":new":  {S: aws.String("new")},
+	}
+
+	p := newParser(nil, values)
+	actions, err := p.parseUpdate(
+		"SET Count = Count + :one, List = list_append(List, :list), " +
+			"Created = if_not_exists(Created, :new) " +
+			"REMOVE Old " +
+			"ADD Tags :tags " +
+			"DELETE Extra :del",
+	)
+	require.Nil(t, err)
+
+	updated := copyItem(it)
+	require.Nil(t, applyActions(actions, it, updated))
+	assert.Equal(t, "2", *updated["Count"].N)
+	assert.Len(t, updated["List"].L, 4)
+	assert.Equal(t, "new", *updated["Created"].S)
+	assert.NotContains(t, updated, "Old")
+	assert.ElementsMatch(t, []string{"a", "b", "c"}, aws.StringValueSlice(updated["Tags"].SS))
+
+	// The original item is unchanged
+	assert.Equal(t, "1", *it["Count"].N)
+	assert.Len(t, it["Tags"].SS, 2)
+
+	// Remove list elements and delete set elements
+	p = newParser(nil, values)
+	actions, err = p.parseUpdate("REMOVE List[0], List[2] DELETE Tags :del")
+	require.Nil(t, err)
+
+	updated = copyItem(it)
+	require.Nil(t, applyActions(actions, it, updated))
+	assert.Equal(t, []*db.AttributeValue{{N: aws.String("2")}}, updated["List"].L)
+	assert.Equal(t, []string{"b"}, aws.StringValueSlice(updated["Tags"].SS))
+
+	// Overlapping paths
+	p = newParser(nil, values)
+	_, err = p.parseUpdate("SET List[0] = :one REMOVE List")
+	assert.NotNil(t, err)
+
+	// Missing operand
+	p = newParser(nil, values)
+	actions, err = p.parseUpdate("SET Count = Missing + :one")
+	require.Nil(t, err)
+	assert.NotNil(t, applyActions(actions, it, copyItem(it)))
+}
+
+func TestProject(t *testing.T) {
+	it := item{
+		"A": {S: aws.String("a")},
+		"B": {M: map[string]*db.AttributeValue{
+			"C": {S: aws.String("c")},
+			"D": {S: aws.String("d")},
+		}},
+		"E": {L: []*db.AttributeValue{{S: aws.String("e0")}, {S: aws.String("e1")}}},
+	}
+
+	p := newParser(nil, nil)
+	paths, err := p.parseProjection("A, B.C, E[1], Missing")
+	require.Nil(t, err)
+
+	expected := item{
+		"A": {S: aws.String("a")},
+		"B": {M: map[string]*db.AttributeValue{"C": {S: aws.String("c")}}},
+		"E": {L: []*db.AttributeValue{{S: aws.String("e1")}}},
+	}
+	assert.Equal(t, expected, project(it, paths))
+}
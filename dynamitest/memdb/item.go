@@ -0,0 +1,543 @@
+package memdb
+
+import (
+	"sort"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	db "github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// write is a checked but not yet applied change to an
+// item. newItem is nil if the item is to be deleted.
+type write struct {
+	t       *table
+	key     string
+	oldItem item
+	newItem item
+}
+
+// apply stores the result of a write and adds its stream
+// record. This must be called with d.mu held.
+func (d *DB) apply(w *write) {
+	if w.newItem == nil {
+		delete(w.t.items, w.key)
+	} else {
+		w.t.items[w.key] = w.newItem
+	}
+
+	d.record(w.t, w.oldItem, w.newItem)
+}
+
+// checkItem validates the values of an item and its size.
+func (t *table) checkItem(it item) error {
+	for name, v := range it {
+		if name == "" {
+			return validationErr("One or more parameter values were invalid: An AttributeName cannot be empty")
+		} else if err := validateValue(v); err != nil {
+			return validationErr("One or more parameter values were invalid: %v", err)
+		}
+	}
+
+	if itemSize(it) > maxItemSize {
+		return validationErr("Item size has exceeded the maximum allowed size")
+	}
+
+	return t.checkIndexKeys(it)
+}
+
+// checkCondition evaluates a condition expression against
+// the current item, if any. The condition is true if there
+// is no expression.
+func checkCondition(p *parser, expr *string, current item) error {
+	if expr == nil {
+		return nil
+	}
+
+	cond, err := p.parseCondition(*expr)
+	if err != nil {
+		return validationErr("Invalid ConditionExpression: %v", err)
+	}
+
+	if current == nil {
+		current = item{}
+	}
+
+	ok, err := cond.eval(current)
+	if err != nil {
+		return validationErr("%v", err)
+	} else if !ok {
+		return conditionFailedErr()
+	}
+
+	return nil
+}
+
+// checkUnused reports placeholders that
+// aren't used in any of the expressions.
+func checkUnused(p *parser) error {
+	if err := p.checkUnused(); err != nil {
+		return validationErr("%v", err)
+	}
+	return nil
+}
+
+func (d *DB) preparePut(
+	t *table,
+	it item,
+	condExpr *string,
+	names map[string]*string,
+	values map[string]*db.AttributeValue) (*write, error) {
+
+	if err := t.checkKey(it, false); err != nil {
+		return nil, err
+	} else if err := t.checkItem(it); err != nil {
+		return nil, err
+	}
+
+	key := t.encodeKey(it)
+	current := t.items[key]
+
+	p := newParser(names, values)
+	if err := checkCondition(p, condExpr, current); err != nil {
+		return nil, err
+	} else if err := checkUnused(p); err != nil {
+		return nil, err
+	}
+
+	return &write{t, key, current, copyItem(it)}, nil
+}
+
+func (d *DB) prepareDelete(
+	t *table,
+	keyItem item,
+	condExpr *string,
+	names map[string]*string,
+	values map[string]*db.AttributeValue) (*write, error) {
+
+	if err := t.checkKey(keyItem, true); err != nil {
+		return nil, err
+	}
+
+	key := t.encodeKey(keyItem)
+	current := t.items[key]
+
+	p := newParser(names, values)
+	if err := checkCondition(p, condExpr, current); err != nil {
+		return nil, err
+	} else if err := checkUnused(p); err != nil {
+		return nil, err
+	}
+
+	return &write{t, key, current, nil}, nil
+}
+
+func (d *DB) prepareUpdate(
+	t *table,
+	keyItem item,
+	updateExpr *string,
+	condExpr *string,
+	names map[string]*string,
+	values map[string]*db.AttributeValue) (*write, []updateAction, error) {
+
+	if err := t.checkKey(keyItem, true); err != nil {
+		return nil, nil, err
+	}
+
+	key := t.encodeKey(keyItem)
+	current := t.items[key]
+
+	p := newParser(names, values)
+	var actions []updateAction
+	if updateExpr != nil {
+		var err error
+		actions, err = p.parseUpdate(*updateExpr)
+		if err != nil {
+			return nil, nil, validationErr("Invalid UpdateExpression: %v", err)
+		}
+	}
+
+	if err := checkCondition(p, condExpr, current); err != nil {
+		return nil, nil, err
+	} else if err := checkUnused(p); err != nil {
+		return nil, nil, err
+	}
+
+	for _, a := range actions {
+		for _, name := range t.keyNames() {
+			if a.path[0].name == name {
+				return nil, nil, validationErr("One or more parameter values were invalid: Cannot update attribute %v. This attribute is part of the key", name)
+			}
+		}
+	}
+
+	newItem := copyItem(current)
+	if newItem == nil {
+		newItem = copyItem(keyItem)
+	}
+	if err := applyActions(actions, current, newItem); err != nil {
+		return nil, nil, validationErr("%v", err)
+	} else if err := t.checkItem(newItem); err != nil {
+		return nil, nil, err
+	}
+
+	return &write{t, key, current, newItem}, actions, nil
+}
+
+// applyActions applies the actions of an update expression to
+// newItem. Operands are evaluated against the unmodified item.
+func applyActions(actions []updateAction, current, newItem item) error {
+	if current == nil {
+		current = item{}
+	}
+
+	// Evaluate operands first so that
+	// actions don't affect each other.
+	values := make([]*db.AttributeValue, len(actions))
+	for i, a := range actions {
+		if a.value == nil {
+			continue
+		}
+
+		v, err := a.value.eval(current)
+		if err != nil {
+			return err
+		} else if v == nil {
+			return errMissingAttr
+		}
+		values[i] = copyValue(v)
+	}
+
+	var removes []path
+	for i, a := range actions {
+		v := values[i]
+		switch a.kind {
+		case "SET":
+			if err := setPath(newItem, a.path, v); err != nil {
+				return err
+			}
+
+		case "REMOVE":
+			removes = append(removes, a.path)
+
+		case "ADD":
+			old := getPath(newItem, a.path)
+			if old == nil {
+				if v.N == nil && v.SS == nil && v.NS == nil && v.BS == nil {
+					return errIncorrectOperand("ADD", v)
+				}
+				if err := setPath(newItem, a.path, v); err != nil {
+					return err
+				}
+				continue
+			}
+
+			sum, err := add(old, v)
+			if err != nil {
+				return err
+			}
+			*old = *sum
+
+		case "DELETE":
+			if v.SS == nil && v.NS == nil && v.BS == nil {
+				return errIncorrectOperand("DELETE", v)
+			}
+
+			old := getPath(newItem, a.path)
+			if old == nil {
+				continue
+			}
+
+			diff, err := subtract(old, v)
+			if err != nil {
+				return err
+			} else if diff == nil {
+				removePath(newItem, a.path)
+			} else {
+				*old = *diff
+			}
+		}
+	}
+
+	// Remove list elements from the
+	// highest index to the lowest.
+	sort.SliceStable(removes, func(i, j int) bool {
+		a, b := removes[i], removes[j]
+		la, lb := a[len(a)-1], b[len(b)-1]
+		return la.isIndex && lb.isIndex && la.index > lb.index
+	})
+	for _, pth := range removes {
+		removePath(newItem, pth)
+	}
+
+	return nil
+}
+
+// updated returns the top level attributes
+// of an item that are modified by actions.
+func updated(it item, actions []updateAction) item {
+	attrs := item{}
+	for _, a := range actions {
+		name := a.path[0].name
+		if v, ok := it[name]; ok {
+			attrs[name] = v
+		}
+	}
+
+	return attrs
+}
+
+// PutItem creates or replaces an item.
+func (d *DB) PutItem(input *db.PutItemInput) (*db.PutItemOutput, error) {
+	return d.PutItemWithContext(aws.BackgroundContext(), input)
+}
+
+// PutItemWithContext is the same as PutItem
+// with the addition of a request context.
+func (d *DB) PutItemWithContext(
+	ctx aws.Context,
+	input *db.PutItemInput,
+	opts ...request.Option) (*db.PutItemOutput, error) {
+
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	} else if err := input.Validate(); err != nil {
+		return nil, err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	t, err := d.table(input.TableName)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := aws.StringValue(input.ReturnValues)
+	if ret != "" && ret != db.ReturnValueNone && ret != db.ReturnValueAllOld {
+		return nil, validationErr("Return values set to invalid value")
+	}
+
+	w, err := d.preparePut(
+		t,
+		input.Item,
+		input.ConditionExpression,
+		input.ExpressionAttributeNames,
+		input.ExpressionAttributeValues,
+	)
+	if err != nil {
+		return nil, err
+	}
+	d.apply(w)
+
+	c := newConsumed(t)
+	c.write(w, false)
+
+	output := &db.PutItemOutput{ConsumedCapacity: c.output(input.ReturnConsumedCapacity)}
+	if ret == db.ReturnValueAllOld {
+		output.Attributes = copyItem(w.oldItem)
+	}
+	return output, nil
+}
+
+// GetItem returns the item with the given primary key.
+// The returned item is nil if it doesn't exist.
+func (d *DB) GetItem(input *db.GetItemInput) (*db.GetItemOutput, error) {
+	return d.GetItemWithContext(aws.BackgroundContext(), input)
+}
+
+// GetItemWithContext is the same as GetItem
+// with the addition of a request context.
+func (d *DB) GetItemWithContext(
+	ctx aws.Context,
+	input *db.GetItemInput,
+	opts ...request.Option) (*db.GetItemOutput, error) {
+
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	} else if err := input.Validate(); err != nil {
+		return nil, err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	t, err := d.table(input.TableName)
+	if err != nil {
+		return nil, err
+	}
+
+	it, err := d.getItem(
+		t,
+		input.Key,
+		input.ProjectionExpression,
+		input.AttributesToGet,
+		input.ExpressionAttributeNames,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	c := newConsumed(t)
+	stored := t.items[t.encodeKey(input.Key)]
+	c.add(nil, readUnits(itemSize(stored), aws.BoolValue(input.ConsistentRead)))
+
+	return &db.GetItemOutput{
+		Item:             it,
+		ConsumedCapacity: c.output(input.ReturnConsumedCapacity),
+	}, nil
+}
+
+// getItem returns a copy of an item with only the attributes
+// in the projection expression, if given. The returned item
+// is nil if it doesn't exist.
+func (d *DB) getItem(
+	t *table,
+	keyItem item,
+	projExpr *string,
+	attrsToGet []*string,
+	names map[string]*string) (item, error) {
+
+	if err := t.checkKey(keyItem, true); err != nil {
+		return nil, err
+	} else if projExpr != nil && len(attrsToGet) > 0 {
+		return nil, validationErr("Can not use both expression and non-expression parameters in the same request")
+	}
+
+	p := newParser(names, nil)
+	var paths []path
+	if projExpr != nil {
+		var err error
+		paths, err = p.parseProjection(*projExpr)
+		if err != nil {
+			return nil, validationErr("Invalid ProjectionExpression: %v", err)
+		}
+	}
+	if err := checkUnused(p); err != nil {
+		return nil, err
+	} else if len(attrsToGet) > 0 {
+		paths = attrPaths(attrsToGet)
+	}
+
+	it := t.items[t.encodeKey(keyItem)]
+	if it == nil {
+		return nil, nil
+	}
+
+	return project(it, paths), nil
+}
+
+// DeleteItem deletes an item. Deleting an
+// item that doesn't exist is not an error.
+func (d *DB) DeleteItem(input *db.DeleteItemInput) (*db.DeleteItemOutput, error) {
+	return d.DeleteItemWithContext(aws.BackgroundContext(), input)
+}
+
+// DeleteItemWithContext is the same as DeleteItem
+// with the addition of a request context.
+func (d *DB) DeleteItemWithContext(
+	ctx aws.Context,
+	input *db.DeleteItemInput,
+	opts ...request.Option) (*db.DeleteItemOutput, error) {
+
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	} else if err := input.Validate(); err != nil {
+		return nil, err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	t, err := d.table(input.TableName)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := aws.StringValue(input.ReturnValues)
+	if ret != "" && ret != db.ReturnValueNone && ret != db.ReturnValueAllOld {
+		return nil, validationErr("Return values set to invalid value")
+	}
+
+	w, err := d.prepareDelete(
+		t,
+		input.Key,
+		input.ConditionExpression,
+		input.ExpressionAttributeNames,
+		input.ExpressionAttributeValues,
+	)
+	if err != nil {
+		return nil, err
+	}
+	d.apply(w)
+
+	c := newConsumed(t)
+	c.write(w, false)
+
+	output := &db.DeleteItemOutput{ConsumedCapacity: c.output(input.ReturnConsumedCapacity)}
+	if ret == db.ReturnValueAllOld {
+		output.Attributes = copyItem(w.oldItem)
+	}
+	return output, nil
+}
+
+// UpdateItem modifies the attributes of an item. The
+// item is created if it doesn't exist.
+func (d *DB) UpdateItem(input *db.UpdateItemInput) (*db.UpdateItemOutput, error) {
+	return d.UpdateItemWithContext(aws.BackgroundContext(), input)
+}
+
+// UpdateItemWithContext is the same as UpdateItem
+// with the addition of a request context.
+func (d *DB) UpdateItemWithContext(
+	ctx aws.Context,
+	input *db.UpdateItemInput,
+	opts ...request.Option) (*db.UpdateItemOutput, error) {
+
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	} else if err := input.Validate(); err != nil {
+		return nil, err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	t, err := d.table(input.TableName)
+	if err != nil {
+		return nil, err
+	}
+
+	w, actions, err := d.prepareUpdate(
+		t,
+		input.Key,
+		input.UpdateExpression,
+		input.ConditionExpression,
+		input.ExpressionAttributeNames,
+		input.ExpressionAttributeValues,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	c := newConsumed(t)
+	c.write(w, false)
+
+	output := &db.UpdateItemOutput{ConsumedCapacity: c.output(input.ReturnConsumedCapacity)}
+	switch aws.StringValue(input.ReturnValues) {
+	case "", db.ReturnValueNone:
+	case db.ReturnValueAllOld:
+		output.Attributes = copyItem(w.oldItem)
+	case db.ReturnValueUpdatedOld:
+		output.Attributes = copyItem(updated(w.oldItem, actions))
+	case db.ReturnValueAllNew:
+		output.Attributes = copyItem(w.newItem)
+	case db.ReturnValueUpdatedNew:
+		output.Attributes = copyItem(updated(w.newItem, actions))
+	default:
+		return nil, validationErr("Return values set to invalid value")
+	}
+
+	d.apply(w)
+	return output, nil
+}
@@ -0,0 +1,555 @@
+package memdb
+
+import (
+	"hash/fnv"
+	"sort"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	db "github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// view is a table or one of its secondary
+// indices which are read by queries and scans.
+type view struct {
+	t   *table
+	idx *index
+
+	hashKey  string
+	rangeKey string
+
+	// keyNames contains the index keys followed by the
+	// table keys. Items are sorted by these attributes.
+	keyNames []string
+}
+
+func (t *table) view(indexName *string, consistent bool) (*view, error) {
+	v := &view{t: t}
+	keySchema := t.keySchema
+	if indexName != nil {
+		idx, err := t.index(*indexName)
+		if err != nil {
+			return nil, err
+		} else if idx.global && consistent {
+			return nil, validationErr("Consistent reads are not supported on global secondary indexes")
+		}
+
+		v.idx = idx
+		keySchema = idx.keySchema
+	}
+
+	v.hashKey, v.rangeKey = keys(keySchema)
+	seen := map[string]bool{}
+	for _, name := range []string{v.hashKey, v.rangeKey, t.hashKey(), t.rangeKey()} {
+		if name != "" && !seen[name] {
+			v.keyNames = append(v.keyNames, name)
+			seen[name] = true
+		}
+	}
+
+	return v, nil
+}
+
+// cmp compares two items by their key attributes.
+func (v *view) cmp(a, b item) int {
+	for _, name := range v.keyNames {
+		if c, _ := compare(a[name], b[name]); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// items returns the items in the view sorted by their keys.
+// Items without the index key attributes are not included.
+func (v *view) items() []item {
+	var items []item
+	for _, it := range v.t.items {
+		if v.idx == nil || hasKeys(it, v.idx.keySchema) {
+			items = append(items, it)
+		}
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		return v.cmp(items[i], items[j]) < 0
+	})
+	return items
+}
+
+// key returns the key attributes of an item that
+// are used as the LastEvaluatedKey of a page.
+func (v *view) key(it item) item {
+	key := item{}
+	for _, name := range v.keyNames {
+		key[name] = copyValue(it[name])
+	}
+	return key
+}
+
+func (v *view) checkStartKey(key item) error {
+	if len(key) != len(v.keyNames) {
+		return validationErr("The provided starting key is invalid")
+	}
+
+	for _, name := range v.keyNames {
+		val, ok := key[name]
+		if !ok || attrType(val) != v.t.attrType(name) {
+			return validationErr("The provided starting key is invalid")
+		}
+	}
+
+	return nil
+}
+
+// projected returns the attributes of an item that are
+// projected to the index. All attributes are returned if
+// the view is a table or if all attributes are projected.
+func (v *view) projected(it item) item {
+	if v.idx == nil || *v.idx.projection.ProjectionType == db.ProjectionTypeAll {
+		return it
+	}
+
+	names := append([]string{}, v.keyNames...)
+	if *v.idx.projection.ProjectionType == db.ProjectionTypeInclude {
+		names = append(names, aws.StringValueSlice(v.idx.projection.NonKeyAttributes)...)
+	}
+
+	projected := item{}
+	for _, name := range names {
+		if val, ok := it[name]; ok {
+			projected[name] = val
+		}
+	}
+	return projected
+}
+
+// read contains the parameters shared by queries and scans.
+type read struct {
+	view       *view
+	consistent bool
+	keyCond    condition
+	filter     condition
+	paths      []path
+	sel        string
+	limit      int
+	forward    bool
+	startKey   item
+
+	// inSegment returns true if an item
+	// belongs to the scanned segment.
+	inSegment func(it item) bool
+}
+
+func (d *DB) newRead(
+	tableName *string,
+	indexName *string,
+	consistent *bool,
+	keyCondExpr *string,
+	filterExpr *string,
+	projExpr *string,
+	attrsToGet []*string,
+	sel *string,
+	names map[string]*string,
+	values map[string]*db.AttributeValue,
+	limit *int64,
+	startKey item) (*read, error) {
+
+	t, err := d.table(tableName)
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := t.view(indexName, aws.BoolValue(consistent))
+	if err != nil {
+		return nil, err
+	}
+
+	r := &read{
+		view:       v,
+		consistent: aws.BoolValue(consistent),
+		forward:    true,
+		startKey:   startKey,
+	}
+	p := newParser(names, values)
+	if keyCondExpr != nil {
+		r.keyCond, err = p.parseCondition(*keyCondExpr)
+		if err != nil {
+			return nil, validationErr("Invalid KeyConditionExpression: %v", err)
+		} else if err := checkKeyCond(r.keyCond, v.hashKey, v.rangeKey); err != nil {
+			return nil, err
+		}
+	}
+	if filterExpr != nil {
+		r.filter, err = p.parseCondition(*filterExpr)
+		if err != nil {
+			return nil, validationErr("Invalid FilterExpression: %v", err)
+		}
+	}
+	if projExpr != nil {
+		r.paths, err = p.parseProjection(*projExpr)
+		if err != nil {
+			return nil, validationErr("Invalid ProjectionExpression: %v", err)
+		}
+	}
+	if err := checkUnused(p); err != nil {
+		return nil, err
+	}
+
+	if len(attrsToGet) > 0 {
+		if projExpr != nil {
+			return nil, validationErr("Can not use both expression and non-expression parameters in the same request")
+		}
+		r.paths = attrPaths(attrsToGet)
+	}
+
+	hasProj := len(r.paths) > 0
+	r.sel = aws.StringValue(sel)
+	switch {
+	case r.sel == "" && hasProj:
+		r.sel = db.SelectSpecificAttributes
+	case r.sel == "" && v.idx != nil:
+		r.sel = db.SelectAllProjectedAttributes
+	case r.sel == "":
+		r.sel = db.SelectAllAttributes
+	case r.sel == db.SelectSpecificAttributes && !hasProj:
+		return nil, validationErr("SPECIFIC_ATTRIBUTES requires a ProjectionExpression")
+	case r.sel != db.SelectSpecificAttributes && hasProj:
+		return nil, validationErr("Cannot specify the ProjectionExpression when choosing to get %v", r.sel)
+	case r.sel == db.SelectAllProjectedAttributes && v.idx == nil:
+		return nil, validationErr("ALL_PROJECTED_ATTRIBUTES can be used only when Querying using an IndexName")
+	case r.sel == db.SelectAllAttributes && v.idx != nil && v.idx.global &&
+		*v.idx.projection.ProjectionType != db.ProjectionTypeAll:
+
+		return nil, validationErr("One or more parameter values were invalid: Select type ALL_ATTRIBUTES is not supported for global secondary index %v because its projection type is not ALL", v.idx.name)
+	}
+
+	if limit != nil {
+		if *limit <= 0 {
+			return nil, validationErr("Limit must be greater than or equal to 1")
+		}
+		r.limit = int(*limit)
+	}
+
+	if startKey != nil {
+		if err := v.checkStartKey(startKey); err != nil {
+			return nil, err
+		}
+	}
+
+	return r, nil
+}
+
+// attrPaths converts the attribute names
+// of AttributesToGet to projection paths.
+func attrPaths(names []*string) []path {
+	paths := make([]path, len(names))
+	for i, name := range names {
+		paths[i] = path{{name: aws.StringValue(name)}}
+	}
+	return paths
+}
+
+// checkKeyCond checks that a key condition has an equality
+// condition on the hash key and an optional condition on the
+// range key that are joined by AND.
+func checkKeyCond(cond condition, hashKey, rangeKey string) error {
+	var conds []condition
+	var flatten func(c condition)
+	flatten = func(c condition) {
+		if and, ok := c.(andCond); ok {
+			flatten(and.a)
+			flatten(and.b)
+		} else {
+			conds = append(conds, c)
+		}
+	}
+	flatten(cond)
+
+	if len(conds) > 2 {
+		return validationErr("Conditions can be of length 1 or 2 only")
+	}
+
+	isKey := func(op operand, name string) bool {
+		p, ok := op.(pathOperand)
+		return ok && len(p.path) == 1 && p.path[0].name == name
+	}
+	isValue := func(op operand) bool {
+		_, ok := op.(valueOperand)
+		return ok
+	}
+
+	hasHash := false
+	for _, c := range conds {
+		switch c := c.(type) {
+		case compareCond:
+			if c.op == "=" && isKey(c.a, hashKey) && isValue(c.b) && !hasHash {
+				hasHash = true
+				continue
+			} else if c.op != "<>" && rangeKey != "" && isKey(c.a, rangeKey) && isValue(c.b) {
+				continue
+			}
+		case betweenCond:
+			if rangeKey != "" && isKey(c.a, rangeKey) && isValue(c.lo) && isValue(c.hi) {
+				continue
+			}
+		case funcCond:
+			if c.name == "begins_with" && rangeKey != "" &&
+				len(c.path) == 1 && c.path[0].name == rangeKey && isValue(c.arg) {
+
+				continue
+			}
+		}
+
+		return validationErr("Query key condition not supported")
+	}
+
+	if !hasHash {
+		return validationErr("Query condition missed key schema element: %v", hashKey)
+	}
+
+	return nil
+}
+
+type page struct {
+	items        []map[string]*db.AttributeValue
+	count        int
+	scannedCount int
+	size         int
+	lastKey      item
+}
+
+// run reads a page of items.
+func (r *read) run() (*page, error) {
+	v := r.view
+	items := v.items()
+	if !r.forward {
+		for i, j := 0, len(items)-1; i < j; i, j = i+1, j-1 {
+			items[i], items[j] = items[j], items[i]
+		}
+	}
+
+	pg := &page{items: []map[string]*db.AttributeValue{}}
+	size := 0
+	for i, it := range items {
+		if r.startKey != nil {
+			c := v.cmp(it, r.startKey)
+			if (r.forward && c <= 0) || (!r.forward && c >= 0) {
+				continue
+			}
+		}
+
+		if r.keyCond != nil {
+			ok, err := r.keyCond.eval(it)
+			if err != nil {
+				return nil, validationErr("%v", err)
+			} else if !ok {
+				continue
+			}
+		}
+
+		if r.inSegment != nil && !r.inSegment(it) {
+			continue
+		}
+
+		pg.scannedCount++
+		size += itemSize(it)
+		pg.size = size
+
+		ok := true
+		if r.filter != nil {
+			var err error
+			ok, err = r.filter.eval(it)
+			if err != nil {
+				return nil, validationErr("%v", err)
+			}
+		}
+
+		if ok {
+			pg.count++
+			if r.sel != db.SelectCount {
+				pg.items = append(pg.items, r.project(it))
+			}
+		}
+
+		if (r.limit > 0 && pg.scannedCount >= r.limit) || size >= maxPageSize {
+			if r.hasMore(items[i+1:]) {
+				pg.lastKey = v.key(it)
+			}
+			break
+		}
+	}
+
+	return pg, nil
+}
+
+// consumed returns the capacity consumed by reading a page.
+func (r *read) consumed(pg *page) *consumed {
+	c := newConsumed(r.view.t)
+	c.add(r.view.idx, readUnits(pg.size, r.consistent))
+	return c
+}
+
+// hasMore returns true if some of the given
+// items can be returned in the next page.
+func (r *read) hasMore(items []item) bool {
+	for _, it := range items {
+		if r.keyCond != nil {
+			if ok, _ := r.keyCond.eval(it); !ok {
+				continue
+			}
+		}
+		if r.inSegment == nil || r.inSegment(it) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *read) project(it item) item {
+	v := r.view
+	switch {
+	case r.sel == db.SelectAllAttributes:
+		return copyItem(it)
+	case r.sel == db.SelectSpecificAttributes && v.idx != nil && !v.idx.global:
+		// Local secondary indices fetch the
+		// attributes that are not projected.
+		return project(it, r.paths)
+	default:
+		return project(v.projected(it), r.paths)
+	}
+}
+
+// Query returns the items with the given hash key
+// and optionally, those that satisfy a range key
+// condition.
+func (d *DB) Query(input *db.QueryInput) (*db.QueryOutput, error) {
+	return d.QueryWithContext(aws.BackgroundContext(), input)
+}
+
+// QueryWithContext is the same as Query
+// with the addition of a request context.
+func (d *DB) QueryWithContext(
+	ctx aws.Context,
+	input *db.QueryInput,
+	opts ...request.Option) (*db.QueryOutput, error) {
+
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	} else if err := input.Validate(); err != nil {
+		return nil, err
+	} else if input.KeyConditionExpression == nil {
+		return nil, validationErr("Either the KeyConditions or KeyConditionExpression parameter must be specified in the request")
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	r, err := d.newRead(
+		input.TableName,
+		input.IndexName,
+		input.ConsistentRead,
+		input.KeyConditionExpression,
+		input.FilterExpression,
+		input.ProjectionExpression,
+		input.AttributesToGet,
+		input.Select,
+		input.ExpressionAttributeNames,
+		input.ExpressionAttributeValues,
+		input.Limit,
+		input.ExclusiveStartKey,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if input.ScanIndexForward != nil {
+		r.forward = *input.ScanIndexForward
+	}
+
+	pg, err := r.run()
+	if err != nil {
+		return nil, err
+	}
+
+	output := &db.QueryOutput{
+		Count:            aws.Int64(int64(pg.count)),
+		ScannedCount:     aws.Int64(int64(pg.scannedCount)),
+		LastEvaluatedKey: pg.lastKey,
+	}
+	output.ConsumedCapacity = r.consumed(pg).output(input.ReturnConsumedCapacity)
+	if r.sel != db.SelectCount {
+		output.Items = pg.items
+	}
+	return output, nil
+}
+
+// Scan returns all items in a table or index. Scans
+// can be divided into segments using TotalSegments.
+func (d *DB) Scan(input *db.ScanInput) (*db.ScanOutput, error) {
+	return d.ScanWithContext(aws.BackgroundContext(), input)
+}
+
+// ScanWithContext is the same as Scan
+// with the addition of a request context.
+func (d *DB) ScanWithContext(
+	ctx aws.Context,
+	input *db.ScanInput,
+	opts ...request.Option) (*db.ScanOutput, error) {
+
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	} else if err := input.Validate(); err != nil {
+		return nil, err
+	} else if (input.Segment == nil) != (input.TotalSegments == nil) {
+		return nil, validationErr("The TotalSegments parameter is required but was not present in the request when parameter Segment is present")
+	} else if input.Segment != nil && *input.Segment >= *input.TotalSegments {
+		return nil, validationErr("The Segment parameter is zero-based and must be less than parameter TotalSegments")
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	r, err := d.newRead(
+		input.TableName,
+		input.IndexName,
+		input.ConsistentRead,
+		nil,
+		input.FilterExpression,
+		input.ProjectionExpression,
+		input.AttributesToGet,
+		input.Select,
+		input.ExpressionAttributeNames,
+		input.ExpressionAttributeValues,
+		input.Limit,
+		input.ExclusiveStartKey,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if input.Segment != nil {
+		hashKey := r.view.hashKey
+		segment := uint32(*input.Segment)
+		total := uint32(*input.TotalSegments)
+		r.inSegment = func(it item) bool {
+			h := fnv.New32a()
+			h.Write([]byte(encodeValues(it, []string{hashKey})))
+			return h.Sum32()%total == segment
+		}
+	}
+
+	pg, err := r.run()
+	if err != nil {
+		return nil, err
+	}
+
+	output := &db.ScanOutput{
+		Count:            aws.Int64(int64(pg.count)),
+		ScannedCount:     aws.Int64(int64(pg.scannedCount)),
+		LastEvaluatedKey: pg.lastKey,
+	}
+	output.ConsumedCapacity = r.consumed(pg).output(input.ReturnConsumedCapacity)
+	if r.sel != db.SelectCount {
+		output.Items = pg.items
+	}
+	return output, nil
+}
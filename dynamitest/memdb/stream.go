@@ -0,0 +1,419 @@
+package memdb
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	db "github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodbstreams"
+	"github.com/aws/aws-sdk-go/service/dynamodbstreams/dynamodbstreamsiface"
+)
+
+const maxGetRecords = 1000
+
+// stream is a table stream. Each stream has a single shard
+// which is closed once the stream is disabled.
+type stream struct {
+	arn       string
+	label     string
+	table     string
+	viewType  string
+	keySchema []*db.KeySchemaElement
+	created   time.Time
+
+	shardID  string
+	startSeq string
+	endSeq   *string
+	records  []*dynamodbstreams.Record
+}
+
+func (s *stream) disabled() bool {
+	return s.endSeq != nil
+}
+
+// setStream enables or disables the stream of a table.
+// This must be called with d.mu held.
+func (d *DB) setStream(t *table, spec *db.StreamSpecification) error {
+	if spec == nil {
+		return nil
+	}
+
+	if !aws.BoolValue(spec.StreamEnabled) {
+		if spec.StreamViewType != nil {
+			return validationErr("One or more parameter values were invalid: StreamViewType cannot be specified when StreamEnabled is false")
+		}
+
+		t.streamSpec = nil
+		if t.stream != nil {
+			d.closeStream(t.stream)
+		}
+		return nil
+	}
+
+	switch aws.StringValue(spec.StreamViewType) {
+	case db.StreamViewTypeKeysOnly,
+		db.StreamViewTypeNewImage,
+		db.StreamViewTypeOldImage,
+		db.StreamViewTypeNewAndOldImages:
+	default:
+		return validationErr("One or more parameter values were invalid: Invalid StreamViewType: %v", aws.StringValue(spec.StreamViewType))
+	}
+
+	created := time.Now()
+	label := created.UTC().Format("2006-01-02T15:04:05.000")
+	s := &stream{
+		arn:       fmt.Sprintf("%v/stream/%v", t.arn, label),
+		label:     label,
+		table:     t.name,
+		viewType:  *spec.StreamViewType,
+		keySchema: t.keySchema,
+		created:   created,
+		shardID:   fmt.Sprintf("shardId-%020d", created.UnixNano()),
+		startSeq:  d.nextSeqNum(),
+	}
+
+	// Stream ARNs must be unique even if
+	// created within the same millisecond.
+	for d.streams[s.arn] != nil {
+		s.arn += "0"
+	}
+
+	d.streams[s.arn] = s
+	t.streamSpec = spec
+	t.stream = s
+	return nil
+}
+
+func (d *DB) closeStream(s *stream) {
+	if !s.disabled() {
+		s.endSeq = aws.String(d.nextSeqNum())
+	}
+}
+
+// record adds a stream record for a write to an item. oldItem
+// and newItem are nil if the item is inserted or removed. This
+// must be called with d.mu held.
+func (d *DB) record(t *table, oldItem, newItem item) {
+	s := t.stream
+	if s == nil || s.disabled() || (oldItem == nil && newItem == nil) {
+		return
+	}
+
+	name := dynamodbstreams.OperationTypeModify
+	key := t.key(newItem)
+	if oldItem == nil {
+		name = dynamodbstreams.OperationTypeInsert
+	} else if newItem == nil {
+		name = dynamodbstreams.OperationTypeRemove
+		key = t.key(oldItem)
+	}
+
+	seq := d.nextSeqNum()
+	rec := &dynamodbstreams.StreamRecord{
+		ApproximateCreationDateTime: aws.Time(now()),
+		Keys:                        copyItem(key),
+		SequenceNumber:              aws.String(seq),
+		SizeBytes:                   aws.Int64(int64(itemSize(oldItem) + itemSize(newItem))),
+		StreamViewType:              aws.String(s.viewType),
+	}
+
+	switch s.viewType {
+	case db.StreamViewTypeNewImage:
+		rec.NewImage = copyItem(newItem)
+	case db.StreamViewTypeOldImage:
+		rec.OldImage = copyItem(oldItem)
+	case db.StreamViewTypeNewAndOldImages:
+		rec.NewImage = copyItem(newItem)
+		rec.OldImage = copyItem(oldItem)
+	}
+
+	s.records = append(s.records, &dynamodbstreams.Record{
+		AwsRegion:    aws.String(region),
+		EventID:      aws.String(seq),
+		EventName:    aws.String(name),
+		EventSource:  aws.String("aws:dynamodb"),
+		EventVersion: aws.String("1.1"),
+		Dynamodb:     rec,
+	})
+}
+
+// Streams returns the DynamoDB Streams API of the database.
+func (d *DB) Streams() *Streams {
+	return &Streams{d: d}
+}
+
+// Streams is an in-memory DynamoDB Streams API. The
+// streams are those of the tables of its database.
+type Streams struct {
+	// DynamoDBStreamsAPI is nil so that
+	// unsupported operations panic.
+	dynamodbstreamsiface.DynamoDBStreamsAPI
+
+	d *DB
+}
+
+func (s *Streams) stream(arn *string) (*stream, error) {
+	st, ok := s.d.streams[aws.StringValue(arn)]
+	if !ok {
+		return nil, &dynamodbstreams.ResourceNotFoundException{
+			Message_: aws.String(fmt.Sprintf("Requested resource not found: Stream: %v not found", aws.StringValue(arn))),
+		}
+	}
+	return st, nil
+}
+
+// ListStreams returns the streams of all tables or of a
+// single table if a table name is given.
+func (s *Streams) ListStreams(
+	input *dynamodbstreams.ListStreamsInput) (*dynamodbstreams.ListStreamsOutput, error) {
+
+	return s.ListStreamsWithContext(aws.BackgroundContext(), input)
+}
+
+// ListStreamsWithContext is the same as ListStreams
+// with the addition of a request context.
+func (s *Streams) ListStreamsWithContext(
+	ctx aws.Context,
+	input *dynamodbstreams.ListStreamsInput,
+	opts ...request.Option) (*dynamodbstreams.ListStreamsOutput, error) {
+
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	var streams []*stream
+	for _, st := range s.d.streams {
+		if input.TableName == nil || *input.TableName == st.table {
+			streams = append(streams, st)
+		}
+	}
+	sort.Slice(streams, func(i, j int) bool {
+		return streams[i].arn < streams[j].arn
+	})
+
+	output := &dynamodbstreams.ListStreamsOutput{
+		Streams: []*dynamodbstreams.Stream{},
+	}
+	for _, st := range streams {
+		output.Streams = append(output.Streams, &dynamodbstreams.Stream{
+			StreamArn:   aws.String(st.arn),
+			StreamLabel: aws.String(st.label),
+			TableName:   aws.String(st.table),
+		})
+	}
+
+	return output, nil
+}
+
+// DescribeStream returns information about a
+// stream including its only shard.
+func (s *Streams) DescribeStream(
+	input *dynamodbstreams.DescribeStreamInput) (*dynamodbstreams.DescribeStreamOutput, error) {
+
+	return s.DescribeStreamWithContext(aws.BackgroundContext(), input)
+}
+
+// DescribeStreamWithContext is the same as DescribeStream
+// with the addition of a request context.
+func (s *Streams) DescribeStreamWithContext(
+	ctx aws.Context,
+	input *dynamodbstreams.DescribeStreamInput,
+	opts ...request.Option) (*dynamodbstreams.DescribeStreamOutput, error) {
+
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	st, err := s.stream(input.StreamArn)
+	if err != nil {
+		return nil, err
+	}
+
+	status := dynamodbstreams.StreamStatusEnabled
+	if st.disabled() {
+		status = dynamodbstreams.StreamStatusDisabled
+	}
+
+	desc := &dynamodbstreams.StreamDescription{
+		StreamArn:               aws.String(st.arn),
+		StreamLabel:             aws.String(st.label),
+		StreamStatus:            aws.String(status),
+		StreamViewType:          aws.String(st.viewType),
+		TableName:               aws.String(st.table),
+		KeySchema:               st.keySchema,
+		CreationRequestDateTime: aws.Time(st.created),
+		Shards:                  []*dynamodbstreams.Shard{},
+	}
+
+	if input.ExclusiveStartShardId == nil || *input.ExclusiveStartShardId < st.shardID {
+		desc.Shards = append(desc.Shards, &dynamodbstreams.Shard{
+			ShardId: aws.String(st.shardID),
+			SequenceNumberRange: &dynamodbstreams.SequenceNumberRange{
+				StartingSequenceNumber: aws.String(st.startSeq),
+				EndingSequenceNumber:   st.endSeq,
+			},
+		})
+	}
+
+	return &dynamodbstreams.DescribeStreamOutput{StreamDescription: desc}, nil
+}
+
+// shardIterator is the decoded form of a shard iterator.
+// pos is the index of the next record to return.
+type shardIterator struct {
+	Arn   string `json:"a"`
+	Shard string `json:"s"`
+	Pos   int    `json:"p"`
+}
+
+func (it shardIterator) encode() *string {
+	b, _ := json.Marshal(it)
+	return aws.String(base64.RawURLEncoding.EncodeToString(b))
+}
+
+func decodeShardIterator(s *string) (shardIterator, error) {
+	var it shardIterator
+	b, err := base64.RawURLEncoding.DecodeString(aws.StringValue(s))
+	if err == nil {
+		err = json.Unmarshal(b, &it)
+	}
+	if err != nil {
+		return it, validationErr("Invalid ShardIterator")
+	}
+
+	return it, nil
+}
+
+// GetShardIterator returns an iterator that points
+// to a position in a shard for use with GetRecords.
+func (s *Streams) GetShardIterator(
+	input *dynamodbstreams.GetShardIteratorInput) (*dynamodbstreams.GetShardIteratorOutput, error) {
+
+	return s.GetShardIteratorWithContext(aws.BackgroundContext(), input)
+}
+
+// GetShardIteratorWithContext is the same as
+// GetShardIterator with the addition of a context.
+func (s *Streams) GetShardIteratorWithContext(
+	ctx aws.Context,
+	input *dynamodbstreams.GetShardIteratorInput,
+	opts ...request.Option) (*dynamodbstreams.GetShardIteratorOutput, error) {
+
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	} else if err := input.Validate(); err != nil {
+		return nil, err
+	}
+
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	st, err := s.stream(input.StreamArn)
+	if err != nil {
+		return nil, err
+	} else if *input.ShardId != st.shardID {
+		return nil, &dynamodbstreams.ResourceNotFoundException{
+			Message_: aws.String(fmt.Sprintf("Requested resource not found: Shard does not exist: %v", *input.ShardId)),
+		}
+	}
+
+	it := shardIterator{Arn: st.arn, Shard: st.shardID}
+	switch *input.ShardIteratorType {
+	case dynamodbstreams.ShardIteratorTypeTrimHorizon:
+	case dynamodbstreams.ShardIteratorTypeLatest:
+		it.Pos = len(st.records)
+
+	case dynamodbstreams.ShardIteratorTypeAtSequenceNumber,
+		dynamodbstreams.ShardIteratorTypeAfterSequenceNumber:
+
+		seq := aws.StringValue(input.SequenceNumber)
+		if seq < st.startSeq || (st.endSeq != nil && seq > *st.endSeq) {
+			return nil, validationErr("Invalid SequenceNumber for ShardIteratorType: %v", *input.ShardIteratorType)
+		}
+
+		it.Pos = sort.Search(len(st.records), func(i int) bool {
+			return *st.records[i].Dynamodb.SequenceNumber >= seq
+		})
+		if *input.ShardIteratorType == dynamodbstreams.ShardIteratorTypeAfterSequenceNumber &&
+			it.Pos < len(st.records) &&
+			*st.records[it.Pos].Dynamodb.SequenceNumber == seq {
+
+			it.Pos++
+		}
+
+	default:
+		return nil, validationErr("Invalid ShardIteratorType: %v", *input.ShardIteratorType)
+	}
+
+	return &dynamodbstreams.GetShardIteratorOutput{ShardIterator: it.encode()}, nil
+}
+
+// GetRecords returns the records of a shard starting from
+// the shard iterator. NextShardIterator is nil once a closed
+// shard has no more records.
+func (s *Streams) GetRecords(
+	input *dynamodbstreams.GetRecordsInput) (*dynamodbstreams.GetRecordsOutput, error) {
+
+	return s.GetRecordsWithContext(aws.BackgroundContext(), input)
+}
+
+// GetRecordsWithContext is the same as GetRecords
+// with the addition of a request context.
+func (s *Streams) GetRecordsWithContext(
+	ctx aws.Context,
+	input *dynamodbstreams.GetRecordsInput,
+	opts ...request.Option) (*dynamodbstreams.GetRecordsOutput, error) {
+
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	} else if err := input.Validate(); err != nil {
+		return nil, err
+	}
+
+	it, err := decodeShardIterator(input.ShardIterator)
+	if err != nil {
+		return nil, err
+	}
+
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	st, err := s.stream(&it.Arn)
+	if err != nil {
+		return nil, err
+	} else if it.Shard != st.shardID || it.Pos > len(st.records) {
+		return nil, validationErr("Invalid ShardIterator")
+	}
+
+	limit := maxGetRecords
+	if input.Limit != nil && int(*input.Limit) < limit {
+		limit = int(*input.Limit)
+	}
+
+	end := it.Pos + limit
+	if end > len(st.records) {
+		end = len(st.records)
+	}
+
+	output := &dynamodbstreams.GetRecordsOutput{
+		Records: append([]*dynamodbstreams.Record{}, st.records[it.Pos:end]...),
+	}
+
+	it.Pos = end
+	if !st.disabled() || it.Pos < len(st.records) {
+		output.NextShardIterator = it.encode()
+	}
+
+	return output, nil
+}
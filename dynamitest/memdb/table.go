@@ -0,0 +1,768 @@
+package memdb
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/client/metadata"
+	"github.com/aws/aws-sdk-go/aws/request"
+	db "github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// index is a local or global secondary index.
+type index struct {
+	name       string
+	global     bool
+	keySchema  []*db.KeySchemaElement
+	projection *db.Projection
+	throughput *db.ProvisionedThroughputDescription
+}
+
+// keys returns the hash and range key names of a key schema.
+// rangeKey is empty if the key schema has no range key.
+func keys(keySchema []*db.KeySchemaElement) (hashKey, rangeKey string) {
+	for _, ke := range keySchema {
+		if *ke.KeyType == db.KeyTypeHash {
+			hashKey = *ke.AttributeName
+		} else {
+			rangeKey = *ke.AttributeName
+		}
+	}
+
+	return hashKey, rangeKey
+}
+
+type table struct {
+	name       string
+	arn        string
+	created    time.Time
+	attrDefs   []*db.AttributeDefinition
+	keySchema  []*db.KeySchemaElement
+	throughput *db.ProvisionedThroughputDescription
+	indexes    []*index
+
+	streamSpec *db.StreamSpecification
+	stream     *stream
+
+	// items maps encoded primary keys to items
+	items map[string]item
+}
+
+func (t *table) hashKey() string {
+	h, _ := keys(t.keySchema)
+	return h
+}
+
+func (t *table) rangeKey() string {
+	_, r := keys(t.keySchema)
+	return r
+}
+
+func (t *table) index(name string) (*index, error) {
+	for _, idx := range t.indexes {
+		if idx.name == name {
+			return idx, nil
+		}
+	}
+
+	return nil, validationErr("The table does not have the specified index: %v", name)
+}
+
+func (t *table) attrType(name string) string {
+	for _, def := range t.attrDefs {
+		if *def.AttributeName == name {
+			return *def.AttributeType
+		}
+	}
+
+	return ""
+}
+
+// keyNames returns the names of the primary key attributes.
+func (t *table) keyNames() []string {
+	names := []string{}
+	for _, ke := range t.keySchema {
+		names = append(names, *ke.AttributeName)
+	}
+	return names
+}
+
+// key extracts the primary key of an item.
+func (t *table) key(it item) item {
+	key := item{}
+	for _, name := range t.keyNames() {
+		if v, ok := it[name]; ok {
+			key[name] = v
+		}
+	}
+	return key
+}
+
+// checkKey checks that key contains exactly the primary key
+// attributes and that they have the correct types. If full
+// is false, other attributes are allowed, eg. for PutItem.
+func (t *table) checkKey(key item, full bool) error {
+	names := t.keyNames()
+	if full && len(key) != len(names) {
+		return validationErr("The provided key element does not match the schema")
+	}
+
+	for _, name := range names {
+		v, ok := key[name]
+		if !ok || v == nil {
+			if full {
+				return validationErr("The provided key element does not match the schema")
+			}
+			return validationErr("One or more parameter values were invalid: Missing the key %v in the item", name)
+		}
+
+		if attrType(v) != t.attrType(name) {
+			return validationErr("One or more parameter values were invalid: Type mismatch for key %v expected: %v actual: %v", name, t.attrType(name), attrType(v))
+		}
+		if (v.S != nil && *v.S == "") || (v.B != nil && len(v.B) == 0) {
+			return validationErr("One or more parameter values are not valid. The AttributeValue for a key attribute cannot contain an empty string value. Key: %v", name)
+		}
+	}
+
+	return nil
+}
+
+// checkIndexKeys checks that the index key attributes
+// of an item, if present, have the correct types.
+func (t *table) checkIndexKeys(it item) error {
+	for _, idx := range t.indexes {
+		for _, ke := range idx.keySchema {
+			name := *ke.AttributeName
+			v, ok := it[name]
+			if !ok {
+				continue
+			}
+
+			if attrType(v) != t.attrType(name) {
+				return validationErr("One or more parameter values were invalid: Type mismatch for Index Key %v Expected: %v Actual: %v IndexName: %v", name, t.attrType(name), attrType(v), idx.name)
+			}
+			if (v.S != nil && *v.S == "") || (v.B != nil && len(v.B) == 0) {
+				return validationErr("One or more parameter values are not valid. A value specified for a secondary index key is not supported. The AttributeValue for a key attribute cannot contain an empty string value. IndexName: %v, IndexKey: %v", idx.name, name)
+			}
+		}
+	}
+
+	return nil
+}
+
+// encodeKey returns a string that uniquely
+// identifies the primary key of an item.
+func (t *table) encodeKey(it item) string {
+	return encodeValues(it, t.keyNames())
+}
+
+func encodeValues(it item, names []string) string {
+	s := ""
+	for _, name := range names {
+		v := it[name]
+		switch {
+		case v == nil:
+			s += "-|"
+		case v.S != nil:
+			s += fmt.Sprintf("S%d:%s|", len(*v.S), *v.S)
+		case v.N != nil:
+			r, _ := parseNumber(*v.N)
+			n := formatNumber(r)
+			s += fmt.Sprintf("N%d:%s|", len(n), n)
+		case v.B != nil:
+			s += fmt.Sprintf("B%d:%s|", len(v.B), v.B)
+		}
+	}
+
+	return s
+}
+
+func (t *table) sizeBytes() int64 {
+	size := 0
+	for _, it := range t.items {
+		size += itemSize(it)
+	}
+	return int64(size)
+}
+
+// indexItemCount returns the number of
+// items that have the index key attributes.
+func (t *table) indexItemCount(idx *index) (count, size int64) {
+	for _, it := range t.items {
+		if hasKeys(it, idx.keySchema) {
+			count++
+			size += int64(itemSize(it))
+		}
+	}
+	return count, size
+}
+
+func hasKeys(it item, keySchema []*db.KeySchemaElement) bool {
+	for _, ke := range keySchema {
+		if _, ok := it[*ke.AttributeName]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func (t *table) describe() *db.TableDescription {
+	desc := &db.TableDescription{
+		TableName:             aws.String(t.name),
+		TableArn:              aws.String(t.arn),
+		TableStatus:           aws.String(db.TableStatusActive),
+		CreationDateTime:      aws.Time(t.created),
+		AttributeDefinitions:  t.attrDefs,
+		KeySchema:             t.keySchema,
+		ProvisionedThroughput: t.throughput,
+		ItemCount:             aws.Int64(int64(len(t.items))),
+		TableSizeBytes:        aws.Int64(t.sizeBytes()),
+	}
+
+	if t.streamSpec != nil && aws.BoolValue(t.streamSpec.StreamEnabled) {
+		desc.StreamSpecification = t.streamSpec
+	}
+	if t.stream != nil {
+		desc.LatestStreamArn = aws.String(t.stream.arn)
+		desc.LatestStreamLabel = aws.String(t.stream.label)
+	}
+
+	for _, idx := range t.indexes {
+		count, size := t.indexItemCount(idx)
+		if idx.global {
+			desc.GlobalSecondaryIndexes = append(desc.GlobalSecondaryIndexes, &db.GlobalSecondaryIndexDescription{
+				IndexName:             aws.String(idx.name),
+				IndexArn:              aws.String(t.arn + "/index/" + idx.name),
+				IndexStatus:           aws.String(db.IndexStatusActive),
+				KeySchema:             idx.keySchema,
+				Projection:            idx.projection,
+				ProvisionedThroughput: idx.throughput,
+				ItemCount:             aws.Int64(count),
+				IndexSizeBytes:        aws.Int64(size),
+			})
+		} else {
+			desc.LocalSecondaryIndexes = append(desc.LocalSecondaryIndexes, &db.LocalSecondaryIndexDescription{
+				IndexName:      aws.String(idx.name),
+				IndexArn:       aws.String(t.arn + "/index/" + idx.name),
+				KeySchema:      idx.keySchema,
+				Projection:     idx.projection,
+				ItemCount:      aws.Int64(count),
+				IndexSizeBytes: aws.Int64(size),
+			})
+		}
+	}
+
+	return desc
+}
+
+func throughputDesc(tp *db.ProvisionedThroughput) *db.ProvisionedThroughputDescription {
+	desc := &db.ProvisionedThroughputDescription{
+		ReadCapacityUnits:      aws.Int64(0),
+		WriteCapacityUnits:     aws.Int64(0),
+		NumberOfDecreasesToday: aws.Int64(0),
+	}
+	if tp != nil {
+		desc.ReadCapacityUnits = aws.Int64(aws.Int64Value(tp.ReadCapacityUnits))
+		desc.WriteCapacityUnits = aws.Int64(aws.Int64Value(tp.WriteCapacityUnits))
+	}
+
+	return desc
+}
+
+// checkKeySchema checks that a key schema has a hash key,
+// an optional range key, and that its attributes are defined
+// as scalar attributes.
+func checkKeySchema(keySchema []*db.KeySchemaElement, defs map[string]string) error {
+	if len(keySchema) == 0 || len(keySchema) > 2 {
+		return validationErr("1 validation error detected: Value at 'keySchema' failed to satisfy constraint: Member must have length less than or equal to 2")
+	}
+
+	hashes := 0
+	for _, ke := range keySchema {
+		if ke == nil || ke.AttributeName == nil || ke.KeyType == nil {
+			return validationErr("Invalid KeySchema: Some key schema elements are missing")
+		}
+
+		switch *ke.KeyType {
+		case db.KeyTypeHash:
+			hashes++
+		case db.KeyTypeRange:
+		default:
+			return validationErr("Invalid KeyType: %v", *ke.KeyType)
+		}
+
+		if _, ok := defs[*ke.AttributeName]; !ok {
+			return validationErr("One or more parameter values were invalid: Some index key attributes are not defined in AttributeDefinitions. Keys: [%v], AttributeDefinitions: %v", *ke.AttributeName, defNames(defs))
+		}
+	}
+
+	if hashes != 1 || *keySchema[0].KeyType != db.KeyTypeHash {
+		return validationErr("Invalid KeySchema: The first KeySchemaElement is not a HASH key type")
+	}
+
+	return nil
+}
+
+func defNames(defs map[string]string) []string {
+	names := []string{}
+	for name := range defs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func checkProjection(proj *db.Projection) error {
+	if proj == nil || proj.ProjectionType == nil {
+		return validationErr("One or more parameter values were invalid: Unknown ProjectionType: null")
+	}
+
+	switch *proj.ProjectionType {
+	case db.ProjectionTypeAll, db.ProjectionTypeKeysOnly:
+		if len(proj.NonKeyAttributes) > 0 {
+			return validationErr("One or more parameter values were invalid: ProjectionType is %v, but NonKeyAttributes is specified", *proj.ProjectionType)
+		}
+	case db.ProjectionTypeInclude:
+	default:
+		return validationErr("One or more parameter values were invalid: Unknown ProjectionType: %v", *proj.ProjectionType)
+	}
+
+	return nil
+}
+
+// CreateTable creates a new table. The table is active
+// immediately and has no limits on its throughput.
+func (d *DB) CreateTable(input *db.CreateTableInput) (*db.CreateTableOutput, error) {
+	return d.CreateTableWithContext(aws.BackgroundContext(), input)
+}
+
+// CreateTableWithContext is the same as CreateTable
+// with the addition of a request context.
+func (d *DB) CreateTableWithContext(
+	ctx aws.Context,
+	input *db.CreateTableInput,
+	opts ...request.Option) (*db.CreateTableOutput, error) {
+
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	} else if err := input.Validate(); err != nil {
+		return nil, err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	name := *input.TableName
+	if _, ok := d.tables[name]; ok {
+		return nil, inUseErr("Cannot create preexisting table")
+	}
+
+	defs := map[string]string{}
+	for _, def := range input.AttributeDefinitions {
+		switch *def.AttributeType {
+		case db.ScalarAttributeTypeS, db.ScalarAttributeTypeN, db.ScalarAttributeTypeB:
+		default:
+			return nil, validationErr("Invalid AttributeType: %v", *def.AttributeType)
+		}
+
+		if _, ok := defs[*def.AttributeName]; ok {
+			return nil, validationErr("Cannot have two attributes with the same name")
+		}
+		defs[*def.AttributeName] = *def.AttributeType
+	}
+
+	if err := checkKeySchema(input.KeySchema, defs); err != nil {
+		return nil, err
+	}
+
+	t := &table{
+		name:       name,
+		arn:        fmt.Sprintf("arn:aws:dynamodb:%v:000000000000:table/%v", region, name),
+		created:    now(),
+		attrDefs:   input.AttributeDefinitions,
+		keySchema:  input.KeySchema,
+		throughput: throughputDesc(input.ProvisionedThroughput),
+		items:      map[string]item{},
+	}
+
+	used := map[string]bool{}
+	for _, ke := range input.KeySchema {
+		used[*ke.AttributeName] = true
+	}
+
+	names := map[string]bool{}
+	for _, lsi := range input.LocalSecondaryIndexes {
+		if t.rangeKey() == "" {
+			return nil, validationErr("One or more parameter values were invalid: Table KeySchema does not have a range key, which is required when specifying a LocalSecondaryIndex")
+		} else if err := checkKeySchema(lsi.KeySchema, defs); err != nil {
+			return nil, err
+		} else if err := checkProjection(lsi.Projection); err != nil {
+			return nil, err
+		}
+
+		hashKey, rangeKey := keys(lsi.KeySchema)
+		if hashKey != t.hashKey() || rangeKey == "" {
+			return nil, validationErr("One or more parameter values were invalid: Index KeySchema does not have the same leading hash key as table KeySchema for index: %v", *lsi.IndexName)
+		}
+
+		if names[*lsi.IndexName] {
+			return nil, validationErr("One or more parameter values were invalid: Duplicate index name: %v", *lsi.IndexName)
+		}
+		names[*lsi.IndexName] = true
+
+		for _, ke := range lsi.KeySchema {
+			used[*ke.AttributeName] = true
+		}
+		t.indexes = append(t.indexes, &index{
+			name:       *lsi.IndexName,
+			keySchema:  lsi.KeySchema,
+			projection: lsi.Projection,
+		})
+	}
+
+	for _, gsi := range input.GlobalSecondaryIndexes {
+		if err := checkKeySchema(gsi.KeySchema, defs); err != nil {
+			return nil, err
+		} else if err := checkProjection(gsi.Projection); err != nil {
+			return nil, err
+		}
+
+		if names[*gsi.IndexName] {
+			return nil, validationErr("One or more parameter values were invalid: Duplicate index name: %v", *gsi.IndexName)
+		}
+		names[*gsi.IndexName] = true
+
+		for _, ke := range gsi.KeySchema {
+			used[*ke.AttributeName] = true
+		}
+		t.indexes = append(t.indexes, &index{
+			name:       *gsi.IndexName,
+			global:     true,
+			keySchema:  gsi.KeySchema,
+			projection: gsi.Projection,
+			throughput: throughputDesc(gsi.ProvisionedThroughput),
+		})
+	}
+
+	for name := range defs {
+		if !used[name] {
+			return nil, validationErr("One or more parameter values were invalid: Number of attributes in KeySchema does not exactly match number of attributes defined in AttributeDefinitions")
+		}
+	}
+
+	if err := d.setStream(t, input.StreamSpecification); err != nil {
+		return nil, err
+	}
+
+	d.tables[name] = t
+	return &db.CreateTableOutput{TableDescription: t.describe()}, nil
+}
+
+// DescribeTable returns information about a table.
+func (d *DB) DescribeTable(input *db.DescribeTableInput) (*db.DescribeTableOutput, error) {
+	return d.DescribeTableWithContext(aws.BackgroundContext(), input)
+}
+
+// DescribeTableWithContext is the same as DescribeTable
+// with the addition of a request context.
+func (d *DB) DescribeTableWithContext(
+	ctx aws.Context,
+	input *db.DescribeTableInput,
+	opts ...request.Option) (*db.DescribeTableOutput, error) {
+
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	t, err := d.table(input.TableName)
+	if err != nil {
+		return nil, notFoundErr("Requested resource not found: Table: %v not found", aws.StringValue(input.TableName))
+	}
+
+	return &db.DescribeTableOutput{Table: t.describe()}, nil
+}
+
+// DescribeTableRequest returns a request that calls DescribeTable
+// when sent. This is used by waiters such as the one waiting for
+// secondary indices to become active.
+func (d *DB) DescribeTableRequest(
+	input *db.DescribeTableInput) (*request.Request, *db.DescribeTableOutput) {
+
+	output := &db.DescribeTableOutput{}
+	req := request.New(
+		aws.Config{},
+		metadata.ClientInfo{ServiceName: db.ServiceName},
+		request.Handlers{},
+		nil,
+		&request.Operation{Name: "DescribeTable"},
+		input,
+		output,
+	)
+
+	req.Handlers.Send.PushBack(func(r *request.Request) {
+		resp, err := d.DescribeTableWithContext(r.Context(), input)
+		if err != nil {
+			r.Error = err
+			return
+		}
+		*output = *resp
+	})
+
+	return req, output
+}
+
+// UpdateTable modifies the throughput, stream, or
+// global secondary indices of a table. Like CreateTable,
+// the changes take effect immediately.
+func (d *DB) UpdateTable(input *db.UpdateTableInput) (*db.UpdateTableOutput, error) {
+	return d.UpdateTableWithContext(aws.BackgroundContext(), input)
+}
+
+// UpdateTableWithContext is the same as UpdateTable
+// with the addition of a request context.
+func (d *DB) UpdateTableWithContext(
+	ctx aws.Context,
+	input *db.UpdateTableInput,
+	opts ...request.Option) (*db.UpdateTableOutput, error) {
+
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	} else if err := input.Validate(); err != nil {
+		return nil, err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	t, err := d.table(input.TableName)
+	if err != nil {
+		return nil, err
+	}
+
+	if input.ProvisionedThroughput == nil &&
+		input.StreamSpecification == nil &&
+		len(input.GlobalSecondaryIndexUpdates) == 0 {
+
+		return nil, validationErr("At least one of ProvisionedThroughput, StreamSpecification or GlobalSecondaryIndexUpdates is required")
+	}
+
+	// Work on a copy so that the table is
+	// unchanged if some of the updates fail.
+	cpy := *t
+	cpy.attrDefs = append([]*db.AttributeDefinition{}, t.attrDefs...)
+	cpy.indexes = append([]*index{}, t.indexes...)
+
+	defs := map[string]string{}
+	for _, def := range cpy.attrDefs {
+		defs[*def.AttributeName] = *def.AttributeType
+	}
+	for _, def := range input.AttributeDefinitions {
+		if typ, ok := defs[*def.AttributeName]; ok {
+			if typ != *def.AttributeType {
+				return nil, validationErr("Cannot change the type of attribute %v", *def.AttributeName)
+			}
+			continue
+		}
+
+		defs[*def.AttributeName] = *def.AttributeType
+		cpy.attrDefs = append(cpy.attrDefs, def)
+	}
+
+	if input.ProvisionedThroughput != nil {
+		cpy.throughput = throughputDesc(input.ProvisionedThroughput)
+	}
+
+	for _, u := range input.GlobalSecondaryIndexUpdates {
+		switch {
+		case u.Create != nil:
+			c := u.Create
+			if err := checkKeySchema(c.KeySchema, defs); err != nil {
+				return nil, err
+			} else if err := checkProjection(c.Projection); err != nil {
+				return nil, err
+			} else if _, err := cpy.index(*c.IndexName); err == nil {
+				return nil, validationErr("One or more parameter values were invalid: Index %v already exists", *c.IndexName)
+			}
+
+			cpy.indexes = append(cpy.indexes, &index{
+				name:       *c.IndexName,
+				global:     true,
+				keySchema:  c.KeySchema,
+				projection: c.Projection,
+				throughput: throughputDesc(c.ProvisionedThroughput),
+			})
+
+		case u.Update != nil:
+			idx, err := cpy.index(*u.Update.IndexName)
+			if err != nil || !idx.global {
+				return nil, notFoundErr("Requested resource not found: Index: %v not found", *u.Update.IndexName)
+			}
+
+			updated := *idx
+			updated.throughput = throughputDesc(u.Update.ProvisionedThroughput)
+			for i := range cpy.indexes {
+				if cpy.indexes[i] == idx {
+					cpy.indexes[i] = &updated
+				}
+			}
+
+		case u.Delete != nil:
+			idx, err := cpy.index(*u.Delete.IndexName)
+			if err != nil || !idx.global {
+				return nil, notFoundErr("Requested resource not found: Index: %v not found", *u.Delete.IndexName)
+			}
+
+			for i := range cpy.indexes {
+				if cpy.indexes[i] == idx {
+					cpy.indexes = append(cpy.indexes[:i], cpy.indexes[i+1:]...)
+					break
+				}
+			}
+		}
+	}
+
+	if input.StreamSpecification != nil {
+		enabled := t.streamSpec != nil && aws.BoolValue(t.streamSpec.StreamEnabled)
+		if aws.BoolValue(input.StreamSpecification.StreamEnabled) == enabled {
+			if enabled {
+				return nil, validationErr("Table already has an enabled stream: %v", t.stream.arn)
+			}
+			return nil, validationErr("Table already has stream disabled")
+		}
+	}
+
+	// Nothing can fail after this point
+	if input.StreamSpecification != nil {
+		if err := d.setStream(&cpy, input.StreamSpecification); err != nil {
+			return nil, err
+		}
+	}
+
+	*t = cpy
+	return &db.UpdateTableOutput{TableDescription: t.describe()}, nil
+}
+
+// DeleteTable deletes a table and all of its items.
+// The stream of the table, if any, is disabled.
+func (d *DB) DeleteTable(input *db.DeleteTableInput) (*db.DeleteTableOutput, error) {
+	return d.DeleteTableWithContext(aws.BackgroundContext(), input)
+}
+
+// DeleteTableWithContext is the same as DeleteTable
+// with the addition of a request context.
+func (d *DB) DeleteTableWithContext(
+	ctx aws.Context,
+	input *db.DeleteTableInput,
+	opts ...request.Option) (*db.DeleteTableOutput, error) {
+
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	t, err := d.table(input.TableName)
+	if err != nil {
+		return nil, err
+	}
+
+	desc := t.describe()
+	desc.TableStatus = aws.String(db.TableStatusDeleting)
+	if t.stream != nil {
+		d.closeStream(t.stream)
+	}
+	delete(d.tables, t.name)
+
+	return &db.DeleteTableOutput{TableDescription: desc}, nil
+}
+
+// ListTables returns the table names in alphabetical order.
+func (d *DB) ListTables(input *db.ListTablesInput) (*db.ListTablesOutput, error) {
+	return d.ListTablesWithContext(aws.BackgroundContext(), input)
+}
+
+// ListTablesWithContext is the same as ListTables
+// with the addition of a request context.
+func (d *DB) ListTablesWithContext(
+	ctx aws.Context,
+	input *db.ListTablesInput,
+	opts ...request.Option) (*db.ListTablesOutput, error) {
+
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	} else if err := input.Validate(); err != nil {
+		return nil, err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	names := []string{}
+	for name := range d.tables {
+		if input.ExclusiveStartTableName == nil || name > *input.ExclusiveStartTableName {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	limit := 100
+	if input.Limit != nil {
+		limit = int(*input.Limit)
+	}
+
+	output := &db.ListTablesOutput{TableNames: []*string{}}
+	if len(names) > limit {
+		names = names[:limit]
+		output.LastEvaluatedTableName = aws.String(names[limit-1])
+	}
+	output.TableNames = aws.StringSlice(names)
+
+	return output, nil
+}
+
+// WaitUntilTableExists returns once the table exists.
+// Since tables are created immediately, this only
+// checks that the table exists.
+func (d *DB) WaitUntilTableExists(input *db.DescribeTableInput) error {
+	return d.WaitUntilTableExistsWithContext(aws.BackgroundContext(), input)
+}
+
+// WaitUntilTableExistsWithContext is the same as
+// WaitUntilTableExists with the addition of a context.
+func (d *DB) WaitUntilTableExistsWithContext(
+	ctx aws.Context,
+	input *db.DescribeTableInput,
+	opts ...request.WaiterOption) error {
+
+	_, err := d.DescribeTableWithContext(ctx, input)
+	return err
+}
+
+// WaitUntilTableNotExists returns once the table no
+// longer exists. Since tables are deleted immediately,
+// this only checks that the table doesn't exist.
+func (d *DB) WaitUntilTableNotExists(input *db.DescribeTableInput) error {
+	return d.WaitUntilTableNotExistsWithContext(aws.BackgroundContext(), input)
+}
+
+// WaitUntilTableNotExistsWithContext is the same as
+// WaitUntilTableNotExists with the addition of a context.
+func (d *DB) WaitUntilTableNotExistsWithContext(
+	ctx aws.Context,
+	input *db.DescribeTableInput,
+	opts ...request.WaiterOption) error {
+
+	_, err := d.DescribeTableWithContext(ctx, input)
+	if err == nil {
+		return fmt.Errorf("table %v still exists", aws.StringValue(input.TableName))
+	} else if _, ok := err.(*db.ResourceNotFoundException); ok {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,238 @@
+package memdb
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	db "github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// TransactWriteItems atomically puts, updates, deletes, and
+// checks the conditions of up to 100 items. If a condition
+// fails, none of the items are written and the error is a
+// *dynamodb.TransactionCanceledException.
+func (d *DB) TransactWriteItems(
+	input *db.TransactWriteItemsInput) (*db.TransactWriteItemsOutput, error) {
+
+	return d.TransactWriteItemsWithContext(aws.BackgroundContext(), input)
+}
+
+// TransactWriteItemsWithContext is the same as
+// TransactWriteItems with the addition of a context.
+func (d *DB) TransactWriteItemsWithContext(
+	ctx aws.Context,
+	input *db.TransactWriteItemsInput,
+	opts ...request.Option) (*db.TransactWriteItemsOutput, error) {
+
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	} else if err := input.Validate(); err != nil {
+		return nil, err
+	} else if len(input.TransactItems) > maxTransactItems {
+		return nil, validationErr("Member must have length less than or equal to %d", maxTransactItems)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	writes := make([]*write, len(input.TransactItems))
+	reasons := make([]*db.CancellationReason, len(input.TransactItems))
+	canceled := false
+
+	// Each item can only be in one step. This is
+	// checked before any condition is evaluated.
+	seen := map[string]bool{}
+	for _, ti := range input.TransactItems {
+		var tableName *string
+		var key item
+		switch {
+		case ti.Put != nil:
+			tableName, key = ti.Put.TableName, ti.Put.Item
+		case ti.Delete != nil:
+			tableName, key = ti.Delete.TableName, ti.Delete.Key
+		case ti.Update != nil:
+			tableName, key = ti.Update.TableName, ti.Update.Key
+		case ti.ConditionCheck != nil:
+			tableName, key = ti.ConditionCheck.TableName, ti.ConditionCheck.Key
+		default:
+			continue
+		}
+
+		t, err := d.table(tableName)
+		if err != nil {
+			return nil, err
+		}
+
+		k := t.name + "/" + t.encodeKey(key)
+		if seen[k] {
+			return nil, validationErr("Transaction request cannot include multiple operations on one item")
+		}
+		seen[k] = true
+	}
+
+	for i, ti := range input.TransactItems {
+		var w *write
+		var err error
+		var t *table
+
+		switch {
+		case ti.Put != nil:
+			put := ti.Put
+			if t, err = d.table(put.TableName); err == nil {
+				w, err = d.preparePut(
+					t,
+					put.Item,
+					put.ConditionExpression,
+					put.ExpressionAttributeNames,
+					put.ExpressionAttributeValues,
+				)
+			}
+
+		case ti.Delete != nil:
+			del := ti.Delete
+			if t, err = d.table(del.TableName); err == nil {
+				w, err = d.prepareDelete(
+					t,
+					del.Key,
+					del.ConditionExpression,
+					del.ExpressionAttributeNames,
+					del.ExpressionAttributeValues,
+				)
+			}
+
+		case ti.Update != nil:
+			upd := ti.Update
+			if t, err = d.table(upd.TableName); err == nil {
+				w, _, err = d.prepareUpdate(
+					t,
+					upd.Key,
+					upd.UpdateExpression,
+					upd.ConditionExpression,
+					upd.ExpressionAttributeNames,
+					upd.ExpressionAttributeValues,
+				)
+			}
+
+		case ti.ConditionCheck != nil:
+			cc := ti.ConditionCheck
+			if t, err = d.table(cc.TableName); err == nil {
+				err = t.checkKey(cc.Key, true)
+			}
+			if err == nil {
+				current := t.items[t.encodeKey(cc.Key)]
+				p := newParser(cc.ExpressionAttributeNames, cc.ExpressionAttributeValues)
+				if err = checkCondition(p, cc.ConditionExpression, current); err == nil {
+					err = checkUnused(p)
+				}
+			}
+
+		default:
+			err = validationErr("TransactItems can only contain one of Check, Put, Update or Delete")
+		}
+
+		if _, ok := err.(*db.ConditionalCheckFailedException); ok {
+			canceled = true
+			reasons[i] = &db.CancellationReason{
+				Code:    aws.String("ConditionalCheckFailed"),
+				Message: aws.String("The conditional request failed"),
+			}
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		reasons[i] = &db.CancellationReason{Code: aws.String("None")}
+		writes[i] = w
+	}
+
+	if canceled {
+		return nil, cancelErr(reasons)
+	}
+
+	cs := map[string]*consumed{}
+	for _, w := range writes {
+		if w != nil {
+			d.apply(w)
+
+			if cs[w.t.name] == nil {
+				cs[w.t.name] = newConsumed(w.t)
+			}
+			cs[w.t.name].write(w, true)
+		}
+	}
+
+	return &db.TransactWriteItemsOutput{
+		ConsumedCapacity: outputs(cs, input.ReturnConsumedCapacity),
+	}, nil
+}
+
+func cancelErr(reasons []*db.CancellationReason) error {
+	codes := ""
+	for i, r := range reasons {
+		if i > 0 {
+			codes += ", "
+		}
+		codes += aws.StringValue(r.Code)
+	}
+
+	return &db.TransactionCanceledException{
+		Message_:            aws.String(fmt.Sprintf("Transaction cancelled, please refer cancellation reasons for specific reasons [%v]", codes)),
+		CancellationReasons: reasons,
+	}
+}
+
+// TransactGetItems atomically returns up to 100 items.
+// The response of an item that doesn't exist is empty.
+func (d *DB) TransactGetItems(
+	input *db.TransactGetItemsInput) (*db.TransactGetItemsOutput, error) {
+
+	return d.TransactGetItemsWithContext(aws.BackgroundContext(), input)
+}
+
+// TransactGetItemsWithContext is the same as
+// TransactGetItems with the addition of a context.
+func (d *DB) TransactGetItemsWithContext(
+	ctx aws.Context,
+	input *db.TransactGetItemsInput,
+	opts ...request.Option) (*db.TransactGetItemsOutput, error) {
+
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	} else if err := input.Validate(); err != nil {
+		return nil, err
+	} else if len(input.TransactItems) > maxTransactItems {
+		return nil, validationErr("Member must have length less than or equal to %d", maxTransactItems)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	output := &db.TransactGetItemsOutput{
+		Responses: make([]*db.ItemResponse, len(input.TransactItems)),
+	}
+	cs := map[string]*consumed{}
+	for i, ti := range input.TransactItems {
+		get := ti.Get
+		t, err := d.table(get.TableName)
+		if err != nil {
+			return nil, err
+		}
+
+		it, err := d.getItem(t, get.Key, get.ProjectionExpression, nil, get.ExpressionAttributeNames)
+		if err != nil {
+			return nil, err
+		}
+		output.Responses[i] = &db.ItemResponse{Item: it}
+
+		// Transactional reads cost twice as much
+		if cs[t.name] == nil {
+			cs[t.name] = newConsumed(t)
+		}
+		stored := t.items[t.encodeKey(get.Key)]
+		cs[t.name].add(nil, 2*readUnits(itemSize(stored), true))
+	}
+
+	output.ConsumedCapacity = outputs(cs, input.ReturnConsumedCapacity)
+	return output, nil
+}
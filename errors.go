@@ -0,0 +1,150 @@
+package dynami
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	db "github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// Error is returned when a DynamoDB request fails. It wraps
+// the error returned by the SDK which can be retrieved using
+// errors.As, eg. with an awserr.Error target. It also matches
+// ErrTableNotFound, ErrThrottled, ErrConditionFailed,
+// ErrValidation and ErrItemTooLarge using errors.Is depending
+// on the error code.
+type Error struct {
+	// Op describes the failed
+	// operation, eg. "cannot put item".
+	Op string
+
+	// Code is the AWS error code, eg.
+	// "ResourceNotFoundException". It's
+	// empty if Err is not an AWS error.
+	Code string
+
+	Err error
+}
+
+// newError wraps the error of a failed request.
+func newError(op string, err error) error {
+	return &Error{
+		Op:   op,
+		Code: awsErrCode(err),
+		Err:  err,
+	}
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("dynami: %v (%v)", e.Op, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is the
+// sentinel error of the error code.
+func (e *Error) Is(target error) bool {
+	switch target {
+	case ErrTableNotFound:
+		return e.Code == db.ErrCodeResourceNotFoundException
+	case ErrThrottled:
+		return request.IsErrorThrottle(e.Err)
+	case ErrConditionFailed:
+		return e.Code == db.ErrCodeConditionalCheckFailedException
+	case ErrValidation:
+		return e.Code == errValidation
+	case ErrItemTooLarge:
+		return e.Code == errValidation && isItemTooLarge(e.Err)
+	}
+
+	return false
+}
+
+// errValidation is the error code of invalid requests.
+const errValidation = "ValidationException"
+
+// isItemTooLarge returns true if err is a validation
+// error caused by an item exceeding the size limit.
+func isItemTooLarge(err error) bool {
+	var aerr awserr.Error
+	if !errors.As(err, &aerr) {
+		return false
+	}
+
+	msg := aerr.Message()
+	return strings.Contains(msg, "Item size") && strings.Contains(msg, "exceeded")
+}
+
+// retryableCodes are the error codes of
+// transient errors not covered by the SDK.
+var retryableCodes = map[string]bool{
+	db.ErrCodeInternalServerError:    true,
+	"TransactionConflictException":   true,
+	"TransactionInProgressException": true,
+	"ServiceUnavailable":             true,
+}
+
+// IsRetryable returns true if err is transient and the
+// operation that caused it can be safely retried later.
+// This includes throttling, unprocessed batch items, and
+// temporary network and server errors. For a BatchError,
+// this returns true if any of its items can be retried.
+func IsRetryable(err error) bool {
+	if errors.Is(err, ErrThrottled) || errors.Is(err, ErrUnprocessed) {
+		return true
+	}
+
+	var aerr awserr.Error
+	if !errors.As(err, &aerr) {
+		return false
+	}
+
+	return retryableCodes[aerr.Code()] ||
+		request.IsErrorThrottle(aerr) ||
+		request.IsErrorRetryable(aerr)
+}
+
+// reasonCodes maps the codes of transaction cancellation
+// reasons to the error codes of the equivalent requests.
+var reasonCodes = map[string]string{
+	"ConditionalCheckFailed":        db.ErrCodeConditionalCheckFailedException,
+	"ProvisionedThroughputExceeded": db.ErrCodeProvisionedThroughputExceededException,
+	"ThrottlingError":               "ThrottlingException",
+	"TransactionConflict":           "TransactionConflictException",
+	"ValidationError":               errValidation,
+}
+
+// reasonError returns the error of a
+// transaction cancellation reason.
+func reasonError(op string, r *db.CancellationReason) error {
+	code := aws.StringValue(r.Code)
+	if c, ok := reasonCodes[code]; ok {
+		code = c
+	}
+
+	return newError(op, awserr.New(code, aws.StringValue(r.Message), nil))
+}
+
+// unwrapErrors returns the errors of m
+// sorted by their index.
+func unwrapErrors(m map[int]error) []error {
+	indices := make([]int, 0, len(m))
+	for i := range m {
+		indices = append(indices, i)
+	}
+	sort.Ints(indices)
+
+	errs := make([]error, len(indices))
+	for i, idx := range indices {
+		errs[i] = m[idx]
+	}
+	return errs
+}
@@ -0,0 +1,69 @@
+package dynami
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	db "github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+func (suite *DatabaseTestSuite) TestErrors() {
+	assert := suite.Assert()
+	require := suite.Require()
+
+	c := suite.client
+	book := tBook{Title: "Wuthering Heights", Author: "Emily Bronte"}
+
+	err := c.PutItem("NoSuchTable", book)
+	require.NotNil(err)
+	assert.True(errors.Is(err, ErrTableNotFound))
+	assert.False(errors.Is(err, ErrThrottled))
+	assert.False(IsRetryable(err))
+
+	var aerr awserr.Error
+	require.True(errors.As(err, &aerr))
+	assert.Equal(db.ErrCodeResourceNotFoundException, aerr.Code())
+
+	var derr *Error
+	require.True(errors.As(err, &derr))
+	assert.Equal("cannot put item", derr.Op)
+
+	// Items over 400KB are rejected
+	large := map[string]interface{}{
+		"Title":  "Large",
+		"Author": "Author",
+		"Text":   strings.Repeat("a", 500*1024),
+	}
+	err = c.PutItem("Book", large)
+	require.NotNil(err)
+	assert.True(errors.Is(err, ErrItemTooLarge))
+	assert.True(errors.Is(err, ErrValidation))
+
+	fc, err := NewClientFromAPI(suite.db, nil)
+	require.Nil(err)
+	fc.Use(func(next Handler) Handler {
+		return func(ctx context.Context, op *Operation) error {
+			return awserr.New(db.ErrCodeProvisionedThroughputExceededException, "throttled", nil)
+		}
+	})
+
+	err = fc.PutItem("Book", book)
+	require.NotNil(err)
+	assert.True(errors.Is(err, ErrThrottled))
+	assert.True(IsRetryable(err))
+
+	// Batch errors match the errors of their items
+	berr := BatchError{
+		"Book": {
+			0: errors.New("invalid"),
+			3: ErrUnprocessed,
+		},
+	}
+	assert.Len(berr.Unwrap(), 2)
+	assert.Equal(ErrUnprocessed, berr.Unwrap()[1])
+	assert.True(errors.Is(berr, ErrUnprocessed))
+	assert.True(IsRetryable(berr))
+	assert.False(IsRetryable(BatchError{"Book": {0: errors.New("invalid")}}))
+}
@@ -1,6 +1,9 @@
 package dynami_test
 
 import (
+	"net/http"
+	"time"
+
 	"github.com/robskie/dynami"
 	"github.com/robskie/dynami/schema"
 )
@@ -130,3 +133,19 @@ func ExampleClient_UpdateTable() {
 	// Perform update
 	client.UpdateTable(table)
 }
+
+func ExampleNewClientWithOptions() {
+	// Connect to DynamoDB Local using
+	// the default credential chain
+	client, err := dynami.NewClientWithOptions(
+		dynami.WithRegion(dynami.USEast1),
+		dynami.WithEndpoint("http://localhost:8000"),
+		dynami.WithMaxRetries(3),
+		dynami.WithHTTPClient(&http.Client{Timeout: 30 * time.Second}),
+	)
+	if err != nil {
+		// Handle error
+	}
+
+	client.ListTables()
+}
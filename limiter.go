@@ -0,0 +1,502 @@
+package dynami
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	sc "github.com/robskie/dynami/schema"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	db "github.com/aws/aws-sdk-go/service/dynamodb"
+	dbiface "github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+)
+
+// RateLimit is the maximum number of read and write
+// capacity units per second that a client consumes
+// on a table or an index. Zero means no limit.
+type RateLimit struct {
+	Read  float64
+	Write float64
+}
+
+// ThroughputLimit returns the given fraction of a table's or
+// an index's provisioned throughput as a rate limit. For
+// example, a fraction of 0.3 limits the client to 30% of the
+// provisioned throughput.
+func ThroughputLimit(t sc.Throughput, fraction float64) RateLimit {
+	return RateLimit{
+		Read:  float64(t.Read) * fraction,
+		Write: float64(t.Write) * fraction,
+	}
+}
+
+func (l RateLimit) validate() error {
+	if l.Read < 0 || l.Write < 0 || math.IsNaN(l.Read) || math.IsNaN(l.Write) {
+		return fmt.Errorf("dynami: invalid rate limit")
+	}
+
+	return nil
+}
+
+// limitKey identifies the table or index of a rate
+// limit. index is empty for the table itself.
+type limitKey struct {
+	table string
+	index string
+}
+
+// bucket is a token bucket that holds capacity units. It
+// can go into debt so that requests which consume more
+// units than its size can still go through.
+type bucket struct {
+	mu     sync.Mutex
+	rate   float64
+	tokens float64
+	last   time.Time
+}
+
+// newBucket returns a full bucket that is refilled with
+// rate units per second and holds at most rate units.
+func newBucket(rate float64) *bucket {
+	return &bucket{
+		rate:   rate,
+		tokens: rate,
+		last:   time.Now(),
+	}
+}
+
+// refill must be called with b.mu held.
+func (b *bucket) refill() {
+	now := time.Now()
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	b.tokens = math.Min(b.tokens, b.rate)
+	b.last = now
+}
+
+// take waits until the bucket is out of
+// debt and then takes n units from it.
+func (b *bucket) take(ctx context.Context, n float64) error {
+	for {
+		b.mu.Lock()
+		b.refill()
+		if b.tokens >= 0 {
+			b.tokens -= n
+			b.mu.Unlock()
+			return nil
+		}
+
+		d := time.Duration(-b.tokens / b.rate * float64(time.Second))
+		b.mu.Unlock()
+
+		t := time.NewTimer(d + time.Millisecond)
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		}
+	}
+}
+
+// give returns n units to the bucket. n is negative
+// when a request consumed more than it has taken.
+func (b *bucket) give(n float64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refill()
+	b.tokens = math.Min(b.tokens+n, b.rate)
+}
+
+// charges maps buckets to capacity units.
+type charges map[*bucket]float64
+
+// limiter contains the read and write
+// buckets of the rate limited tables.
+type limiter struct {
+	read  map[limitKey]*bucket
+	write map[limitKey]*bucket
+
+	// indexes contains the names of the
+	// rate limited indexes of each table.
+	indexes map[string][]string
+}
+
+func newLimiter(limits map[limitKey]RateLimit) *limiter {
+	l := &limiter{
+		read:    map[limitKey]*bucket{},
+		write:   map[limitKey]*bucket{},
+		indexes: map[string][]string{},
+	}
+
+	for k, limit := range limits {
+		if limit.Read > 0 {
+			l.read[k] = newBucket(limit.Read)
+		}
+		if limit.Write > 0 {
+			l.write[k] = newBucket(limit.Write)
+		}
+		if k.index != "" {
+			l.indexes[k.table] = append(l.indexes[k.table], k.index)
+		}
+	}
+
+	return l
+}
+
+// readFrom adds n units to the read bucket of the given
+// index, or to that of its table if the index has none.
+func (l *limiter) readFrom(ch charges, table, index string, n float64) {
+	if b, ok := l.read[limitKey{table, index}]; ok && index != "" {
+		ch[b] += n
+	} else if b, ok := l.read[limitKey{table, ""}]; ok {
+		ch[b] += n
+	}
+}
+
+// writeTo adds n units to the write bucket of the given
+// table. Since writes also consume the capacity of global
+// secondary indexes, their buckets are added too but with
+// zero units. These are charged once the actual consumed
+// capacity is known.
+func (l *limiter) writeTo(ch charges, table string, n float64) {
+	if b, ok := l.write[limitKey{table, ""}]; ok {
+		ch[b] += n
+	}
+	for _, index := range l.indexes[table] {
+		if b, ok := l.write[limitKey{table, index}]; ok {
+			ch[b] += 0
+		}
+	}
+}
+
+// consumed returns the charges of the actual consumed
+// capacities. index is the index that was read, if any.
+func (l *limiter) consumed(ccs []*db.ConsumedCapacity, index string, write bool) charges {
+	buckets := l.read
+	if write {
+		buckets = l.write
+	}
+
+	ch := charges{}
+	for _, cc := range ccs {
+		if cc == nil {
+			continue
+		}
+		table := aws.StringValue(cc.TableName)
+
+		// If only the total is known, it is charged
+		// to the same bucket as the estimate
+		if cc.Table == nil {
+			n := aws.Float64Value(cc.CapacityUnits)
+			if write {
+				l.writeTo(ch, table, n)
+			} else {
+				l.readFrom(ch, table, index, n)
+			}
+			continue
+		}
+
+		// Local secondary indexes share
+		// the capacity of their table
+		tunits := capacityUnits(cc.Table, write)
+		for _, c := range cc.LocalSecondaryIndexes {
+			tunits += capacityUnits(c, write)
+		}
+		if b, ok := buckets[limitKey{table, ""}]; ok {
+			ch[b] += tunits
+		}
+
+		for index, c := range cc.GlobalSecondaryIndexes {
+			if b, ok := buckets[limitKey{table, index}]; ok {
+				ch[b] += capacityUnits(c, write)
+			}
+		}
+	}
+
+	return ch
+}
+
+// wait takes the estimated units from their buckets.
+func (l *limiter) wait(ctx context.Context, est charges) error {
+	for b, n := range est {
+		if err := b.take(ctx, n); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// settle charges the difference between the
+// estimated units and the actual units. If the
+// actual units are unknown, the estimate stays.
+func (l *limiter) settle(est charges, ccs []*db.ConsumedCapacity, index string, write bool) {
+	known := false
+	for _, cc := range ccs {
+		known = known || cc != nil
+	}
+	if !known {
+		return
+	}
+
+	actual := l.consumed(ccs, index, write)
+	for b, n := range est {
+		b.give(n - actual[b])
+		delete(actual, b)
+	}
+	for b, n := range actual {
+		b.give(-n)
+	}
+}
+
+// limitedDB is a DynamoDB API that rate limits the
+// requests of a client. It requests the consumed
+// capacity of each call to charge the buckets, using
+// a copy of the input so that the caller's is kept.
+type limitedDB struct {
+	dbiface.DynamoDBAPI
+
+	l *limiter
+}
+
+func (d *limitedDB) unwrap() dbiface.DynamoDBAPI {
+	return d.DynamoDBAPI
+}
+
+// returnCapacity returns the consumed capacity
+// mode that includes the capacity of indexes.
+func returnCapacity(mode *string) *string {
+	if aws.StringValue(mode) == db.ReturnConsumedCapacityTotal {
+		return mode
+	}
+
+	return aws.String(db.ReturnConsumedCapacityIndexes)
+}
+
+func (d *limitedDB) GetItemWithContext(
+	ctx aws.Context,
+	input *db.GetItemInput,
+	opts ...request.Option) (*db.GetItemOutput, error) {
+
+	est := charges{}
+	d.l.readFrom(est, aws.StringValue(input.TableName), "", 1)
+	if err := d.l.wait(ctx, est); err != nil {
+		return nil, err
+	}
+
+	in := *input
+	in.ReturnConsumedCapacity = returnCapacity(input.ReturnConsumedCapacity)
+	out, err := d.DynamoDBAPI.GetItemWithContext(ctx, &in, opts...)
+	if err == nil {
+		d.l.settle(est, []*db.ConsumedCapacity{out.ConsumedCapacity}, "", false)
+	}
+	return out, err
+}
+
+func (d *limitedDB) QueryWithContext(
+	ctx aws.Context,
+	input *db.QueryInput,
+	opts ...request.Option) (*db.QueryOutput, error) {
+
+	est := charges{}
+	index := aws.StringValue(input.IndexName)
+	d.l.readFrom(est, aws.StringValue(input.TableName), index, 1)
+	if err := d.l.wait(ctx, est); err != nil {
+		return nil, err
+	}
+
+	in := *input
+	in.ReturnConsumedCapacity = returnCapacity(input.ReturnConsumedCapacity)
+	out, err := d.DynamoDBAPI.QueryWithContext(ctx, &in, opts...)
+	if err == nil {
+		d.l.settle(est, []*db.ConsumedCapacity{out.ConsumedCapacity}, index, false)
+	}
+	return out, err
+}
+
+func (d *limitedDB) ScanWithContext(
+	ctx aws.Context,
+	input *db.ScanInput,
+	opts ...request.Option) (*db.ScanOutput, error) {
+
+	est := charges{}
+	index := aws.StringValue(input.IndexName)
+	d.l.readFrom(est, aws.StringValue(input.TableName), index, 1)
+	if err := d.l.wait(ctx, est); err != nil {
+		return nil, err
+	}
+
+	in := *input
+	in.ReturnConsumedCapacity = returnCapacity(input.ReturnConsumedCapacity)
+	out, err := d.DynamoDBAPI.ScanWithContext(ctx, &in, opts...)
+	if err == nil {
+		d.l.settle(est, []*db.ConsumedCapacity{out.ConsumedCapacity}, index, false)
+	}
+	return out, err
+}
+
+func (d *limitedDB) BatchGetItemWithContext(
+	ctx aws.Context,
+	input *db.BatchGetItemInput,
+	opts ...request.Option) (*db.BatchGetItemOutput, error) {
+
+	est := charges{}
+	for table, ka := range input.RequestItems {
+		d.l.readFrom(est, table, "", float64(len(ka.Keys)))
+	}
+	if err := d.l.wait(ctx, est); err != nil {
+		return nil, err
+	}
+
+	in := *input
+	in.ReturnConsumedCapacity = returnCapacity(input.ReturnConsumedCapacity)
+	out, err := d.DynamoDBAPI.BatchGetItemWithContext(ctx, &in, opts...)
+	if err == nil {
+		d.l.settle(est, out.ConsumedCapacity, "", false)
+	}
+	return out, err
+}
+
+func (d *limitedDB) TransactGetItemsWithContext(
+	ctx aws.Context,
+	input *db.TransactGetItemsInput,
+	opts ...request.Option) (*db.TransactGetItemsOutput, error) {
+
+	// Transactional reads cost twice as much
+	est := charges{}
+	for _, ti := range input.TransactItems {
+		if ti.Get != nil {
+			d.l.readFrom(est, aws.StringValue(ti.Get.TableName), "", 2)
+		}
+	}
+	if err := d.l.wait(ctx, est); err != nil {
+		return nil, err
+	}
+
+	in := *input
+	in.ReturnConsumedCapacity = returnCapacity(input.ReturnConsumedCapacity)
+	out, err := d.DynamoDBAPI.TransactGetItemsWithContext(ctx, &in, opts...)
+	if err == nil {
+		d.l.settle(est, out.ConsumedCapacity, "", false)
+	}
+	return out, err
+}
+
+func (d *limitedDB) PutItemWithContext(
+	ctx aws.Context,
+	input *db.PutItemInput,
+	opts ...request.Option) (*db.PutItemOutput, error) {
+
+	est := charges{}
+	d.l.writeTo(est, aws.StringValue(input.TableName), 1)
+	if err := d.l.wait(ctx, est); err != nil {
+		return nil, err
+	}
+
+	in := *input
+	in.ReturnConsumedCapacity = returnCapacity(input.ReturnConsumedCapacity)
+	out, err := d.DynamoDBAPI.PutItemWithContext(ctx, &in, opts...)
+	if err == nil {
+		d.l.settle(est, []*db.ConsumedCapacity{out.ConsumedCapacity}, "", true)
+	}
+	return out, err
+}
+
+func (d *limitedDB) DeleteItemWithContext(
+	ctx aws.Context,
+	input *db.DeleteItemInput,
+	opts ...request.Option) (*db.DeleteItemOutput, error) {
+
+	est := charges{}
+	d.l.writeTo(est, aws.StringValue(input.TableName), 1)
+	if err := d.l.wait(ctx, est); err != nil {
+		return nil, err
+	}
+
+	in := *input
+	in.ReturnConsumedCapacity = returnCapacity(input.ReturnConsumedCapacity)
+	out, err := d.DynamoDBAPI.DeleteItemWithContext(ctx, &in, opts...)
+	if err == nil {
+		d.l.settle(est, []*db.ConsumedCapacity{out.ConsumedCapacity}, "", true)
+	}
+	return out, err
+}
+
+func (d *limitedDB) UpdateItemWithContext(
+	ctx aws.Context,
+	input *db.UpdateItemInput,
+	opts ...request.Option) (*db.UpdateItemOutput, error) {
+
+	est := charges{}
+	d.l.writeTo(est, aws.StringValue(input.TableName), 1)
+	if err := d.l.wait(ctx, est); err != nil {
+		return nil, err
+	}
+
+	in := *input
+	in.ReturnConsumedCapacity = returnCapacity(input.ReturnConsumedCapacity)
+	out, err := d.DynamoDBAPI.UpdateItemWithContext(ctx, &in, opts...)
+	if err == nil {
+		d.l.settle(est, []*db.ConsumedCapacity{out.ConsumedCapacity}, "", true)
+	}
+	return out, err
+}
+
+func (d *limitedDB) BatchWriteItemWithContext(
+	ctx aws.Context,
+	input *db.BatchWriteItemInput,
+	opts ...request.Option) (*db.BatchWriteItemOutput, error) {
+
+	est := charges{}
+	for table, reqs := range input.RequestItems {
+		d.l.writeTo(est, table, float64(len(reqs)))
+	}
+	if err := d.l.wait(ctx, est); err != nil {
+		return nil, err
+	}
+
+	in := *input
+	in.ReturnConsumedCapacity = returnCapacity(input.ReturnConsumedCapacity)
+	out, err := d.DynamoDBAPI.BatchWriteItemWithContext(ctx, &in, opts...)
+	if err == nil {
+		d.l.settle(est, out.ConsumedCapacity, "", true)
+	}
+	return out, err
+}
+
+func (d *limitedDB) TransactWriteItemsWithContext(
+	ctx aws.Context,
+	input *db.TransactWriteItemsInput,
+	opts ...request.Option) (*db.TransactWriteItemsOutput, error) {
+
+	// Transactional writes cost twice as much
+	est := charges{}
+	for _, ti := range input.TransactItems {
+		switch {
+		case ti.Put != nil:
+			d.l.writeTo(est, aws.StringValue(ti.Put.TableName), 2)
+		case ti.Delete != nil:
+			d.l.writeTo(est, aws.StringValue(ti.Delete.TableName), 2)
+		case ti.Update != nil:
+			d.l.writeTo(est, aws.StringValue(ti.Update.TableName), 2)
+		case ti.ConditionCheck != nil:
+			d.l.writeTo(est, aws.StringValue(ti.ConditionCheck.TableName), 2)
+		}
+	}
+	if err := d.l.wait(ctx, est); err != nil {
+		return nil, err
+	}
+
+	in := *input
+	in.ReturnConsumedCapacity = returnCapacity(input.ReturnConsumedCapacity)
+	out, err := d.DynamoDBAPI.TransactWriteItemsWithContext(ctx, &in, opts...)
+	if err == nil {
+		d.l.settle(est, out.ConsumedCapacity, "", true)
+	}
+	return out, err
+}
@@ -0,0 +1,154 @@
+package dynami
+
+import (
+	"context"
+	"time"
+
+	sc "github.com/robskie/dynami/schema"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	db "github.com/aws/aws-sdk-go/service/dynamodb"
+	dbiface "github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+)
+
+// tCapacityDB reports that each PutItem
+// request consumes the given write units.
+type tCapacityDB struct {
+	dbiface.DynamoDBAPI
+
+	units float64
+	mode  string
+}
+
+func (f *tCapacityDB) PutItemWithContext(
+	ctx aws.Context,
+	input *db.PutItemInput,
+	opts ...request.Option) (*db.PutItemOutput, error) {
+
+	f.mode = aws.StringValue(input.ReturnConsumedCapacity)
+	out, err := f.DynamoDBAPI.PutItemWithContext(ctx, input, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	out.ConsumedCapacity = &db.ConsumedCapacity{
+		TableName: input.TableName,
+		Table:     &db.Capacity{WriteCapacityUnits: aws.Float64(f.units)},
+	}
+	return out, nil
+}
+
+func (suite *DatabaseTestSuite) TestRateLimit() {
+	assert := suite.Assert()
+	require := suite.Require()
+
+	fake := &tCapacityDB{DynamoDBAPI: suite.db, units: 20}
+	c, err := NewClientFromAPI(fake, nil, WithRateLimit("Book", RateLimit{Write: 50}))
+	require.Nil(err)
+
+	// The first three puts use up the
+	// burst and the fourth one waits
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		book := tBook{Title: randString(10), Author: randString(10)}
+		err = c.PutItem("Book", book)
+		require.Nil(err)
+	}
+	assert.True(time.Since(start) >= 150*time.Millisecond)
+	assert.Equal(db.ReturnConsumedCapacityIndexes, fake.mode)
+
+	// Stop waiting when the context is done
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	book := tBook{Title: randString(10), Author: randString(10)}
+	err = c.PutItemWithContext(ctx, "Book", book)
+	assert.NotNil(err)
+
+	// Other tables are not limited
+	fake.mode = ""
+	err = c.PutItem("Quote", tQuote{Author: randString(10), Text: randString(10)})
+	require.Nil(err)
+	assert.Equal(db.ReturnConsumedCapacityIndexes, fake.mode)
+
+	// The caller's input is not modified
+	ldb := &limitedDB{DynamoDBAPI: fake, l: newLimiter(nil)}
+	input := &db.PutItemInput{
+		TableName:              aws.String("Quote"),
+		Item:                   dbitem{"Author": {S: aws.String("a")}, "Text": {S: aws.String("b")}},
+		ReturnConsumedCapacity: aws.String(db.ReturnConsumedCapacityNone),
+	}
+	_, err = ldb.PutItemWithContext(context.Background(), input)
+	require.Nil(err)
+	assert.Equal(db.ReturnConsumedCapacityIndexes, fake.mode)
+	assert.Equal(db.ReturnConsumedCapacityNone, aws.StringValue(input.ReturnConsumedCapacity))
+
+	// Invalid limits
+	_, err = NewClientFromAPI(fake, nil, WithRateLimit("", RateLimit{Read: 1}))
+	assert.NotNil(err)
+
+	_, err = NewClientFromAPI(fake, nil, WithIndexRateLimit("Book", "GenreIndex", RateLimit{Read: -1}))
+	assert.NotNil(err)
+}
+
+func (suite *DatabaseTestSuite) TestRateLimitCharges() {
+	assert := suite.Assert()
+
+	l := newLimiter(map[limitKey]RateLimit{
+		{"Book", ""}:           ThroughputLimit(sc.Throughput{Read: 10, Write: 20}, 0.5),
+		{"Book", "GenreIndex"}: {Read: 4, Write: 4},
+	})
+
+	table := l.read[limitKey{"Book", ""}]
+	index := l.read[limitKey{"Book", "GenreIndex"}]
+	assert.Equal(5.0, table.rate)
+	assert.Equal(10.0, l.write[limitKey{"Book", ""}].rate)
+
+	// Reads from an index without a
+	// limit are charged to its table
+	est := charges{}
+	l.readFrom(est, "Book", "GenreIndex", 1)
+	l.readFrom(est, "Book", "AuthorIndex", 1)
+	l.readFrom(est, "Quote", "", 1)
+	assert.Equal(charges{index: 1, table: 1}, est)
+
+	// Writes include the limited indexes
+	est = charges{}
+	l.writeTo(est, "Book", 3)
+	assert.Equal(charges{
+		l.write[limitKey{"Book", ""}]:           3,
+		l.write[limitKey{"Book", "GenreIndex"}]: 0,
+	}, est)
+
+	// Local secondary indexes are
+	// charged to their table
+	ch := l.consumed([]*db.ConsumedCapacity{
+		{
+			TableName: aws.String("Book"),
+			Table:     &db.Capacity{ReadCapacityUnits: aws.Float64(1)},
+			LocalSecondaryIndexes: map[string]*db.Capacity{
+				"TitleIndex": {ReadCapacityUnits: aws.Float64(0.5)},
+			},
+			GlobalSecondaryIndexes: map[string]*db.Capacity{
+				"GenreIndex":  {ReadCapacityUnits: aws.Float64(2)},
+				"AuthorIndex": {ReadCapacityUnits: aws.Float64(3)},
+			},
+		},
+		{
+			TableName:     aws.String("Book"),
+			CapacityUnits: aws.Float64(1),
+		},
+	}, "", false)
+	assert.Equal(charges{table: 2.5, index: 2}, ch)
+
+	// If only the total is known, reads from
+	// an index are only charged to the index
+	ch = l.consumed([]*db.ConsumedCapacity{
+		{
+			TableName:     aws.String("Book"),
+			CapacityUnits: aws.Float64(3),
+		},
+	}, "GenreIndex", false)
+	assert.Equal(charges{index: 3}, ch)
+}
@@ -0,0 +1,422 @@
+package dynami
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	db "github.com/aws/aws-sdk-go/service/dynamodb"
+	dbattribute "github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+)
+
+var (
+	marshalerType       = reflect.TypeOf((*dbattribute.Marshaler)(nil)).Elem()
+	textMarshalerType   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// implements returns true if t or a pointer to t implements iface.
+func implements(t, iface reflect.Type) bool {
+	return t.Implements(iface) || reflect.PtrTo(t).Implements(iface)
+}
+
+// isTextKeyType returns true if a key of type t is
+// marshaled into a string using its MarshalText method.
+// This is the case if t implements encoding.TextMarshaler
+// but not dynamodbattribute.Marshaler.
+func isTextKeyType(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	return implements(t, textMarshalerType) && !implements(t, marshalerType)
+}
+
+// textKeyCache maps a struct type to
+// the names returned by textKeys.
+var textKeyCache sync.Map
+
+// textKeys returns the attribute names of the key
+// fields of struct type t that are text key types.
+func textKeys(t reflect.Type) []string {
+	if names, ok := textKeyCache.Load(t); ok {
+		return names.([]string)
+	}
+
+	var names []string
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" || !isKeyField(f) || !isTextKeyType(f.Type) {
+			continue
+		}
+
+		names = append(names, fieldAttrName(f))
+	}
+
+	textKeyCache.Store(t, names)
+	return names
+}
+
+// keyNameCache maps a struct type to
+// the names returned by keyNames.
+var keyNameCache sync.Map
+
+// keyNames returns the attribute names of the key
+// fields of struct type t, including index keys.
+func keyNames(t reflect.Type) []string {
+	if names, ok := keyNameCache.Load(t); ok {
+		return names.([]string)
+	}
+
+	var names []string
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath == "" && isKeyField(f) {
+			names = append(names, fieldAttrName(f))
+		}
+	}
+
+	keyNameCache.Store(t, names)
+	return names
+}
+
+// fieldAttrName returns the attribute name of struct field f
+// taken from its dynamodbav or json tag, if present.
+func fieldAttrName(f reflect.StructField) string {
+	nameTag := f.Tag.Get("dynamodbav")
+	if nameTag == "" {
+		nameTag = f.Tag.Get("json")
+	}
+	if tags := strings.Split(nameTag, ","); tags[0] != "" {
+		return tags[0]
+	}
+
+	return f.Name
+}
+
+// isSetField returns true if f is tagged with `dbset:"true"`
+// or has a set option in its dynamodbav tag.
+func isSetField(f reflect.StructField) bool {
+	if tag, ok := f.Tag.Lookup("dbset"); ok && (tag == "" || tag == "true") {
+		return true
+	}
+
+	opts := strings.Split(f.Tag.Get("dynamodbav"), ",")
+	for _, opt := range opts[1:] {
+		switch opt {
+		case "stringset", "numberset", "binaryset":
+			return true
+		}
+	}
+
+	return false
+}
+
+// setField is a struct field that is either marshaled
+// into a set or is a struct that may contain set fields.
+type setField struct {
+	index    int
+	name     string
+	set      bool
+	embedded bool
+}
+
+// setFieldCache maps a struct type to
+// the fields returned by setFields.
+var setFieldCache sync.Map
+
+// setFields returns the set fields of struct type t
+// and its struct fields that may contain set fields.
+func setFields(t reflect.Type) []setField {
+	if fields, ok := setFieldCache.Load(t); ok {
+		return fields.([]setField)
+	}
+
+	var fields []setField
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" || f.Tag.Get("dynamodbav") == "-" {
+			continue
+		}
+
+		ft := f.Type
+		for ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+
+		name := fieldAttrName(f)
+		switch {
+		case isSetField(f):
+			fields = append(fields, setField{index: i, name: name, set: true})
+		case ft.Kind() == reflect.Struct && !implements(ft, marshalerType):
+			embedded := f.Anonymous && name == f.Name
+			fields = append(fields, setField{index: i, name: name, embedded: embedded})
+		}
+	}
+
+	setFieldCache.Store(t, fields)
+	return fields
+}
+
+// marshalSets replaces the attributes of the set fields
+// of struct val, and of its nested structs, with sets.
+func marshalSets(val reflect.Value, item dbitem) error {
+	for _, f := range setFields(val.Type()) {
+		field := val.Field(f.index)
+		for field.Kind() == reflect.Ptr && !field.IsNil() {
+			field = field.Elem()
+		}
+
+		av, ok := item[f.name]
+		switch {
+		case field.Kind() == reflect.Ptr:
+			continue
+		case f.set && ok:
+			set, err := marshalSet(field)
+			if err != nil {
+				return fmt.Errorf("field (%v) is invalid (%w)", f.name, err)
+			}
+			item[f.name] = set
+		case f.embedded:
+			if err := marshalSets(field, item); err != nil {
+				return err
+			}
+		case ok && av.M != nil:
+			if err := marshalSets(field, av.M); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// isKeyField returns true if f is tagged as the
+// hash or range key of a table or an index.
+func isKeyField(f reflect.StructField) bool {
+	if f.Tag.Get("dbkey") != "" {
+		return true
+	}
+
+	parts := strings.Split(f.Tag.Get("dbindex"), ",")
+	for i := 0; i+1 < len(parts); i += 2 {
+		if parts[i] == "hash" || parts[i] == "range" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// textMarshaler returns the text marshaler of v. This
+// returns false if v is a nil pointer or doesn't
+// implement encoding.TextMarshaler.
+func textMarshaler(v reflect.Value) (encoding.TextMarshaler, bool) {
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil, false
+		} else if m, ok := v.Interface().(encoding.TextMarshaler); ok {
+			return m, true
+		}
+		v = v.Elem()
+	}
+
+	if m, ok := v.Interface().(encoding.TextMarshaler); ok {
+		return m, true
+	}
+
+	// Copy v so that its pointer methods can be called
+	p := reflect.New(v.Type())
+	p.Elem().Set(v)
+	m, ok := p.Interface().(encoding.TextMarshaler)
+	return m, ok
+}
+
+// marshalText marshals v into a string attribute.
+// Empty text is marshaled into a null attribute.
+func marshalText(v reflect.Value) (*db.AttributeValue, error) {
+	m, ok := textMarshaler(v)
+	if !ok {
+		return &db.AttributeValue{NULL: aws.Bool(true)}, nil
+	}
+
+	text, err := m.MarshalText()
+	if err != nil {
+		return nil, err
+	} else if len(text) == 0 {
+		return &db.AttributeValue{NULL: aws.Bool(true)}, nil
+	}
+
+	return &db.AttributeValue{S: aws.String(string(text))}, nil
+}
+
+// marshalItem marshals a map or struct item. Unlike
+// dynamodbattribute.MarshalMap, this marshals key fields
+// whose type implements encoding.TextMarshaler, but not
+// dynamodbattribute.Marshaler, into string attributes,
+// and set fields, including those of nested structs,
+// into sets without duplicates.
+func marshalItem(item interface{}) (dbitem, error) {
+	return encodeItem(dbattribute.NewEncoder(), item)
+}
+
+// marshalWriteItem marshals an item that is about to be
+// written. Its empty values are handled according to mode,
+// except for the empty key attributes of struct items which
+// are always omitted.
+func marshalWriteItem(item interface{}, mode EmptyValues) (dbitem, error) {
+	enc := dbattribute.NewEncoder(func(e *dbattribute.Encoder) {
+		e.NullEmptyString = mode == OmitEmpty
+	})
+
+	av, err := encodeItem(enc, item)
+	if err != nil {
+		return nil, err
+	}
+	av = removeEmptyAttr(av, mode)
+
+	if t := reflect.Indirect(reflect.ValueOf(item)).Type(); t.Kind() == reflect.Struct {
+		for _, name := range keyNames(t) {
+			if v, ok := av[name]; ok && !removeEmptyValue(v, OmitEmpty) {
+				delete(av, name)
+			}
+		}
+	}
+
+	return av, nil
+}
+
+// encodeItem is the same as marshalItem
+// but uses the given encoder.
+func encodeItem(enc *dbattribute.Encoder, item interface{}) (dbitem, error) {
+	val := reflect.Indirect(reflect.ValueOf(item))
+	m, err := enc.Encode(val.Interface())
+	if err != nil {
+		return nil, err
+	}
+
+	av := dbitem{}
+	if m != nil && m.M != nil {
+		av = m.M
+	}
+	if val.Kind() != reflect.Struct {
+		return av, nil
+	}
+
+	if err := marshalSets(val, av); err != nil {
+		return nil, err
+	}
+
+	for _, name := range textKeys(val.Type()) {
+		field, err := valueByName(val, name)
+		if err != nil {
+			continue
+		}
+
+		if av[name], err = marshalText(field); err != nil {
+			return nil, fmt.Errorf("key (%v) is invalid (%w)", name, err)
+		}
+	}
+
+	return av, nil
+}
+
+// unmarshalItem unmarshals item into out
+// which is marshaled using marshalItem.
+func unmarshalItem(item dbitem, out interface{}) error {
+	val := reflect.ValueOf(out)
+	if val.Kind() != reflect.Ptr || val.IsNil() || val.Elem().Kind() != reflect.Struct {
+		return dbattribute.UnmarshalMap(item, out)
+	}
+
+	val = val.Elem()
+	names := textKeys(val.Type())
+	if len(names) == 0 {
+		return dbattribute.UnmarshalMap(item, out)
+	}
+
+	rest := dbitem{}
+	texts := map[string]string{}
+	for name, av := range item {
+		rest[name] = av
+	}
+	for _, name := range names {
+		if av := item[name]; av != nil && av.S != nil {
+			texts[name] = *av.S
+			delete(rest, name)
+		}
+	}
+
+	if err := dbattribute.UnmarshalMap(rest, out); err != nil {
+		return err
+	}
+
+	for name, text := range texts {
+		field, err := valueByName(val, name)
+		if err != nil {
+			continue
+		}
+
+		for field.Kind() == reflect.Ptr {
+			if field.IsNil() {
+				field.Set(reflect.New(field.Type().Elem()))
+			}
+			field = field.Elem()
+		}
+
+		if !implements(field.Type(), textUnmarshalerType) {
+			return fmt.Errorf("key (%v) does not implement encoding.TextUnmarshaler", name)
+		}
+
+		u := field.Addr().Interface().(encoding.TextUnmarshaler)
+		if err := u.UnmarshalText([]byte(text)); err != nil {
+			return fmt.Errorf("key (%v) is invalid (%w)", name, err)
+		}
+	}
+
+	return nil
+}
+
+// keyValue returns the value of a key condition.
+// Text key types are converted to strings.
+func keyValue(value interface{}) (interface{}, error) {
+	v := reflect.ValueOf(value)
+	if !v.IsValid() || !isTextKeyType(v.Type()) {
+		return value, nil
+	}
+
+	av, err := marshalText(v)
+	if err != nil {
+		return nil, err
+	}
+
+	return aws.StringValue(av.S), nil
+}
+
+// marshalValue marshals an expression value the same way
+// as item attributes, so types that implement
+// dynamodbattribute.Marshaler use their own marshaler. Byte
+// slices and arrays are always marshaled into binary
+// attributes the same way as key fields, and empty sets
+// are marshaled into nulls.
+func marshalValue(value interface{}) (*db.AttributeValue, error) {
+	v := reflect.ValueOf(value)
+	if v.IsValid() &&
+		(v.Kind() == reflect.Slice || v.Kind() == reflect.Array) &&
+		v.Type().Elem().Kind() == reflect.Uint8 &&
+		!implements(v.Type(), marshalerType) {
+
+		b := make([]byte, v.Len())
+		reflect.Copy(reflect.ValueOf(b), v)
+		return &db.AttributeValue{B: b}, nil
+	}
+
+	av, err := dbattribute.Marshal(value)
+	if err == nil && isEmptySet(av) {
+		av = &db.AttributeValue{NULL: aws.Bool(true)}
+	}
+	return av, err
+}
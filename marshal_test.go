@@ -0,0 +1,230 @@
+package dynami
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	sc "github.com/robskie/dynami/schema"
+
+	"github.com/aws/aws-sdk-go/aws"
+	db "github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// tUUID is a text key type.
+type tUUID [16]byte
+
+func (u tUUID) MarshalText() ([]byte, error) {
+	return []byte(hex.EncodeToString(u[:])), nil
+}
+
+func (u *tUUID) UnmarshalText(text []byte) error {
+	b, err := hex.DecodeString(string(text))
+	if err != nil {
+		return err
+	} else if len(b) != len(u) {
+		return fmt.Errorf("invalid uuid length")
+	}
+
+	copy(u[:], b)
+	return nil
+}
+
+// tCode is a key type with a custom marshaler.
+type tCode struct {
+	Prefix string
+	Number int
+}
+
+func (c tCode) MarshalDynamoDBAttributeValue(av *db.AttributeValue) error {
+	if c.Prefix == "" {
+		av.NULL = aws.Bool(true)
+		return nil
+	}
+
+	av.S = aws.String(fmt.Sprintf("%v-%v", c.Prefix, c.Number))
+	return nil
+}
+
+func (c *tCode) UnmarshalDynamoDBAttributeValue(av *db.AttributeValue) error {
+	if av.S == nil {
+		return nil
+	}
+
+	parts := strings.SplitN(*av.S, "-", 2)
+	if len(parts) != 2 {
+		return fmt.Errorf("invalid code")
+	}
+
+	c.Prefix = parts[0]
+	_, err := fmt.Sscan(parts[1], &c.Number)
+	return err
+}
+
+type tKeyed struct {
+	ID    tUUID  `dbkey:"hash"`
+	Seq   *int   `dbkey:"range"`
+	Code  tCode  `dbindex:"hash,CodeIndex" dbtype:"string"`
+	Value string `dbindex:"project,CodeIndex"`
+}
+
+func (suite *DatabaseTestSuite) TestMarshaledKeys() {
+	assert := suite.Assert()
+	require := suite.Require()
+
+	table := sc.NewTable("KeyedTable", tKeyed{}, map[string]sc.Throughput{
+		"KeyedTable": {Read: 10, Write: 10},
+		"CodeIndex":  {Read: 10, Write: 10},
+	})
+	assert.Contains(table.Attributes, sc.Attribute{"ID", sc.StringType})
+	assert.Contains(table.Attributes, sc.Attribute{"Seq", sc.NumberType})
+	assert.Contains(table.Attributes, sc.Attribute{"Code", sc.StringType})
+
+	c := suite.client
+	err := c.CreateTable(table)
+	require.Nil(err)
+
+	// Pointer keys are not empty even if zero
+	item := tKeyed{
+		ID:    tUUID{1, 2, 3, 4},
+		Seq:   aws.Int(0),
+		Code:  tCode{"A", 42},
+		Value: "value",
+	}
+	err = c.PutItem("KeyedTable", item)
+	require.Nil(err)
+
+	resp, err := suite.db.GetItem(&db.GetItemInput{
+		TableName: aws.String("KeyedTable"),
+		Key: map[string]*db.AttributeValue{
+			"ID":  {S: aws.String("01020304000000000000000000000000")},
+			"Seq": {N: aws.String("0")},
+		},
+	})
+	require.Nil(err)
+	assert.Equal("A-42", aws.StringValue(resp.Item["Code"].S))
+
+	fetched := tKeyed{ID: item.ID, Seq: aws.Int(0)}
+	err = c.GetItem("KeyedTable", &fetched)
+	require.Nil(err)
+	assert.Equal(item, fetched)
+
+	// Get using the index key
+	fetched = tKeyed{Code: item.Code}
+	err = c.GetItem("KeyedTable", &fetched)
+	require.Nil(err)
+	assert.Equal(item.ID, fetched.ID)
+
+	it := c.Query("KeyedTable").
+		HashFilter("ID", item.ID).
+		RangeFilter("Seq = :seq", 0).
+		Run()
+	require.True(it.HasNext())
+	fetched = tKeyed{}
+	require.Nil(it.Next(&fetched))
+	assert.Equal(item, fetched)
+
+	it = c.Query("KeyedTable").
+		Index("CodeIndex").
+		HashFilter("Code", item.Code).
+		Run()
+	require.True(it.HasNext())
+	fetched = tKeyed{}
+	require.Nil(it.Next(&fetched))
+	assert.Equal(item.ID, fetched.ID)
+
+	// Nil pointer keys are empty
+	err = c.DeleteItem("KeyedTable", tKeyed{ID: item.ID})
+	assert.NotNil(err)
+
+	err = c.DeleteItem("KeyedTable", item)
+	require.Nil(err)
+}
+
+type tBlob struct {
+	Hash  []byte  `dbkey:"hash"`
+	Range [4]byte `dbkey:"range"`
+	Data  string
+}
+
+func (suite *DatabaseTestSuite) TestBinaryKeys() {
+	assert := suite.Assert()
+	require := suite.Require()
+
+	table := sc.NewTable("BlobTable", tBlob{}, map[string]sc.Throughput{
+		"BlobTable": {Read: 10, Write: 10},
+	})
+	table.StreamEnabled = true
+	assert.Contains(table.Attributes, sc.Attribute{"Hash", sc.BinaryType})
+	assert.Contains(table.Attributes, sc.Attribute{"Range", sc.BinaryType})
+
+	c := suite.client
+	err := c.CreateTable(table)
+	require.Nil(err)
+
+	described, err := c.DescribeTable("BlobTable")
+	require.Nil(err)
+	assert.Contains(described.Attributes, sc.Attribute{"Hash", sc.BinaryType})
+
+	hash := []byte{0, 1, 2}
+	blobs := []tBlob{
+		{Hash: hash, Range: [4]byte{1}, Data: "a"},
+		{Hash: hash, Range: [4]byte{2}, Data: "b"},
+		{Hash: hash, Range: [4]byte{3}, Data: "c"},
+		{Hash: hash, Range: [4]byte{2}, Data: "d"},
+	}
+	err = c.BatchPut("BlobTable", blobs).Run()
+	require.Nil(err)
+
+	// The last duplicate is written
+	fetched := tBlob{Hash: hash, Range: [4]byte{2}}
+	err = c.GetItem("BlobTable", &fetched)
+	require.Nil(err)
+	assert.Equal("d", fetched.Data)
+
+	keys := []tBlob{
+		{Hash: hash, Range: [4]byte{1}},
+		{Hash: hash, Range: [4]byte{9}},
+		{Hash: hash, Range: [4]byte{3}},
+	}
+	err = c.BatchGet("BlobTable", keys).Run()
+	require.NotNil(err)
+	berr, ok := err.(BatchError)
+	require.True(ok)
+	require.Len(berr["BlobTable"], 1)
+	assert.Equal(ErrNoSuchItem, berr["BlobTable"][1])
+	assert.Equal("a", keys[0].Data)
+	assert.Equal("c", keys[2].Data)
+
+	it := c.Query("BlobTable").
+		HashFilter("Hash", hash).
+		RangeFilter("Range BETWEEN :lo AND :hi", [4]byte{2}, [4]byte{3}).
+		Run()
+
+	var data []string
+	for it.HasNext() {
+		var b tBlob
+		require.Nil(it.Next(&b))
+		data = append(data, b.Data)
+	}
+	require.Nil(it.Err())
+	assert.Equal([]string{"d", "c"}, data)
+
+	it = c.Query("BlobTable").
+		HashFilter("Hash", hash).
+		RangeFilter("begins_with(Range, :prefix)", []byte{1}).
+		Run()
+	require.True(it.HasNext())
+	fetched = tBlob{}
+	require.Nil(it.Next(&fetched))
+	assert.Equal("a", fetched.Data)
+	assert.False(it.HasNext())
+
+	stream, err := c.GetStream("BlobTable")
+	require.Nil(err)
+	require.True(stream.HasNext())
+	var record tBlob
+	_, err = stream.Next(&record)
+	require.Nil(err)
+	assert.Equal(hash, record.Hash)
+}
@@ -0,0 +1,370 @@
+package dynami
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodbstreams"
+)
+
+// DefaultLatencyBuckets are the upper bounds, in seconds,
+// of the latency histogram buckets used by NewMetrics.
+var DefaultLatencyBuckets = []float64{
+	0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10,
+}
+
+// Metrics records the requests sent by one or more clients.
+// It keeps a latency histogram and the number of errors,
+// throttled requests, SDK retries and batch retry rounds of
+// each operation and table, and the lag of each table's
+// stream. Add it to a client using WithMetrics.
+//
+// Metrics implements expvar.Var, so it can be published using
+// expvar.Publish, and http.Handler, which serves the metrics in
+// the Prometheus text exposition format.
+type Metrics struct {
+	mu sync.Mutex
+
+	buckets []float64
+
+	ops    map[opKey]*opMetrics
+	errors map[errKey]int64
+
+	// lags maps a table to the
+	// stream lag of each shard.
+	lags map[string]map[string]time.Duration
+
+	// now returns the current time and
+	// is replaced in tests.
+	now func() time.Time
+}
+
+// opKey identifies the requests of an operation on a table.
+type opKey struct {
+	op    string
+	table string
+}
+
+type errKey struct {
+	opKey
+	code string
+}
+
+// opMetrics contains the metrics of an operation on a table.
+type opMetrics struct {
+	counts []int64
+	count  int64
+	sum    float64
+
+	throttled    int64
+	retries      int64
+	batchRetries int64
+}
+
+// NewMetrics creates new metrics with
+// the given latency buckets in seconds.
+// DefaultLatencyBuckets is used if no
+// buckets are given.
+func NewMetrics(buckets ...float64) *Metrics {
+	if len(buckets) == 0 {
+		buckets = DefaultLatencyBuckets
+	}
+
+	b := append([]float64(nil), buckets...)
+	sort.Float64s(b)
+
+	return &Metrics{
+		buckets: b,
+		ops:     map[opKey]*opMetrics{},
+		errors:  map[errKey]int64{},
+		lags:    map[string]map[string]time.Duration{},
+		now:     time.Now,
+	}
+}
+
+// Middleware returns the middleware that records
+// the requests that pass through it. This is added
+// to a client by WithMetrics.
+func (m *Metrics) Middleware() Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, op *Operation) error {
+			err := next(ctx, op)
+			m.record(ctx, op, err)
+			return err
+		}
+	}
+}
+
+func (m *Metrics) record(ctx context.Context, op *Operation, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := opKey{op.Name, op.Table}
+	om := m.ops[key]
+	if om == nil {
+		om = &opMetrics{counts: make([]int64, len(m.buckets))}
+		m.ops[key] = om
+	}
+
+	secs := op.Duration.Seconds()
+	for i, b := range m.buckets {
+		if secs <= b {
+			om.counts[i]++
+		}
+	}
+	om.count++
+	om.sum += secs
+	om.retries += int64(op.Retries)
+	if op.Attempt > 0 {
+		om.batchRetries++
+	}
+
+	if err != nil {
+		code := awsErrCode(err)
+		if code == "" {
+			code = "Unknown"
+		}
+		m.errors[errKey{key, code}]++
+
+		if request.IsErrorThrottle(err) {
+			om.throttled++
+		}
+	}
+
+	if out, ok := op.Output.(*dynamodbstreams.GetRecordsOutput); ok && op.Table != "" {
+		m.recordLag(op.Table, streamShard(ctx), out.Records)
+	}
+}
+
+// recordLag sets the stream lag of a table's shard to the
+// age of the newest record. The lag is zero if there are no
+// records since the shard has been read to its end.
+func (m *Metrics) recordLag(table, shard string, records []*dynamodbstreams.Record) {
+	now := m.now()
+
+	var lag time.Duration
+	if n := len(records); n > 0 {
+		last := records[n-1]
+		if last.Dynamodb != nil && last.Dynamodb.ApproximateCreationDateTime != nil {
+			lag = now.Sub(aws.TimeValue(last.Dynamodb.ApproximateCreationDateTime))
+		}
+	}
+	if lag < 0 {
+		lag = 0
+	}
+
+	if m.lags[table] == nil {
+		m.lags[table] = map[string]time.Duration{}
+	}
+	m.lags[table][shard] = lag
+}
+
+// lag returns the stream lag of a table
+// which is the maximum lag of its shards.
+func (m *Metrics) lag(table string) time.Duration {
+	var max time.Duration
+	for _, lag := range m.lags[table] {
+		if lag > max {
+			max = lag
+		}
+	}
+
+	return max
+}
+
+// String returns the metrics in JSON. This
+// implements the expvar.Var interface.
+func (m *Metrics) String() string {
+	type latency struct {
+		Buckets map[string]int64 `json:"buckets"`
+		Count   int64            `json:"count"`
+		Sum     float64          `json:"sum"`
+	}
+	type operation struct {
+		Latency      latency          `json:"latency_seconds"`
+		Errors       map[string]int64 `json:"errors"`
+		Throttled    int64            `json:"throttled"`
+		Retries      int64            `json:"retries"`
+		BatchRetries int64            `json:"batch_retries"`
+	}
+	type vars struct {
+		Tables    map[string]map[string]*operation `json:"tables"`
+		StreamLag map[string]float64               `json:"stream_lag_seconds"`
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	v := vars{
+		Tables:    map[string]map[string]*operation{},
+		StreamLag: map[string]float64{},
+	}
+	get := func(key opKey) *operation {
+		ops := v.Tables[key.table]
+		if ops == nil {
+			ops = map[string]*operation{}
+			v.Tables[key.table] = ops
+		}
+
+		o := ops[key.op]
+		if o == nil {
+			o = &operation{
+				Latency: latency{Buckets: map[string]int64{}},
+				Errors:  map[string]int64{},
+			}
+			ops[key.op] = o
+		}
+		return o
+	}
+
+	for key, om := range m.ops {
+		o := get(key)
+		for i, b := range m.buckets {
+			o.Latency.Buckets[formatFloat(b)] = om.counts[i]
+		}
+		o.Latency.Count = om.count
+		o.Latency.Sum = om.sum
+		o.Throttled = om.throttled
+		o.Retries = om.retries
+		o.BatchRetries = om.batchRetries
+	}
+	for key, n := range m.errors {
+		get(key.opKey).Errors[key.code] = n
+	}
+	for table := range m.lags {
+		v.StreamLag[table] = m.lag(table).Seconds()
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "{}"
+	}
+	return string(b)
+}
+
+// ServeHTTP writes the metrics in the Prometheus
+// text exposition format.
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.Write(m.prometheus())
+}
+
+func (m *Metrics) prometheus() []byte {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	keys := make([]opKey, 0, len(m.ops))
+	for key := range m.ops {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].table != keys[j].table {
+			return keys[i].table < keys[j].table
+		}
+		return keys[i].op < keys[j].op
+	})
+
+	buf := &bytes.Buffer{}
+	header := func(name, typ, help string) {
+		fmt.Fprintf(buf, "# HELP %v %v\n# TYPE %v %v\n", name, help, name, typ)
+	}
+
+	name := "dynami_request_duration_seconds"
+	header(name, "histogram", "Duration of DynamoDB requests.")
+	for _, key := range keys {
+		om := m.ops[key]
+		labels := opLabels(key)
+		for i, b := range m.buckets {
+			fmt.Fprintf(buf, "%v_bucket{%v,le=\"%v\"} %v\n", name, labels, formatFloat(b), om.counts[i])
+		}
+		fmt.Fprintf(buf, "%v_bucket{%v,le=\"+Inf\"} %v\n", name, labels, om.count)
+		fmt.Fprintf(buf, "%v_sum{%v} %v\n", name, labels, formatFloat(om.sum))
+		fmt.Fprintf(buf, "%v_count{%v} %v\n", name, labels, om.count)
+	}
+
+	ekeys := make([]errKey, 0, len(m.errors))
+	for key := range m.errors {
+		ekeys = append(ekeys, key)
+	}
+	sort.Slice(ekeys, func(i, j int) bool {
+		a, b := ekeys[i], ekeys[j]
+		if a.table != b.table {
+			return a.table < b.table
+		} else if a.op != b.op {
+			return a.op < b.op
+		}
+		return a.code < b.code
+	})
+
+	name = "dynami_request_errors_total"
+	header(name, "counter", "Number of failed DynamoDB requests by error code.")
+	for _, key := range ekeys {
+		fmt.Fprintf(buf, "%v{%v,code=\"%v\"} %v\n", name, opLabels(key.opKey), escapeLabel(key.code), m.errors[key])
+	}
+
+	counters := []struct {
+		name  string
+		help  string
+		value func(om *opMetrics) int64
+	}{
+		{
+			"dynami_throttled_requests_total",
+			"Number of DynamoDB requests that failed due to throttling.",
+			func(om *opMetrics) int64 { return om.throttled },
+		},
+		{
+			"dynami_request_retries_total",
+			"Number of times the SDK retried DynamoDB requests.",
+			func(om *opMetrics) int64 { return om.retries },
+		},
+		{
+			"dynami_batch_retries_total",
+			"Number of requests that retried the unprocessed items of batch operations.",
+			func(om *opMetrics) int64 { return om.batchRetries },
+		},
+	}
+	for _, c := range counters {
+		header(c.name, "counter", c.help)
+		for _, key := range keys {
+			fmt.Fprintf(buf, "%v{%v} %v\n", c.name, opLabels(key), c.value(m.ops[key]))
+		}
+	}
+
+	tables := make([]string, 0, len(m.lags))
+	for table := range m.lags {
+		tables = append(tables, table)
+	}
+	sort.Strings(tables)
+
+	name = "dynami_stream_lag_seconds"
+	header(name, "gauge", "Age of the newest stream record read from each table.")
+	for _, table := range tables {
+		fmt.Fprintf(buf, "%v{table=\"%v\"} %v\n", name, escapeLabel(table), formatFloat(m.lag(table).Seconds()))
+	}
+
+	return buf.Bytes()
+}
+
+func opLabels(key opKey) string {
+	return fmt.Sprintf("operation=\"%v\",table=\"%v\"", escapeLabel(key.op), escapeLabel(key.table))
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(s string) string {
+	return labelEscaper.Replace(s)
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
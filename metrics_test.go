@@ -0,0 +1,120 @@
+package dynami
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	db "github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodbstreams"
+)
+
+func (suite *DatabaseTestSuite) TestMetrics() {
+	assert := suite.Assert()
+	require := suite.Require()
+
+	m := NewMetrics(0.5, 1)
+	c, err := NewClientFromAPI(suite.db, nil, WithMetrics(m))
+	require.Nil(err)
+
+	book := tBook{Title: "Carmilla", Author: "Sheridan Le Fanu", Genre: "Horror"}
+	err = c.PutItem("Book", book)
+	require.Nil(err)
+
+	err = c.PutItem("NoSuchTable", book)
+	require.NotNil(err)
+
+	// Throttle every GetItem request
+	c.Use(func(next Handler) Handler {
+		return func(ctx context.Context, op *Operation) error {
+			if op.Name == "GetItem" {
+				return awserr.New(db.ErrCodeProvisionedThroughputExceededException, "throttled", nil)
+			}
+			return next(ctx, op)
+		}
+	})
+
+	fetched := tBook{Title: book.Title, Author: book.Author}
+	err = c.GetItem("Book", &fetched)
+	require.NotNil(err)
+
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	text := rec.Body.String()
+
+	assert.Contains(text, "# TYPE dynami_request_duration_seconds histogram")
+	assert.Contains(text, `dynami_request_duration_seconds_bucket{operation="PutItem",table="Book",le="+Inf"} 1`)
+	assert.Contains(text, `dynami_request_duration_seconds_count{operation="PutItem",table="Book"} 1`)
+	assert.Contains(text, `dynami_request_errors_total{operation="PutItem",table="NoSuchTable",code="ResourceNotFoundException"} 1`)
+	assert.Contains(text, `dynami_throttled_requests_total{operation="GetItem",table="Book"} 1`)
+	assert.Contains(text, `dynami_throttled_requests_total{operation="PutItem",table="Book"} 0`)
+
+	var vars map[string]interface{}
+	err = json.Unmarshal([]byte(m.String()), &vars)
+	require.Nil(err)
+	assert.Contains(vars, "tables")
+	assert.Contains(vars, "stream_lag_seconds")
+
+	_, err = NewClientFromAPI(suite.db, nil, WithMetrics(nil))
+	assert.NotNil(err)
+}
+
+func TestMetricsLabels(t *testing.T) {
+	m := NewMetrics()
+	m.record(context.Background(), &Operation{Name: "Query", Table: `a"b\c`}, nil)
+
+	text := string(m.prometheus())
+	assert.Contains(t, text, `dynami_request_duration_seconds_count{operation="Query",table="a\"b\\c"} 1`)
+	assert.Contains(t, text, `le="0.005"`)
+}
+
+func (suite *DatabaseTestSuite) TestMetricsBatchRetries() {
+	assert := suite.Assert()
+	require := suite.Require()
+
+	var books []tBook
+	for i := 0; i < 5; i++ {
+		books = append(books, tBook{Title: randString(20), Author: randString(15)})
+	}
+
+	// Every retry round is counted even if
+	// the previous round made some progress
+	m := NewMetrics()
+	fake := &tThrottledDB{DynamoDBAPI: suite.db, throttles: 3, partial: true}
+	c, err := NewClientFromAPI(fake, nil, WithMetrics(m), WithBatchRetry(RetryPolicy{MaxAttempts: 3}))
+	require.Nil(err)
+
+	err = c.BatchPut("Book", books).Run()
+	require.Nil(err)
+	require.Equal(4, fake.requests)
+
+	text := string(m.prometheus())
+	assert.Contains(text, `dynami_batch_retries_total{operation="BatchWriteItem",table="Book"} 3`)
+}
+
+func TestMetricsStreamLag(t *testing.T) {
+	now := time.Now()
+	m := NewMetrics()
+	m.now = func() time.Time { return now }
+
+	records := []*dynamodbstreams.Record{{
+		Dynamodb: &dynamodbstreams.StreamRecord{
+			ApproximateCreationDateTime: aws.Time(now.Add(-time.Minute)),
+		},
+	}}
+
+	// An empty page of one shard doesn't
+	// hide the lag of the other shards
+	m.recordLag("Table", "shard1", records)
+	m.recordLag("Table", "shard2", nil)
+	assert.Equal(t, time.Minute, m.lag("Table"))
+
+	m.recordLag("Table", "shard1", nil)
+	assert.Zero(t, m.lag("Table"))
+}
@@ -0,0 +1,610 @@
+package dynami
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	db "github.com/aws/aws-sdk-go/service/dynamodb"
+	dbiface "github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/aws/aws-sdk-go/service/dynamodbstreams"
+	"github.com/aws/aws-sdk-go/service/dynamodbstreams/dynamodbstreamsiface"
+)
+
+// Operation is a DynamoDB or DynamoDB Streams request
+// sent by a client. It is passed to each middleware.
+type Operation struct {
+	// Name is the name of the API operation, eg. "PutItem".
+	Name string
+
+	// Table and Index are the table and index of the request.
+	// Table is empty if the request has no table or if it has
+	// multiple tables, eg. a batch operation on two tables.
+	Table string
+	Index string
+
+	// Input is the input of the request, eg. a
+	// *dynamodb.PutItemInput. Middleware may modify
+	// or replace it as long as its type is kept.
+	Input interface{}
+
+	// Output is the output of the request. It's nil
+	// until the request succeeds. Waiters have no output.
+	Output interface{}
+
+	// Attempt is the number of times in a row that the
+	// unprocessed items of a batch operation have been
+	// retried. It's zero for other requests.
+	Attempt int
+
+	// Retries is the number of times the SDK retried
+	// the request, and Duration is the time spent
+	// sending it. These are set once the request is
+	// sent.
+	Retries  int
+	Duration time.Duration
+}
+
+// Handler sends an operation.
+type Handler func(ctx context.Context, op *Operation) error
+
+// Middleware wraps a handler. It can inspect or modify the
+// operation before and after calling next, or return an
+// error without calling next, eg. to inject faults.
+type Middleware func(next Handler) Handler
+
+// Use adds middleware to the client. Every request sent by
+// the client passes through its middleware in the order that
+// they were added, ie. the first one is the outermost. This
+// should be called before the client is used.
+func (c *Client) Use(mw ...Middleware) {
+	c.chain.add(mw...)
+}
+
+// chain contains the middleware of a client.
+type chain struct {
+	mu  sync.RWMutex
+	mws []Middleware
+}
+
+func (ch *chain) add(mw ...Middleware) {
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+
+	ch.mws = append(ch.mws, mw...)
+}
+
+// run sends op through the middleware and then to send.
+func (ch *chain) run(
+	ctx context.Context,
+	op *Operation,
+	send func(ctx context.Context, op *Operation) error) error {
+
+	h := func(ctx context.Context, op *Operation) error {
+		start := time.Now()
+		err := send(ctx, op)
+		op.Duration = time.Since(start)
+		return err
+	}
+
+	ch.mu.RLock()
+	for i := len(ch.mws) - 1; i >= 0; i-- {
+		h = ch.mws[i](h)
+	}
+	ch.mu.RUnlock()
+
+	return h(ctx, op)
+}
+
+// newOperation returns an operation on the given
+// tables. The attempt is taken from the context.
+func newOperation(
+	ctx context.Context,
+	name string,
+	input interface{},
+	index *string,
+	tables ...string) *Operation {
+
+	op := &Operation{
+		Name:    name,
+		Index:   aws.StringValue(index),
+		Input:   input,
+		Attempt: attemptFrom(ctx),
+	}
+
+	sort.Strings(tables)
+	if len(tables) > 0 && tables[0] == tables[len(tables)-1] {
+		op.Table = tables[0]
+	}
+	return op
+}
+
+// options returns the request options of
+// op which record the number of retries.
+func (op *Operation) options(opts []request.Option) []request.Option {
+	record := func(r *request.Request) {
+		r.Handlers.Complete.PushBack(func(r *request.Request) {
+			op.Retries = r.RetryCount
+		})
+	}
+
+	return append(opts[:len(opts):len(opts)], record)
+}
+
+// result returns the error of an operation
+// whose output is missing without an error.
+func (op *Operation) result(missing bool, err error) error {
+	if err == nil && missing {
+		return fmt.Errorf("dynami: %v returned no output", op.Name)
+	}
+	return err
+}
+
+type attemptKey struct{}
+
+// withAttempt returns a context whose requests are
+// the given retry attempt of a batch operation.
+func withAttempt(ctx context.Context, attempt int) context.Context {
+	return context.WithValue(ctx, attemptKey{}, attempt)
+}
+
+func attemptFrom(ctx context.Context) int {
+	attempt, _ := ctx.Value(attemptKey{}).(int)
+	return attempt
+}
+
+type tableKey struct{}
+
+// withTable returns a context whose stream requests
+// are on the given table. This is needed because
+// shard iterators don't contain the table name.
+func withTable(ctx context.Context, table string) context.Context {
+	return context.WithValue(ctx, tableKey{}, table)
+}
+
+type shardKey struct{}
+
+// withShard returns a context whose stream
+// requests are on the given shard.
+func withShard(ctx context.Context, shard string) context.Context {
+	return context.WithValue(ctx, shardKey{}, shard)
+}
+
+// streamShard returns the shard ID from the context.
+func streamShard(ctx context.Context) string {
+	shard, _ := ctx.Value(shardKey{}).(string)
+	return shard
+}
+
+// streamTable returns the table name
+// from a stream ARN or the context.
+func streamTable(ctx context.Context, arn *string) string {
+	// The ARN has the format arn:aws:dynamodb:
+	// region:account:table/name/stream/label
+	parts := strings.Split(aws.StringValue(arn), "/")
+	if len(parts) >= 2 {
+		return parts[1]
+	}
+
+	table, _ := ctx.Value(tableKey{}).(string)
+	return table
+}
+
+// unwrapper is implemented by the DynamoDB
+// APIs that wrap the API of a client.
+type unwrapper interface {
+	unwrap() dbiface.DynamoDBAPI
+}
+
+// baseAPI returns the API wrapped by dynami.
+func baseAPI(api dbiface.DynamoDBAPI) dbiface.DynamoDBAPI {
+	for {
+		u, ok := api.(unwrapper)
+		if !ok {
+			return api
+		}
+		api = u.unwrap()
+	}
+}
+
+// middlewareDB is a DynamoDB API that
+// sends every request through a chain.
+type middlewareDB struct {
+	dbiface.DynamoDBAPI
+
+	ch *chain
+}
+
+func (d *middlewareDB) unwrap() dbiface.DynamoDBAPI {
+	return d.DynamoDBAPI
+}
+
+func (d *middlewareDB) GetItemWithContext(
+	ctx aws.Context,
+	input *db.GetItemInput,
+	opts ...request.Option) (*db.GetItemOutput, error) {
+
+	op := newOperation(ctx, "GetItem", input, nil, aws.StringValue(input.TableName))
+	err := d.ch.run(ctx, op, func(ctx context.Context, op *Operation) error {
+		out, err := d.DynamoDBAPI.GetItemWithContext(ctx, op.Input.(*db.GetItemInput), op.options(opts)...)
+		if err == nil {
+			op.Output = out
+		}
+		return err
+	})
+
+	out, _ := op.Output.(*db.GetItemOutput)
+	return out, op.result(out == nil, err)
+}
+
+func (d *middlewareDB) PutItemWithContext(
+	ctx aws.Context,
+	input *db.PutItemInput,
+	opts ...request.Option) (*db.PutItemOutput, error) {
+
+	op := newOperation(ctx, "PutItem", input, nil, aws.StringValue(input.TableName))
+	err := d.ch.run(ctx, op, func(ctx context.Context, op *Operation) error {
+		out, err := d.DynamoDBAPI.PutItemWithContext(ctx, op.Input.(*db.PutItemInput), op.options(opts)...)
+		if err == nil {
+			op.Output = out
+		}
+		return err
+	})
+
+	out, _ := op.Output.(*db.PutItemOutput)
+	return out, op.result(out == nil, err)
+}
+
+func (d *middlewareDB) DeleteItemWithContext(
+	ctx aws.Context,
+	input *db.DeleteItemInput,
+	opts ...request.Option) (*db.DeleteItemOutput, error) {
+
+	op := newOperation(ctx, "DeleteItem", input, nil, aws.StringValue(input.TableName))
+	err := d.ch.run(ctx, op, func(ctx context.Context, op *Operation) error {
+		out, err := d.DynamoDBAPI.DeleteItemWithContext(ctx, op.Input.(*db.DeleteItemInput), op.options(opts)...)
+		if err == nil {
+			op.Output = out
+		}
+		return err
+	})
+
+	out, _ := op.Output.(*db.DeleteItemOutput)
+	return out, op.result(out == nil, err)
+}
+
+func (d *middlewareDB) UpdateItemWithContext(
+	ctx aws.Context,
+	input *db.UpdateItemInput,
+	opts ...request.Option) (*db.UpdateItemOutput, error) {
+
+	op := newOperation(ctx, "UpdateItem", input, nil, aws.StringValue(input.TableName))
+	err := d.ch.run(ctx, op, func(ctx context.Context, op *Operation) error {
+		out, err := d.DynamoDBAPI.UpdateItemWithContext(ctx, op.Input.(*db.UpdateItemInput), op.options(opts)...)
+		if err == nil {
+			op.Output = out
+		}
+		return err
+	})
+
+	out, _ := op.Output.(*db.UpdateItemOutput)
+	return out, op.result(out == nil, err)
+}
+
+func (d *middlewareDB) BatchGetItemWithContext(
+	ctx aws.Context,
+	input *db.BatchGetItemInput,
+	opts ...request.Option) (*db.BatchGetItemOutput, error) {
+
+	var tables []string
+	for table := range input.RequestItems {
+		tables = append(tables, table)
+	}
+
+	op := newOperation(ctx, "BatchGetItem", input, nil, tables...)
+	err := d.ch.run(ctx, op, func(ctx context.Context, op *Operation) error {
+		out, err := d.DynamoDBAPI.BatchGetItemWithContext(ctx, op.Input.(*db.BatchGetItemInput), op.options(opts)...)
+		if err == nil {
+			op.Output = out
+		}
+		return err
+	})
+
+	out, _ := op.Output.(*db.BatchGetItemOutput)
+	return out, op.result(out == nil, err)
+}
+
+func (d *middlewareDB) BatchWriteItemWithContext(
+	ctx aws.Context,
+	input *db.BatchWriteItemInput,
+	opts ...request.Option) (*db.BatchWriteItemOutput, error) {
+
+	var tables []string
+	for table := range input.RequestItems {
+		tables = append(tables, table)
+	}
+
+	op := newOperation(ctx, "BatchWriteItem", input, nil, tables...)
+	err := d.ch.run(ctx, op, func(ctx context.Context, op *Operation) error {
+		out, err := d.DynamoDBAPI.BatchWriteItemWithContext(ctx, op.Input.(*db.BatchWriteItemInput), op.options(opts)...)
+		if err == nil {
+			op.Output = out
+		}
+		return err
+	})
+
+	out, _ := op.Output.(*db.BatchWriteItemOutput)
+	return out, op.result(out == nil, err)
+}
+
+func (d *middlewareDB) QueryWithContext(
+	ctx aws.Context,
+	input *db.QueryInput,
+	opts ...request.Option) (*db.QueryOutput, error) {
+
+	op := newOperation(ctx, "Query", input, input.IndexName, aws.StringValue(input.TableName))
+	err := d.ch.run(ctx, op, func(ctx context.Context, op *Operation) error {
+		out, err := d.DynamoDBAPI.QueryWithContext(ctx, op.Input.(*db.QueryInput), op.options(opts)...)
+		if err == nil {
+			op.Output = out
+		}
+		return err
+	})
+
+	out, _ := op.Output.(*db.QueryOutput)
+	return out, op.result(out == nil, err)
+}
+
+func (d *middlewareDB) ScanWithContext(
+	ctx aws.Context,
+	input *db.ScanInput,
+	opts ...request.Option) (*db.ScanOutput, error) {
+
+	op := newOperation(ctx, "Scan", input, input.IndexName, aws.StringValue(input.TableName))
+	err := d.ch.run(ctx, op, func(ctx context.Context, op *Operation) error {
+		out, err := d.DynamoDBAPI.ScanWithContext(ctx, op.Input.(*db.ScanInput), op.options(opts)...)
+		if err == nil {
+			op.Output = out
+		}
+		return err
+	})
+
+	out, _ := op.Output.(*db.ScanOutput)
+	return out, op.result(out == nil, err)
+}
+
+func (d *middlewareDB) TransactGetItemsWithContext(
+	ctx aws.Context,
+	input *db.TransactGetItemsInput,
+	opts ...request.Option) (*db.TransactGetItemsOutput, error) {
+
+	var tables []string
+	for _, ti := range input.TransactItems {
+		if ti.Get != nil {
+			tables = append(tables, aws.StringValue(ti.Get.TableName))
+		}
+	}
+
+	op := newOperation(ctx, "TransactGetItems", input, nil, tables...)
+	err := d.ch.run(ctx, op, func(ctx context.Context, op *Operation) error {
+		out, err := d.DynamoDBAPI.TransactGetItemsWithContext(ctx, op.Input.(*db.TransactGetItemsInput), op.options(opts)...)
+		if err == nil {
+			op.Output = out
+		}
+		return err
+	})
+
+	out, _ := op.Output.(*db.TransactGetItemsOutput)
+	return out, op.result(out == nil, err)
+}
+
+func (d *middlewareDB) TransactWriteItemsWithContext(
+	ctx aws.Context,
+	input *db.TransactWriteItemsInput,
+	opts ...request.Option) (*db.TransactWriteItemsOutput, error) {
+
+	var tables []string
+	for _, ti := range input.TransactItems {
+		switch {
+		case ti.Put != nil:
+			tables = append(tables, aws.StringValue(ti.Put.TableName))
+		case ti.Delete != nil:
+			tables = append(tables, aws.StringValue(ti.Delete.TableName))
+		case ti.Update != nil:
+			tables = append(tables, aws.StringValue(ti.Update.TableName))
+		case ti.ConditionCheck != nil:
+			tables = append(tables, aws.StringValue(ti.ConditionCheck.TableName))
+		}
+	}
+
+	op := newOperation(ctx, "TransactWriteItems", input, nil, tables...)
+	err := d.ch.run(ctx, op, func(ctx context.Context, op *Operation) error {
+		out, err := d.DynamoDBAPI.TransactWriteItemsWithContext(ctx, op.Input.(*db.TransactWriteItemsInput), op.options(opts)...)
+		if err == nil {
+			op.Output = out
+		}
+		return err
+	})
+
+	out, _ := op.Output.(*db.TransactWriteItemsOutput)
+	return out, op.result(out == nil, err)
+}
+
+func (d *middlewareDB) CreateTableWithContext(
+	ctx aws.Context,
+	input *db.CreateTableInput,
+	opts ...request.Option) (*db.CreateTableOutput, error) {
+
+	op := newOperation(ctx, "CreateTable", input, nil, aws.StringValue(input.TableName))
+	err := d.ch.run(ctx, op, func(ctx context.Context, op *Operation) error {
+		out, err := d.DynamoDBAPI.CreateTableWithContext(ctx, op.Input.(*db.CreateTableInput), op.options(opts)...)
+		if err == nil {
+			op.Output = out
+		}
+		return err
+	})
+
+	out, _ := op.Output.(*db.CreateTableOutput)
+	return out, op.result(out == nil, err)
+}
+
+func (d *middlewareDB) DescribeTableWithContext(
+	ctx aws.Context,
+	input *db.DescribeTableInput,
+	opts ...request.Option) (*db.DescribeTableOutput, error) {
+
+	op := newOperation(ctx, "DescribeTable", input, nil, aws.StringValue(input.TableName))
+	err := d.ch.run(ctx, op, func(ctx context.Context, op *Operation) error {
+		out, err := d.DynamoDBAPI.DescribeTableWithContext(ctx, op.Input.(*db.DescribeTableInput), op.options(opts)...)
+		if err == nil {
+			op.Output = out
+		}
+		return err
+	})
+
+	out, _ := op.Output.(*db.DescribeTableOutput)
+	return out, op.result(out == nil, err)
+}
+
+func (d *middlewareDB) UpdateTableWithContext(
+	ctx aws.Context,
+	input *db.UpdateTableInput,
+	opts ...request.Option) (*db.UpdateTableOutput, error) {
+
+	op := newOperation(ctx, "UpdateTable", input, nil, aws.StringValue(input.TableName))
+	err := d.ch.run(ctx, op, func(ctx context.Context, op *Operation) error {
+		out, err := d.DynamoDBAPI.UpdateTableWithContext(ctx, op.Input.(*db.UpdateTableInput), op.options(opts)...)
+		if err == nil {
+			op.Output = out
+		}
+		return err
+	})
+
+	out, _ := op.Output.(*db.UpdateTableOutput)
+	return out, op.result(out == nil, err)
+}
+
+func (d *middlewareDB) DeleteTableWithContext(
+	ctx aws.Context,
+	input *db.DeleteTableInput,
+	opts ...request.Option) (*db.DeleteTableOutput, error) {
+
+	op := newOperation(ctx, "DeleteTable", input, nil, aws.StringValue(input.TableName))
+	err := d.ch.run(ctx, op, func(ctx context.Context, op *Operation) error {
+		out, err := d.DynamoDBAPI.DeleteTableWithContext(ctx, op.Input.(*db.DeleteTableInput), op.options(opts)...)
+		if err == nil {
+			op.Output = out
+		}
+		return err
+	})
+
+	out, _ := op.Output.(*db.DeleteTableOutput)
+	return out, op.result(out == nil, err)
+}
+
+func (d *middlewareDB) ListTablesWithContext(
+	ctx aws.Context,
+	input *db.ListTablesInput,
+	opts ...request.Option) (*db.ListTablesOutput, error) {
+
+	op := newOperation(ctx, "ListTables", input, nil)
+	err := d.ch.run(ctx, op, func(ctx context.Context, op *Operation) error {
+		out, err := d.DynamoDBAPI.ListTablesWithContext(ctx, op.Input.(*db.ListTablesInput), op.options(opts)...)
+		if err == nil {
+			op.Output = out
+		}
+		return err
+	})
+
+	out, _ := op.Output.(*db.ListTablesOutput)
+	return out, op.result(out == nil, err)
+}
+
+func (d *middlewareDB) WaitUntilTableExistsWithContext(
+	ctx aws.Context,
+	input *db.DescribeTableInput,
+	opts ...request.WaiterOption) error {
+
+	op := newOperation(ctx, "WaitUntilTableExists", input, nil, aws.StringValue(input.TableName))
+	return d.ch.run(ctx, op, func(ctx context.Context, op *Operation) error {
+		return d.DynamoDBAPI.WaitUntilTableExistsWithContext(ctx, op.Input.(*db.DescribeTableInput), opts...)
+	})
+}
+
+func (d *middlewareDB) WaitUntilTableNotExistsWithContext(
+	ctx aws.Context,
+	input *db.DescribeTableInput,
+	opts ...request.WaiterOption) error {
+
+	op := newOperation(ctx, "WaitUntilTableNotExists", input, nil, aws.StringValue(input.TableName))
+	return d.ch.run(ctx, op, func(ctx context.Context, op *Operation) error {
+		return d.DynamoDBAPI.WaitUntilTableNotExistsWithContext(ctx, op.Input.(*db.DescribeTableInput), opts...)
+	})
+}
+
+// middlewareStreams is a DynamoDB Streams API
+// that sends every request through a chain.
+type middlewareStreams struct {
+	dynamodbstreamsiface.DynamoDBStreamsAPI
+
+	ch *chain
+}
+
+func (d *middlewareStreams) DescribeStreamWithContext(
+	ctx aws.Context,
+	input *dynamodbstreams.DescribeStreamInput,
+	opts ...request.Option) (*dynamodbstreams.DescribeStreamOutput, error) {
+
+	op := newOperation(ctx, "DescribeStream", input, nil, streamTable(ctx, input.StreamArn))
+	err := d.ch.run(ctx, op, func(ctx context.Context, op *Operation) error {
+		out, err := d.DynamoDBStreamsAPI.DescribeStreamWithContext(ctx, op.Input.(*dynamodbstreams.DescribeStreamInput), op.options(opts)...)
+		if err == nil {
+			op.Output = out
+		}
+		return err
+	})
+
+	out, _ := op.Output.(*dynamodbstreams.DescribeStreamOutput)
+	return out, op.result(out == nil, err)
+}
+
+func (d *middlewareStreams) GetShardIteratorWithContext(
+	ctx aws.Context,
+	input *dynamodbstreams.GetShardIteratorInput,
+	opts ...request.Option) (*dynamodbstreams.GetShardIteratorOutput, error) {
+
+	op := newOperation(ctx, "GetShardIterator", input, nil, streamTable(ctx, input.StreamArn))
+	err := d.ch.run(ctx, op, func(ctx context.Context, op *Operation) error {
+		out, err := d.DynamoDBStreamsAPI.GetShardIteratorWithContext(ctx, op.Input.(*dynamodbstreams.GetShardIteratorInput), op.options(opts)...)
+		if err == nil {
+			op.Output = out
+		}
+		return err
+	})
+
+	out, _ := op.Output.(*dynamodbstreams.GetShardIteratorOutput)
+	return out, op.result(out == nil, err)
+}
+
+func (d *middlewareStreams) GetRecordsWithContext(
+	ctx aws.Context,
+	input *dynamodbstreams.GetRecordsInput,
+	opts ...request.Option) (*dynamodbstreams.GetRecordsOutput, error) {
+
+	op := newOperation(ctx, "GetRecords", input, nil, streamTable(ctx, nil))
+	err := d.ch.run(ctx, op, func(ctx context.Context, op *Operation) error {
+		out, err := d.DynamoDBStreamsAPI.GetRecordsWithContext(ctx, op.Input.(*dynamodbstreams.GetRecordsInput), op.options(opts)...)
+		if err == nil {
+			op.Output = out
+		}
+		return err
+	})
+
+	out, _ := op.Output.(*dynamodbstreams.GetRecordsOutput)
+	return out, op.result(out == nil, err)
+}
@@ -0,0 +1,96 @@
+package dynami
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	db "github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+func (suite *DatabaseTestSuite) TestMiddleware() {
+	assert := suite.Assert()
+	require := suite.Require()
+
+	c, err := NewClientFromAPI(suite.db, nil)
+	require.Nil(err)
+
+	var mu sync.Mutex
+	var trace []string
+	var ops []Operation
+	c.Use(
+		func(next Handler) Handler {
+			return func(ctx context.Context, op *Operation) error {
+				mu.Lock()
+				trace = append(trace, "outer")
+				mu.Unlock()
+
+				err := next(ctx, op)
+
+				mu.Lock()
+				ops = append(ops, *op)
+				mu.Unlock()
+				return err
+			}
+		},
+		func(next Handler) Handler {
+			return func(ctx context.Context, op *Operation) error {
+				mu.Lock()
+				trace = append(trace, "inner")
+				mu.Unlock()
+				return next(ctx, op)
+			}
+		},
+	)
+
+	book := tBook{Title: "Frankenstein", Author: "Mary Shelley", Genre: "Horror"}
+	err = c.PutItem("Book", book)
+	require.Nil(err)
+
+	require.Len(ops, 1)
+	assert.Equal([]string{"outer", "inner"}, trace)
+	assert.Equal("PutItem", ops[0].Name)
+	assert.Equal("Book", ops[0].Table)
+	assert.IsType(&db.PutItemInput{}, ops[0].Input)
+	assert.IsType(&db.PutItemOutput{}, ops[0].Output)
+	assert.True(ops[0].Duration > 0)
+
+	// Queries record their index
+	ops = nil
+	it := c.Query("Book").
+		Index("GenreIndex").
+		HashFilter("Genre", "Horror").
+		Run()
+	for it.HasNext() {
+		require.Nil(it.Next(nil))
+	}
+	require.Nil(it.Err())
+	require.NotEmpty(ops)
+	assert.Equal("Query", ops[0].Name)
+	assert.Equal("GenreIndex", ops[0].Index)
+
+	// Batch operations on a single table
+	ops = nil
+	err = c.BatchDelete("Book", []tBook{book}).Run()
+	require.Nil(err)
+	require.NotEmpty(ops)
+	assert.Equal("BatchWriteItem", ops[0].Name)
+	assert.Equal("Book", ops[0].Table)
+	assert.Zero(ops[0].Attempt)
+
+	// Inject a fault
+	errFault := errors.New("injected fault")
+	c.Use(func(next Handler) Handler {
+		return func(ctx context.Context, op *Operation) error {
+			if op.Name == "GetItem" {
+				return errFault
+			}
+			return next(ctx, op)
+		}
+	})
+
+	fetched := tBook{Title: book.Title, Author: book.Author}
+	err = c.GetItem("Book", &fetched)
+	require.NotNil(err)
+	assert.Contains(err.Error(), errFault.Error())
+}
@@ -0,0 +1,308 @@
+package dynami
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	db "github.com/aws/aws-sdk-go/service/dynamodb"
+	dbs "github.com/aws/aws-sdk-go/service/dynamodbstreams"
+)
+
+// Option configures a client created by NewClientWithOptions.
+type Option func(*clientOptions) error
+
+type clientOptions struct {
+	region      *Region
+	credentials *credentials.Credentials
+
+	// dbEndpoint and dbsEndpoint override the
+	// DynamoDB and DynamoDB Streams endpoints
+	// of the region, respectively.
+	dbEndpoint  string
+	dbsEndpoint string
+
+	maxRetries *int
+	httpClient *http.Client
+
+	logger   aws.Logger
+	logLevel aws.LogLevelType
+
+	batchRetry *RetryPolicy
+	rateLimits map[limitKey]RateLimit
+	collector  StatsCollector
+	metrics    *Metrics
+
+	emptyValues      EmptyValues
+	tableEmptyValues map[string]EmptyValues
+}
+
+func newClientOptions(opts []Option) (*clientOptions, error) {
+	o := &clientOptions{}
+	for _, opt := range opts {
+		if err := opt(o); err != nil {
+			return nil, err
+		}
+	}
+
+	return o, nil
+}
+
+// WithRegion sets the region of the client. If this is not
+// given, the region is taken from the AWS_REGION environment
+// variable or the shared config file.
+func WithRegion(region *Region) Option {
+	return func(o *clientOptions) error {
+		if region == nil {
+			return fmt.Errorf("dynami: nil region")
+		}
+
+		o.region = region
+		return nil
+	}
+}
+
+// WithCredentials sets the credentials provider of the client.
+// This can be any of the SDK's providers, eg. environment
+// variables, a shared credentials profile, or an assumed role
+// using stscreds. If this is not given, the default credential
+// chain of the SDK is used.
+func WithCredentials(creds *credentials.Credentials) Option {
+	return func(o *clientOptions) error {
+		if creds == nil {
+			return fmt.Errorf("dynami: nil credentials")
+		}
+
+		o.credentials = creds
+		return nil
+	}
+}
+
+// WithStaticCredentials sets the client to use the
+// given access key ID, secret key, and session token.
+// The session token may be empty.
+func WithStaticCredentials(accessKeyID, secretAccessKey, sessionToken string) Option {
+	return func(o *clientOptions) error {
+		o.credentials = credentials.NewStaticCredentials(
+			accessKeyID,
+			secretAccessKey,
+			sessionToken,
+		)
+		return nil
+	}
+}
+
+// WithEndpoint overrides the DynamoDB endpoint of the region.
+// endpoint can be a hostname or a fully qualified URI, eg.
+// "http://localhost:8000" for DynamoDB Local.
+func WithEndpoint(endpoint string) Option {
+	return func(o *clientOptions) error {
+		o.dbEndpoint = endpoint
+		return nil
+	}
+}
+
+// WithStreamsEndpoint overrides the DynamoDB Streams
+// endpoint of the region. See WithEndpoint for details.
+func WithStreamsEndpoint(endpoint string) Option {
+	return func(o *clientOptions) error {
+		o.dbsEndpoint = endpoint
+		return nil
+	}
+}
+
+// WithMaxRetries sets the maximum number of times
+// a failed request is retried. Zero disables retries.
+func WithMaxRetries(maxRetries int) Option {
+	return func(o *clientOptions) error {
+		if maxRetries < 0 {
+			return fmt.Errorf("dynami: max retries must not be negative")
+		}
+
+		o.maxRetries = aws.Int(maxRetries)
+		return nil
+	}
+}
+
+// WithHTTPClient sets the HTTP client used to send requests.
+// Use this to set timeouts, proxies, or connection limits.
+func WithHTTPClient(client *http.Client) Option {
+	return func(o *clientOptions) error {
+		if client == nil {
+			return fmt.Errorf("dynami: nil HTTP client")
+		}
+
+		o.httpClient = client
+		return nil
+	}
+}
+
+// WithLogger sets the logger of the client and
+// the level of detail of the logged requests.
+func WithLogger(logger aws.Logger, level aws.LogLevelType) Option {
+	return func(o *clientOptions) error {
+		if logger == nil {
+			return fmt.Errorf("dynami: nil logger")
+		}
+
+		o.logger = logger
+		o.logLevel = level
+		return nil
+	}
+}
+
+// WithBatchRetry sets the default retry policy for the
+// unprocessed items of batch operations. This can be
+// overridden by each batch operation. If this is not
+// given, DefaultRetryPolicy is used.
+func WithBatchRetry(policy RetryPolicy) Option {
+	return func(o *clientOptions) error {
+		if err := policy.validate(); err != nil {
+			return err
+		}
+
+		o.batchRetry = &policy
+		return nil
+	}
+}
+
+// WithRateLimit limits the capacity units per second that the
+// client consumes on the given table. Before each request, the
+// client waits until the table has enough capacity left, and
+// once done, it is charged using the consumed capacity that is
+// returned by DynamoDB. This can be given multiple times for
+// different tables.
+func WithRateLimit(tableName string, limit RateLimit) Option {
+	return WithIndexRateLimit(tableName, "", limit)
+}
+
+// WithIndexRateLimit is the same as WithRateLimit but limits
+// the capacity units consumed on a global secondary index.
+// Writes to the table wait for the capacity of its limited
+// indexes as well.
+func WithIndexRateLimit(tableName, indexName string, limit RateLimit) Option {
+	return func(o *clientOptions) error {
+		if tableName == "" {
+			return fmt.Errorf("dynami: empty table name")
+		} else if err := limit.validate(); err != nil {
+			return err
+		}
+
+		if o.rateLimits == nil {
+			o.rateLimits = map[limitKey]RateLimit{}
+		}
+		o.rateLimits[limitKey{tableName, indexName}] = limit
+		return nil
+	}
+}
+
+// WithStatsCollector sets the collector that receives the
+// consumed capacity and item counts of every request sent
+// by the client.
+func WithStatsCollector(collector StatsCollector) Option {
+	return func(o *clientOptions) error {
+		if collector == nil {
+			return fmt.Errorf("dynami: nil stats collector")
+		}
+
+		o.collector = collector
+		return nil
+	}
+}
+
+// WithMetrics records every request sent by the client
+// in the given metrics. The same metrics can be shared
+// by multiple clients.
+func WithMetrics(m *Metrics) Option {
+	return func(o *clientOptions) error {
+		if m == nil {
+			return fmt.Errorf("dynami: nil metrics")
+		}
+
+		o.metrics = m
+		return nil
+	}
+}
+
+// WithEmptyValues sets how empty strings and null values
+// are written by PutItem, BatchPut, and transactions. If
+// this is not given, OmitEmpty is used.
+func WithEmptyValues(mode EmptyValues) Option {
+	return func(o *clientOptions) error {
+		if err := mode.validate(); err != nil {
+			return err
+		}
+
+		o.emptyValues = mode
+		return nil
+	}
+}
+
+// WithTableEmptyValues is the same as WithEmptyValues but
+// only applies to the given table. This can be given
+// multiple times for different tables.
+func WithTableEmptyValues(tableName string, mode EmptyValues) Option {
+	return func(o *clientOptions) error {
+		if tableName == "" {
+			return fmt.Errorf("dynami: empty table name")
+		} else if err := mode.validate(); err != nil {
+			return err
+		}
+
+		if o.tableEmptyValues == nil {
+			o.tableEmptyValues = map[string]EmptyValues{}
+		}
+		o.tableEmptyValues[tableName] = mode
+		return nil
+	}
+}
+
+// NewClientWithOptions creates a new client configured
+// by the given options. Unlike NewClient, this returns
+// an error instead of panicking.
+func NewClientWithOptions(opts ...Option) (*Client, error) {
+	o, err := newClientOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &aws.Config{
+		Credentials: o.credentials,
+		MaxRetries:  o.maxRetries,
+		HTTPClient:  o.httpClient,
+		Logger:      o.logger,
+	}
+	if o.logger != nil {
+		config.LogLevel = aws.LogLevel(o.logLevel)
+	}
+
+	dbEndpoint := o.dbEndpoint
+	dbsEndpoint := o.dbsEndpoint
+	if o.region != nil {
+		config.Region = aws.String(o.region.Name)
+		if dbEndpoint == "" {
+			dbEndpoint = o.region.DynamoDBEndpoint
+		}
+		if dbsEndpoint == "" {
+			dbsEndpoint = o.region.DynamoDBStreamsEndpoint
+		}
+	}
+
+	sess, err := session.NewSessionWithOptions(session.Options{
+		Config:            *config,
+		SharedConfigState: session.SharedConfigEnable,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("dynami: cannot create new client (%w)", err)
+	} else if aws.StringValue(sess.Config.Region) == "" {
+		return nil, fmt.Errorf("dynami: cannot create new client (missing region)")
+	}
+
+	return newClient(
+		db.New(sess, &aws.Config{Endpoint: toPtr(dbEndpoint).(*string)}),
+		dbs.New(sess, &aws.Config{Endpoint: toPtr(dbsEndpoint).(*string)}),
+		o,
+	), nil
+}
@@ -0,0 +1,132 @@
+package dynami
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+// projectionExpr is a parsed projection expression.
+type projectionExpr struct {
+	expr           string
+	attributeNames map[string]*string
+}
+
+// parseProjection creates a projection expression from the given
+// attribute paths. A path can be a top level attribute name, a nested
+// attribute such as "Info.Publisher", or a list element such as
+// "Tags[0]". Duplicate paths are ignored.
+func parseProjection(attrs []string) (*projectionExpr, error) {
+	if len(attrs) == 0 {
+		return nil, fmt.Errorf("dynami: empty projection")
+	}
+
+	p := &projectionExpr{attributeNames: map[string]*string{}}
+	exprs := make([]string, 0, len(attrs))
+	added := map[string]bool{}
+	for _, attr := range attrs {
+		if added[attr] {
+			continue
+		}
+		added[attr] = true
+
+		names := strings.Split(attr, ".")
+		for i, n := range names {
+			// Separate list indices from the name
+			name, index := n, ""
+			if j := strings.IndexByte(n, '['); j >= 0 {
+				name, index = n[:j], n[j:]
+			}
+
+			if name == "" {
+				return nil, fmt.Errorf("dynami: invalid projection attribute (%v)", attr)
+			}
+
+			ph := "#" + name + "_PH"
+			p.attributeNames[ph] = aws.String(name)
+			names[i] = ph + index
+		}
+
+		exprs = append(exprs, strings.Join(names, "."))
+	}
+
+	p.expr = strings.Join(exprs, ", ")
+	return p, nil
+}
+
+// withKey returns a copy of attrs with the key attribute
+// names appended. Duplicates are removed by parseProjection.
+func withKey(attrs []string, key dbitem) []string {
+	cpy := make([]string, len(attrs), len(attrs)+len(key))
+	copy(cpy, attrs)
+
+	for name := range key {
+		cpy = append(cpy, name)
+	}
+
+	return cpy
+}
+
+// Attributes returns the names of the attributes stored from
+// the given struct. This is useful for projecting only the
+// attributes that are loaded into the destination struct. For
+// example, Query.Select(dynami.Attributes(Item{})...). Fields
+// ignored with a "-" dynamodbav or json tag are excluded.
+func Attributes(item interface{}) []string {
+	t := reflect.TypeOf(item)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	return structAttrs(t)
+}
+
+func structAttrs(t reflect.Type) []string {
+	var attrs []string
+	nf := t.NumField()
+	for i := 0; i < nf; i++ {
+		f := t.Field(i)
+
+		// Consider only exported fields
+		if f.PkgPath != "" && !f.Anonymous {
+			continue
+		}
+
+		// Get name from dynamodbav or json tag
+		name := f.Name
+		nameTag := f.Tag.Get("dynamodbav")
+		if nameTag == "" {
+			nameTag = f.Tag.Get("json")
+		}
+
+		tags := strings.Split(nameTag, ",")
+		if tags[0] == "-" {
+			continue
+		} else if tags[0] != "" {
+			name = tags[0]
+		} else if f.Anonymous {
+			// Fields of untagged embedded
+			// structs are stored inline
+			ft := f.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				attrs = append(attrs, structAttrs(ft)...)
+				continue
+			} else if f.PkgPath != "" {
+				continue
+			}
+		}
+
+		attrs = append(attrs, name)
+	}
+
+	return attrs
+}
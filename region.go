@@ -0,0 +1,202 @@
+package dynami
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws/endpoints"
+)
+
+// Region defines where DynamoDB services are located.
+type Region struct {
+	Name string
+
+	// DynamoDB and DynamoDB Streams endpoint
+	// URLs (hostname only or fully qualified URI)
+	DynamoDBEndpoint        string
+	DynamoDBStreamsEndpoint string
+}
+
+// These are the list of all supported AWS regions.
+var (
+	USEast1 = &Region{
+		"us-east-1",
+		"dynamodb.us-east-1.amazonaws.com",
+		"streams.dynamodb.us-east-1.amazonaws.com",
+	}
+
+	USWest1 = &Region{
+		"us-west-1",
+		"dynamodb.us-west-1.amazonaws.com",
+		"streams.dynamodb.us-west-1.amazonaws.com",
+	}
+
+	USWest2 = &Region{
+		"us-west-2",
+		"dynamodb.us-west-2.amazonaws.com",
+		"streams.dynamodb.us-west-2.amazonaws.com",
+	}
+
+	EUWest1 = &Region{
+		"eu-west-1",
+		"dynamodb.eu-west-1.amazonaws.com",
+		"streams.dynamodb.eu-west-1.amazonaws.com",
+	}
+
+	EUCentral1 = &Region{
+		"eu-central-1",
+		"dynamodb.eu-central-1.amazonaws.com",
+		"streams.dynamodb.eu-central-1.amazonaws.com",
+	}
+
+	APNortheast1 = &Region{
+		"ap-northeast-1",
+		"dynamodb.ap-northeast-1.amazonaws.com",
+		"streams.dynamodb.ap-northeast-1.amazonaws.com",
+	}
+
+	APNortheast2 = &Region{
+		"ap-northeast-2",
+		"dynamodb.ap-northeast-2.amazonaws.com",
+		"streams.dynamodb.ap-northeast-2.amazonaws.com",
+	}
+
+	APSoutheast1 = &Region{
+		"ap-southeast-1",
+		"dynamodb.ap-southeast-1.amazonaws.com",
+		"streams.dynamodb.ap-southeast-1.amazonaws.com",
+	}
+
+	APSoutheast2 = &Region{
+		"ap-southeast-2",
+		"dynamodb.ap-southeast-2.amazonaws.com",
+		"streams.dynamodb.ap-southeast-2.amazonaws.com",
+	}
+
+	SAEast1 = &Region{
+		"sa-east-1",
+		"dynamodb.sa-east-1.amazonaws.com",
+		"streams.dynamodb.sa-east-1.amazonaws.com",
+	}
+)
+
+// registry contains the registered regions
+// and the resolver for unregistered ones.
+var registry = struct {
+	*sync.RWMutex
+	regions  map[string]*Region
+	resolver EndpointResolver
+}{
+	&sync.RWMutex{},
+	map[string]*Region{
+		"us-east-1":      USEast1,
+		"us-west-1":      USWest1,
+		"us-west-2":      USWest2,
+		"eu-west-1":      EUWest1,
+		"eu-central-1":   EUCentral1,
+		"ap-northeast-1": APNortheast1,
+		"ap-northeast-2": APNortheast2,
+		"ap-southeast-1": APSoutheast1,
+		"ap-southeast-2": APSoutheast2,
+		"sa-east-1":      SAEast1,
+	},
+	NewSDKEndpointResolver(),
+}
+
+// EndpointResolver derives the DynamoDB and DynamoDB
+// Streams endpoints of a region from its name.
+type EndpointResolver func(name string) (*Region, error)
+
+// NewSDKEndpointResolver returns a resolver that uses the
+// endpoint model of the AWS SDK. This is the default resolver
+// and covers every region known to the SDK, including those
+// in the China and GovCloud partitions. opts can be used to
+// resolve FIPS or dual-stack endpoints, eg.
+// endpoints.UseFIPSEndpointOption.
+func NewSDKEndpointResolver(opts ...func(*endpoints.Options)) EndpointResolver {
+	const (
+		dbService  = "dynamodb"
+		dbsService = "streams.dynamodb"
+	)
+
+	return func(name string) (*Region, error) {
+		ps := endpoints.DefaultPartitions()
+		if _, ok := endpoints.PartitionForRegion(ps, name); !ok {
+			return nil, fmt.Errorf("dynami: unknown region (%v)", name)
+		}
+
+		resolver := endpoints.DefaultResolver()
+		dbe, err := resolver.EndpointFor(dbService, name, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("dynami: cannot resolve endpoint (%w)", err)
+		}
+
+		dbse, err := resolver.EndpointFor(dbsService, name, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("dynami: cannot resolve endpoint (%w)", err)
+		}
+
+		return &Region{
+			Name:                    name,
+			DynamoDBEndpoint:        dbe.URL,
+			DynamoDBStreamsEndpoint: dbse.URL,
+		}, nil
+	}
+}
+
+// SetEndpointResolver sets the resolver used by LookupRegion
+// for regions that are not registered. If resolver is nil,
+// only registered regions can be looked up.
+func SetEndpointResolver(resolver EndpointResolver) {
+	registry.Lock()
+	registry.resolver = resolver
+	registry.Unlock()
+}
+
+// RegisterRegion adds a region to the registry so that it can
+// be looked up by name. This is useful for private endpoints or
+// DynamoDB Local. A registered region replaces any region with
+// the same name, including the predefined ones.
+func RegisterRegion(region *Region) error {
+	if region == nil || region.Name == "" {
+		return fmt.Errorf("dynami: region must have a name")
+	} else if region.DynamoDBEndpoint == "" || region.DynamoDBStreamsEndpoint == "" {
+		return fmt.Errorf("dynami: region must have DynamoDB and DynamoDB Streams endpoints")
+	}
+
+	registry.Lock()
+	registry.regions[region.Name] = region
+	registry.Unlock()
+
+	return nil
+}
+
+// LookupRegion returns the region with the given name, eg.
+// "us-east-1". Registered regions are returned first. For
+// other regions, the endpoints are derived from the name
+// using the endpoint resolver.
+func LookupRegion(name string) (*Region, error) {
+	registry.RLock()
+	reg, ok := registry.regions[name]
+	resolver := registry.resolver
+	registry.RUnlock()
+
+	if ok {
+		return reg, nil
+	} else if resolver == nil {
+		return nil, fmt.Errorf("dynami: unknown region (%v)", name)
+	}
+
+	return resolver(name)
+}
+
+// GetRegion is the same as LookupRegion
+// except that it panics if there's an error.
+func GetRegion(name string) *Region {
+	reg, err := LookupRegion(name)
+	if err != nil {
+		panic(err)
+	}
+
+	return reg
+}
@@ -0,0 +1,80 @@
+package dynami
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws/endpoints"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLookupRegion(t *testing.T) {
+	restoreRegistry(t)
+
+	// Predefined region
+	reg, err := LookupRegion("us-east-1")
+	require.Nil(t, err)
+	assert.Equal(t, USEast1, reg)
+
+	// Resolved region
+	reg, err = LookupRegion("eu-north-1")
+	require.Nil(t, err)
+	assert.Equal(t, "eu-north-1", reg.Name)
+	assert.Equal(t, "https://dynamodb.eu-north-1.amazonaws.com", reg.DynamoDBEndpoint)
+	assert.Equal(t, "https://streams.dynamodb.eu-north-1.amazonaws.com", reg.DynamoDBStreamsEndpoint)
+
+	reg, err = LookupRegion("cn-north-1")
+	require.Nil(t, err)
+	assert.Equal(t, "https://dynamodb.cn-north-1.amazonaws.com.cn", reg.DynamoDBEndpoint)
+
+	// Unknown region
+	_, err = LookupRegion("unknown-region")
+	assert.NotNil(t, err)
+	assert.Panics(t, func() { GetRegion("unknown-region") })
+
+	// Registered region
+	local := &Region{
+		"local",
+		"http://localhost:8001",
+		"http://localhost:8001",
+	}
+	err = RegisterRegion(local)
+	require.Nil(t, err)
+
+	reg, err = LookupRegion("local")
+	require.Nil(t, err)
+	assert.Equal(t, local, reg)
+
+	err = RegisterRegion(&Region{Name: "invalid"})
+	assert.NotNil(t, err)
+
+	// Custom resolver
+	SetEndpointResolver(NewSDKEndpointResolver(endpoints.UseFIPSEndpointOption))
+
+	reg, err = LookupRegion("us-east-2")
+	require.Nil(t, err)
+	assert.Equal(t, "https://dynamodb-fips.us-east-2.amazonaws.com", reg.DynamoDBEndpoint)
+
+	SetEndpointResolver(nil)
+	_, err = LookupRegion("us-east-2")
+	assert.NotNil(t, err)
+}
+
+// restoreRegistry restores the region
+// registry when the test is finished.
+func restoreRegistry(t *testing.T) {
+	registry.RLock()
+	regions := make(map[string]*Region, len(registry.regions))
+	for name, reg := range registry.regions {
+		regions[name] = reg
+	}
+	resolver := registry.resolver
+	registry.RUnlock()
+
+	t.Cleanup(func() {
+		registry.Lock()
+		registry.regions = regions
+		registry.resolver = resolver
+		registry.Unlock()
+	})
+}
@@ -0,0 +1,148 @@
+package dynami
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+// RetryPolicy specifies how the unprocessed items of a batch
+// operation are retried. Before each retry, the operation waits
+// for BaseDelay doubled for every request in a row that left
+// some items unprocessed, up to MaxDelay. The delay is only
+// reset once a request processes all of its items. A policy
+// must limit either the number of attempts or the elapsed
+// time, so the zero value is not valid.
+type RetryPolicy struct {
+	// BaseDelay is the delay before the first retry.
+	BaseDelay time.Duration
+
+	// MaxDelay caps the delay between retries.
+	// Zero means that there is no cap.
+	MaxDelay time.Duration
+
+	// Jitter is the fraction of each delay that is
+	// randomized. It must be between 0 and 1, where
+	// 1 picks a random delay between zero and the
+	// computed delay.
+	Jitter float64
+
+	// MaxAttempts is the maximum number of retries in
+	// a row that process none of the unprocessed items
+	// before giving up. Zero means that there is no
+	// limit.
+	MaxAttempts int
+
+	// MaxElapsedTime is the maximum total time spent
+	// retrying, from the first retry of the operation,
+	// before giving up. Zero means that there is no
+	// limit.
+	MaxElapsedTime time.Duration
+}
+
+// DefaultRetryPolicy is the retry policy of
+// batch operations unless configured otherwise.
+var DefaultRetryPolicy = RetryPolicy{
+	BaseDelay:      50 * time.Millisecond,
+	MaxDelay:       5 * time.Second,
+	Jitter:         1,
+	MaxAttempts:    10,
+	MaxElapsedTime: time.Minute,
+}
+
+func (p RetryPolicy) validate() error {
+	if p.BaseDelay < 0 || p.MaxDelay < 0 || p.MaxElapsedTime < 0 {
+		return fmt.Errorf("dynami: retry delays must not be negative")
+	} else if p.MaxAttempts < 0 {
+		return fmt.Errorf("dynami: max attempts must not be negative")
+	} else if p.Jitter < 0 || p.Jitter > 1 {
+		return fmt.Errorf("dynami: jitter must be between 0 and 1")
+	} else if p.MaxAttempts == 0 && p.MaxElapsedTime == 0 {
+		return fmt.Errorf("dynami: retry policy must limit the attempts or the elapsed time")
+	}
+
+	return nil
+}
+
+// delay returns the delay before the
+// given retry without the jitter.
+func (p RetryPolicy) delay(attempt int) time.Duration {
+	d := p.BaseDelay
+	for i := 1; i < attempt && d > 0; i++ {
+		d *= 2
+		if p.MaxDelay > 0 && d >= p.MaxDelay {
+			break
+		}
+	}
+
+	if p.MaxDelay > 0 && d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+	return d
+}
+
+// backoff keeps track of the retries
+// of a batch operation's unprocessed items.
+type backoff struct {
+	policy RetryPolicy
+
+	// retries is the number of requests in a row
+	// that left some items unprocessed, and attempts
+	// is the number of those that processed nothing.
+	retries  int
+	attempts int
+	start    time.Time
+}
+
+// reset is called when a request processes all of
+// its items. The elapsed time is not reset since it
+// covers the whole operation.
+func (b *backoff) reset() {
+	b.retries = 0
+	b.attempts = 0
+}
+
+// progress is called when a request processes some
+// but not all of its items. Only the attempts are
+// reset so that the delay keeps growing.
+func (b *backoff) progress() {
+	b.attempts = 0
+}
+
+// wait sleeps before retrying unprocessed items. This
+// returns false if the retry budget is exhausted, and
+// an error if the context is done while waiting.
+func (b *backoff) wait(ctx context.Context) (bool, error) {
+	p := b.policy
+	if b.start.IsZero() {
+		b.start = time.Now()
+	}
+
+	b.retries++
+	b.attempts++
+	if p.MaxAttempts > 0 && b.attempts > p.MaxAttempts {
+		return false, nil
+	}
+
+	d := p.delay(b.retries)
+	if j := int64(float64(d) * p.Jitter); j > 0 {
+		d -= time.Duration(rand.Int63n(j + 1))
+	}
+
+	if p.MaxElapsedTime > 0 && time.Since(b.start)+d > p.MaxElapsedTime {
+		return false, nil
+	} else if d <= 0 {
+		return true, ctx.Err()
+	}
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+		return true, nil
+	case <-ctx.Done():
+		return false, ctx.Err()
+	}
+}
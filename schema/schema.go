@@ -166,6 +166,11 @@ type Table struct {
 	GlobalSecondaryIndexes []SecondaryIndex
 	StreamEnabled          bool
 
+	// VersionAttribute is the name of the numeric
+	// attribute used for optimistic locking. This is
+	// empty if the item has no field tagged with dbversion.
+	VersionAttribute string
+
 	// private read-only fields
 	tprivate
 }
@@ -337,6 +342,8 @@ func NewTable(
 	)
 	copy(table.GlobalSecondaryIndexes, sc.GlobalSecondaryIndexes)
 
+	table.VersionAttribute = sc.VersionAttribute
+
 	// Add provisioned throughput for all global secondary indices
 	for i, idx := range table.GlobalSecondaryIndexes {
 		tp, ok := throughput[idx.Name]
@@ -1,17 +1,24 @@
 package schema
 
 import (
+	"encoding"
 	"fmt"
 	"reflect"
 	"sort"
 	"strings"
 	"sync"
+
+	dbattribute "github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
 )
 
 const (
 	tagHashAttr      = "hash"
 	tagRangeAttr     = "range"
 	tagProjectedAttr = "project"
+
+	tagStringType = "string"
+	tagNumberType = "number"
+	tagBinaryType = "binary"
 )
 
 var register = struct {
@@ -62,6 +69,7 @@ func GetSchema(item interface{}) *Table {
 
 		indices := map[string]bool{}
 		defs := map[string]*Attribute{}
+		version := ""
 
 		// Extract table schema from field tags
 		t := v.Type()
@@ -107,6 +115,24 @@ func GetSchema(item interface{}) *Table {
 				}
 			}
 
+			versionTag, ok := f.Tag.Lookup("dbversion")
+			if ok {
+				if versionTag != "" && versionTag != "true" {
+					panic(fmt.Errorf("dynami: invalid dbversion tag (%v) on struct field (%v)",
+						versionTag,
+						f.Name,
+					))
+				} else if version != "" {
+					panic(fmt.Errorf("dynami: struct (%v) has more than one dbversion tag", t.Name()))
+				} else if keyTag != "" {
+					panic(fmt.Errorf("dynami: key field (%v) cannot have a dbversion tag", f.Name))
+				} else if !isIntType(f.Type) {
+					panic(fmt.Errorf("dynami: version field (%v) must be an integer", f.Name))
+				}
+
+				version = name
+			}
+
 			indexTag := f.Tag.Get("dbindex")
 			if indexTag != "" {
 				parts := strings.Split(indexTag, ",")
@@ -225,6 +251,7 @@ func GetSchema(item interface{}) *Table {
 			Key:        pkey,
 			LocalSecondaryIndexes:  localIdxs,
 			GlobalSecondaryIndexes: globalIdxs,
+			VersionAttribute:       version,
 		}
 
 		// Register schema
@@ -236,23 +263,110 @@ func GetSchema(item interface{}) *Table {
 	return s
 }
 
+var (
+	marshalerType     = reflect.TypeOf((*dbattribute.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// implements returns true if t or a pointer to t implements iface.
+func implements(t, iface reflect.Type) bool {
+	return t.Implements(iface) || reflect.PtrTo(t).Implements(iface)
+}
+
 func getAttrType(f reflect.StructField) AttributeType {
+	if typeTag, ok := f.Tag.Lookup("dbtype"); ok {
+		return getTaggedAttrType(f.Name, typeTag)
+	}
+
 	t := f.Type
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	// Custom marshalers take precedence over text
+	// marshalers which are marshaled into strings.
+	// Their type is inferred from their kind, or
+	// from their marshaled zero value.
+	if implements(t, marshalerType) {
+		if at, ok := kindAttrType(t); ok {
+			return at
+		} else if at, ok := marshaledAttrType(t); ok {
+			return at
+		}
+		panic(fmt.Errorf("dynami: cannot infer the type of key field (%v), use a dbtype tag", f.Name))
+	} else if implements(t, textMarshalerType) {
+		return StringType
+	}
+
+	at, ok := kindAttrType(t)
+	if !ok {
+		panic(fmt.Errorf("dynami: key field (%v) must be a byte slice, number or string", f.Name))
+	}
+	return at
+}
+
+// kindAttrType returns the attribute
+// type of a key field given its kind.
+func kindAttrType(t reflect.Type) (AttributeType, bool) {
 	switch t.Kind() {
 	case reflect.String:
-		return StringType
+		return StringType, true
 	case reflect.Int, reflect.Int8, reflect.Int16,
 		reflect.Int32, reflect.Int64, reflect.Uint,
 		reflect.Uint8, reflect.Uint16, reflect.Uint32,
 		reflect.Uint64, reflect.Float32, reflect.Float64:
-		return NumberType
+		return NumberType, true
 	case reflect.Array, reflect.Slice:
-		et := t.Elem().Kind()
-		if et != reflect.Uint8 {
-			panic(fmt.Errorf("dynami: key field (%v) must be a byte slice, number or string", f.Name))
+		if t.Elem().Kind() == reflect.Uint8 {
+			return BinaryType, true
 		}
+	}
+
+	return "", false
+}
+
+// marshaledAttrType returns the attribute type of
+// the marshaled zero value of t. This returns false
+// if it can't be marshaled into a scalar attribute.
+func marshaledAttrType(t reflect.Type) (AttributeType, bool) {
+	av, err := dbattribute.Marshal(reflect.New(t).Interface())
+	switch {
+	case err != nil:
+		return "", false
+	case av.S != nil:
+		return StringType, true
+	case av.N != nil:
+		return NumberType, true
+	case av.B != nil:
+		return BinaryType, true
+	}
+
+	return "", false
+}
+
+// getTaggedAttrType returns the attribute
+// type given by the dbtype tag of a key field.
+func getTaggedAttrType(name, typeTag string) AttributeType {
+	switch typeTag {
+	case tagStringType:
 		return StringType
+	case tagNumberType:
+		return NumberType
+	case tagBinaryType:
+		return BinaryType
 	default:
-		panic(fmt.Errorf("dynami: key field (%v) must be a byte slice, number or string", f.Name))
+		panic(fmt.Errorf("dynami: invalid dbtype tag (%v) on struct field (%v)", typeTag, name))
 	}
 }
+
+func isIntType(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16,
+		reflect.Int32, reflect.Int64, reflect.Uint,
+		reflect.Uint8, reflect.Uint16, reflect.Uint32,
+		reflect.Uint64:
+		return true
+	}
+
+	return false
+}
@@ -6,6 +6,9 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/aws/aws-sdk-go/aws"
+	db "github.com/aws/aws-sdk-go/service/dynamodb"
 )
 
 func TestFieldTags(t *testing.T) {
@@ -98,3 +101,97 @@ func TestFieldTags(t *testing.T) {
 		assert.Contains(t, expectedGlobalIdxs, actualIdx)
 	}
 }
+
+func TestVersionTag(t *testing.T) {
+	type tStruct struct {
+		Hash    string `dbkey:"hash"`
+		Version int    `dbversion:"true" json:"version"`
+	}
+
+	s := GetSchema(tStruct{})
+	assert.Equal(t, "version", s.VersionAttribute)
+
+	type tInvalid struct {
+		Hash    string `dbkey:"hash"`
+		Version string `dbversion:"true"`
+	}
+	assert.Panics(t, func() { GetSchema(tInvalid{}) })
+
+	type tNoVersion struct {
+		Hash string `dbkey:"hash"`
+	}
+	s = GetSchema(tNoVersion{})
+	assert.Equal(t, "", s.VersionAttribute)
+}
+
+type tTextKey [4]byte
+
+func (k tTextKey) MarshalText() ([]byte, error) {
+	return []byte("text"), nil
+}
+
+type tNumberKey struct{ n int }
+
+func (k *tNumberKey) MarshalDynamoDBAttributeValue(av *db.AttributeValue) error {
+	av.N = aws.String("0")
+	return nil
+}
+
+type tStringID string
+
+func (id tStringID) MarshalDynamoDBAttributeValue(av *db.AttributeValue) error {
+	av.S = aws.String("id-" + string(id))
+	return nil
+}
+
+type tNullKey struct{ s string }
+
+func (k tNullKey) MarshalDynamoDBAttributeValue(av *db.AttributeValue) error {
+	if k.s == "" {
+		av.NULL = aws.Bool(true)
+		return nil
+	}
+
+	av.S = aws.String(k.s)
+	return nil
+}
+
+func TestMarshaledKeyTypes(t *testing.T) {
+	type tStruct struct {
+		Hash  *string    `dbkey:"hash"`
+		Range tNumberKey `dbkey:"range" dbtype:"number"`
+
+		Text tTextKey `dbindex:"hash,GlobalIndex"`
+	}
+
+	s := GetSchema(tStruct{})
+	assert.Contains(t, s.Attributes, Attribute{"Hash", StringType})
+	assert.Contains(t, s.Attributes, Attribute{"Range", NumberType})
+	assert.Contains(t, s.Attributes, Attribute{"Text", StringType})
+
+	// Without a dbtype tag, the type is inferred
+	// from the kind or the marshaled zero value
+	type tUntyped struct {
+		Hash  tStringID  `dbkey:"hash"`
+		Range tNumberKey `dbkey:"range"`
+	}
+	s = GetSchema(tUntyped{})
+	assert.Contains(t, s.Attributes, Attribute{"Hash", StringType})
+	assert.Contains(t, s.Attributes, Attribute{"Range", NumberType})
+
+	type tNull struct {
+		Hash tNullKey `dbkey:"hash"`
+	}
+	assert.Panics(t, func() { GetSchema(tNull{}) })
+
+	type tTagged struct {
+		Hash tNullKey `dbkey:"hash" dbtype:"string"`
+	}
+	s = GetSchema(tTagged{})
+	assert.Contains(t, s.Attributes, Attribute{"Hash", StringType})
+
+	type tInvalidType struct {
+		Hash tNumberKey `dbkey:"hash" dbtype:"map"`
+	}
+	assert.Panics(t, func() { GetSchema(tInvalidType{}) })
+}
@@ -0,0 +1,200 @@
+package dynami
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+
+	db "github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// StringSet is a set of strings. It is stored as a
+// string set (SS) attribute instead of a list. Like
+// all sets, duplicate elements are removed and an
+// empty set is not stored since DynamoDB doesn't
+// allow empty sets.
+type StringSet []string
+
+// MarshalDynamoDBAttributeValue implements
+// the dynamodbattribute.Marshaler interface.
+func (s StringSet) MarshalDynamoDBAttributeValue(av *db.AttributeValue) error {
+	return marshalSetInto(av, reflect.ValueOf([]string(s)))
+}
+
+// UnmarshalDynamoDBAttributeValue implements the
+// dynamodbattribute.Unmarshaler interface. A list
+// of strings can also be unmarshaled into a set.
+func (s *StringSet) UnmarshalDynamoDBAttributeValue(av *db.AttributeValue) error {
+	*s = nil
+	if av == nil || av.NULL != nil {
+		return nil
+	}
+
+	values := av.SS
+	if av.SS == nil && av.L != nil {
+		for _, e := range av.L {
+			if e.S == nil {
+				return fmt.Errorf("dynami: cannot unmarshal list into a string set")
+			}
+			values = append(values, e.S)
+		}
+	} else if av.SS == nil {
+		return fmt.Errorf("dynami: cannot unmarshal attribute into a string set")
+	}
+
+	for _, v := range values {
+		*s = append(*s, *v)
+	}
+	return nil
+}
+
+// NumberSet is a set of numbers. It is
+// stored as a number set (NS) attribute.
+type NumberSet []float64
+
+// MarshalDynamoDBAttributeValue implements
+// the dynamodbattribute.Marshaler interface.
+func (s NumberSet) MarshalDynamoDBAttributeValue(av *db.AttributeValue) error {
+	return marshalSetInto(av, reflect.ValueOf([]float64(s)))
+}
+
+// UnmarshalDynamoDBAttributeValue implements the
+// dynamodbattribute.Unmarshaler interface. A list
+// of numbers can also be unmarshaled into a set.
+func (s *NumberSet) UnmarshalDynamoDBAttributeValue(av *db.AttributeValue) error {
+	*s = nil
+	if av == nil || av.NULL != nil {
+		return nil
+	}
+
+	values := av.NS
+	if av.NS == nil && av.L != nil {
+		for _, e := range av.L {
+			if e.N == nil {
+				return fmt.Errorf("dynami: cannot unmarshal list into a number set")
+			}
+			values = append(values, e.N)
+		}
+	} else if av.NS == nil {
+		return fmt.Errorf("dynami: cannot unmarshal attribute into a number set")
+	}
+
+	for _, v := range values {
+		n, err := strconv.ParseFloat(*v, 64)
+		if err != nil {
+			return fmt.Errorf("dynami: invalid number (%w)", err)
+		}
+		*s = append(*s, n)
+	}
+	return nil
+}
+
+// BinarySet is a set of byte slices. It
+// is stored as a binary set (BS) attribute.
+type BinarySet [][]byte
+
+// MarshalDynamoDBAttributeValue implements
+// the dynamodbattribute.Marshaler interface.
+func (s BinarySet) MarshalDynamoDBAttributeValue(av *db.AttributeValue) error {
+	return marshalSetInto(av, reflect.ValueOf([][]byte(s)))
+}
+
+// UnmarshalDynamoDBAttributeValue implements the
+// dynamodbattribute.Unmarshaler interface. A list
+// of binary values can also be unmarshaled into a set.
+func (s *BinarySet) UnmarshalDynamoDBAttributeValue(av *db.AttributeValue) error {
+	*s = nil
+	if av == nil || av.NULL != nil {
+		return nil
+	}
+
+	values := av.BS
+	if av.BS == nil && av.L != nil {
+		for _, e := range av.L {
+			if e.B == nil {
+				return fmt.Errorf("dynami: cannot unmarshal list into a binary set")
+			}
+			values = append(values, e.B)
+		}
+	} else if av.BS == nil {
+		return fmt.Errorf("dynami: cannot unmarshal attribute into a binary set")
+	}
+
+	for _, v := range values {
+		*s = append(*s, append([]byte{}, v...))
+	}
+	return nil
+}
+
+// marshalSetInto marshals v into av using marshalSet.
+func marshalSetInto(av *db.AttributeValue, v reflect.Value) error {
+	set, err := marshalSet(v)
+	if err != nil {
+		return err
+	}
+
+	*av = *set
+	return nil
+}
+
+// isSetValue returns true if v is a slice or array that
+// is marshaled into a set by Update.Add and Update.Delete.
+// Byte slices and types that implement their own marshaler
+// are excluded.
+func isSetValue(v reflect.Value) bool {
+	if !v.IsValid() ||
+		(v.Kind() != reflect.Slice && v.Kind() != reflect.Array) ||
+		implements(v.Type(), marshalerType) {
+		return false
+	}
+
+	return v.Type().Elem().Kind() != reflect.Uint8
+}
+
+// marshalSet marshals the elements of slice or array v
+// into a string, number, or binary set depending on their
+// marshaled types. Duplicate elements and nil pointers are
+// removed. An empty set is marshaled into a string set without
+// any elements so that it is never stored.
+func marshalSet(v reflect.Value) (*db.AttributeValue, error) {
+	for v.Kind() == reflect.Ptr && !v.IsNil() {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return nil, fmt.Errorf("cannot marshal %v into a set", v.Type())
+	}
+
+	av := &db.AttributeValue{}
+	seen := map[string]bool{}
+	for i := 0; i < v.Len(); i++ {
+		elem, err := marshalValue(v.Index(i).Interface())
+		if err != nil {
+			return nil, err
+		} else if elem.NULL != nil {
+			continue
+		}
+
+		key := keyString(elem)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		switch {
+		case elem.S != nil && av.NS == nil && av.BS == nil:
+			av.SS = append(av.SS, elem.S)
+		case elem.N != nil && av.SS == nil && av.BS == nil:
+			av.NS = append(av.NS, elem.N)
+		case elem.B != nil && av.SS == nil && av.NS == nil:
+			av.BS = append(av.BS, elem.B)
+		default:
+			return nil, fmt.Errorf("cannot marshal %v into a set", v.Type())
+		}
+	}
+
+	if len(av.SS) == 0 && len(av.NS) == 0 && len(av.BS) == 0 {
+		return &db.AttributeValue{SS: []*string{}}, nil
+	}
+
+	return av, nil
+}
@@ -0,0 +1,145 @@
+package dynami
+
+import (
+	sc "github.com/robskie/dynami/schema"
+
+	"github.com/aws/aws-sdk-go/aws"
+	db "github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+type tSetInfo struct {
+	Codes []string `dbset:"true"`
+}
+
+type tSet struct {
+	Key    string   `dbkey:"hash"`
+	Tags   []string `dbset:"true"`
+	Scores []int    `dynamodbav:",numberset"`
+	Labels StringSet
+	Empty  []string `dbset:"true"`
+	Info   tSetInfo
+}
+
+func (suite *DatabaseTestSuite) TestSets() {
+	assert := suite.Assert()
+	require := suite.Require()
+
+	table := sc.NewTable("SetTable", tSet{}, map[string]sc.Throughput{
+		"SetTable": {Read: 10, Write: 10},
+	})
+
+	c := suite.client
+	err := c.CreateTable(table)
+	require.Nil(err)
+
+	item := tSet{
+		Key:    "a",
+		Tags:   []string{"go", "aws", "go"},
+		Scores: []int{1, 2},
+		Labels: StringSet{"x"},
+		Info:   tSetInfo{Codes: []string{"c1"}},
+	}
+	err = c.PutItem("SetTable", item)
+	require.Nil(err)
+
+	resp, err := suite.db.GetItem(&db.GetItemInput{
+		TableName: aws.String("SetTable"),
+		Key:       dbitem{"Key": {S: aws.String("a")}},
+	})
+	require.Nil(err)
+	assert.Len(resp.Item["Tags"].SS, 2)
+	assert.Len(resp.Item["Scores"].NS, 2)
+	assert.Len(resp.Item["Labels"].SS, 1)
+	assert.Len(resp.Item["Info"].M["Codes"].SS, 1)
+	assert.NotContains(resp.Item, "Empty")
+
+	fetched := tSet{Key: "a"}
+	err = c.GetItem("SetTable", &fetched)
+	require.Nil(err)
+	assert.ElementsMatch([]string{"go", "aws"}, fetched.Tags)
+	assert.ElementsMatch(item.Scores, fetched.Scores)
+	assert.Equal(item.Labels, fetched.Labels)
+	assert.Equal(item.Info, fetched.Info)
+	assert.Nil(fetched.Empty)
+
+	// Map items can use the set types
+	err = c.PutItem("SetTable", map[string]interface{}{
+		"Key":    "b",
+		"Tags":   StringSet{"go"},
+		"Scores": NumberSet{3},
+		"Labels": StringSet{},
+	})
+	require.Nil(err)
+
+	it := c.Query("SetTable").
+		Filter("contains(Tags, :tag)", "aws").
+		Run()
+	require.True(it.HasNext())
+	fetched = tSet{}
+	require.Nil(it.Next(&fetched))
+	assert.Equal("a", fetched.Key)
+	assert.False(it.HasNext())
+
+	it = c.Query("SetTable").
+		Filter("size(Tags) BETWEEN :lo AND :hi", 1, 1).
+		Run()
+	require.True(it.HasNext())
+	fetched = tSet{}
+	require.Nil(it.Next(&fetched))
+	assert.Equal("b", fetched.Key)
+	assert.Nil(fetched.Labels)
+	assert.False(it.HasNext())
+
+	// Slices are added and deleted as sets
+	var updated tSet
+	err = c.Update("SetTable", tSet{Key: "a"}).
+		Add("Tags", []string{"db", "go"}).
+		Delete("Scores", []int{1}).
+		Add("Labels", StringSet{"y"}).
+		Run(&updated)
+	require.Nil(err)
+	assert.ElementsMatch([]string{"go", "aws", "db"}, updated.Tags)
+	assert.Equal([]int{2}, updated.Scores)
+	assert.ElementsMatch(StringSet{"x", "y"}, updated.Labels)
+
+	// Set values are marshaled as sets in filters
+	it = c.Query("SetTable").
+		Filter("Labels = :labels", StringSet{"y", "x"}).
+		Run()
+	require.True(it.HasNext())
+	fetched = tSet{}
+	require.Nil(it.Next(&fetched))
+	assert.Equal("a", fetched.Key)
+	assert.False(it.HasNext())
+
+	// Empty sets are not stored even if nulls are kept
+	kc, err := NewClientFromAPI(suite.db, nil, WithEmptyValues(KeepNulls))
+	require.Nil(err)
+
+	err = kc.PutItem("SetTable", tSet{
+		Key:    "c",
+		Tags:   []string{},
+		Labels: StringSet{},
+	})
+	require.Nil(err)
+
+	resp, err = suite.db.GetItem(&db.GetItemInput{
+		TableName: aws.String("SetTable"),
+		Key:       dbitem{"Key": {S: aws.String("c")}},
+	})
+	require.Nil(err)
+	assert.NotContains(resp.Item, "Tags")
+	assert.NotContains(resp.Item, "Labels")
+	assert.NotContains(resp.Item, "Empty")
+	assert.NotContains(resp.Item["Info"].M, "Codes")
+
+	err = c.Update("SetTable", tSet{Key: "a"}).
+		Add("Tags", []string{}).
+		Run(nil)
+	assert.NotNil(err)
+
+	err = c.Update("SetTable", tSet{Key: "a"}).
+		Add("Tags", []interface{}{"a", 1}).
+		Run(nil)
+	assert.NotNil(err)
+}
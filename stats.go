@@ -0,0 +1,356 @@
+package dynami
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	db "github.com/aws/aws-sdk-go/service/dynamodb"
+	dbiface "github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+)
+
+// Capacity contains consumed read and write capacity units.
+type Capacity struct {
+	Read  float64
+	Write float64
+}
+
+// TableStats contains the capacity consumed on a table.
+type TableStats struct {
+	// Capacity is the capacity consumed
+	// on the table excluding its indexes.
+	Capacity Capacity
+
+	// Indexes contains the capacity consumed on
+	// each local and global secondary index.
+	Indexes map[string]Capacity
+}
+
+// Stats contains the capacity consumed by one or more
+// requests and the number of items that they read.
+type Stats struct {
+	// Tables contains the capacity
+	// consumed on each table.
+	Tables map[string]TableStats
+
+	// Count is the number of items returned.
+	Count int
+
+	// ScannedCount is the number of items evaluated
+	// by queries and scans before filters are applied.
+	// This is greater than Count if items are filtered.
+	ScannedCount int
+}
+
+// Total returns the capacity consumed
+// on every table and index.
+func (s Stats) Total() Capacity {
+	var total Capacity
+	for _, ts := range s.Tables {
+		total.Read += ts.Capacity.Read
+		total.Write += ts.Capacity.Write
+		for _, c := range ts.Indexes {
+			total.Read += c.Read
+			total.Write += c.Write
+		}
+	}
+
+	return total
+}
+
+// Add adds the stats of other requests to s.
+func (s *Stats) Add(other Stats) {
+	s.Count += other.Count
+	s.ScannedCount += other.ScannedCount
+
+	for table, ots := range other.Tables {
+		ts := s.table(table)
+		ts.Capacity.Read += ots.Capacity.Read
+		ts.Capacity.Write += ots.Capacity.Write
+		for index, c := range ots.Indexes {
+			ic := ts.Indexes[index]
+			ic.Read += c.Read
+			ic.Write += c.Write
+			ts.Indexes[index] = ic
+		}
+		s.Tables[table] = ts
+	}
+}
+
+// clone returns a deep copy of s.
+func (s Stats) clone() Stats {
+	var c Stats
+	c.Add(s)
+	return c
+}
+
+// table returns the stats of the given table. The
+// returned stats must be stored back to s.Tables.
+func (s *Stats) table(name string) TableStats {
+	if s.Tables == nil {
+		s.Tables = map[string]TableStats{}
+	}
+
+	ts := s.Tables[name]
+	if ts.Indexes == nil {
+		ts.Indexes = map[string]Capacity{}
+	}
+	return ts
+}
+
+// capacityUnits returns the read or write units of c.
+// The units are in CapacityUnits if DynamoDB doesn't
+// report reads and writes separately.
+func capacityUnits(c *db.Capacity, write bool) float64 {
+	if c == nil {
+		return 0
+	} else if write && c.WriteCapacityUnits != nil {
+		return *c.WriteCapacityUnits
+	} else if !write && c.ReadCapacityUnits != nil {
+		return *c.ReadCapacityUnits
+	}
+
+	return aws.Float64Value(c.CapacityUnits)
+}
+
+// addCapacity adds the given consumed capacities as
+// either reads or writes depending on the request.
+func (s *Stats) addCapacity(ccs []*db.ConsumedCapacity, write bool) {
+	add := func(c *Capacity, units float64) {
+		if write {
+			c.Write += units
+		} else {
+			c.Read += units
+		}
+	}
+
+	for _, cc := range ccs {
+		if cc == nil || cc.TableName == nil {
+			continue
+		}
+
+		ts := s.table(*cc.TableName)
+		if cc.Table != nil {
+			add(&ts.Capacity, capacityUnits(cc.Table, write))
+		} else {
+			add(&ts.Capacity, aws.Float64Value(cc.CapacityUnits))
+		}
+
+		for _, indexes := range []map[string]*db.Capacity{
+			cc.LocalSecondaryIndexes,
+			cc.GlobalSecondaryIndexes,
+		} {
+			for index, c := range indexes {
+				ic := ts.Indexes[index]
+				add(&ic, capacityUnits(c, write))
+				ts.Indexes[index] = ic
+			}
+		}
+
+		s.Tables[*cc.TableName] = ts
+	}
+}
+
+// StatsCollector collects the stats of every request sent by a
+// client. op is the name of the DynamoDB operation, eg. "Query".
+// Collect may be called concurrently from multiple goroutines.
+type StatsCollector interface {
+	Collect(op string, stats Stats)
+}
+
+// StatsCollectorFunc is a function that implements StatsCollector.
+type StatsCollectorFunc func(op string, stats Stats)
+
+// Collect calls f(op, stats).
+func (f StatsCollectorFunc) Collect(op string, stats Stats) {
+	f(op, stats)
+}
+
+// collectedDB is a DynamoDB API that sends
+// the stats of each request to a collector. Like
+// limitedDB, it works on a copy of each input.
+type collectedDB struct {
+	dbiface.DynamoDBAPI
+
+	c StatsCollector
+}
+
+func (d *collectedDB) unwrap() dbiface.DynamoDBAPI {
+	return d.DynamoDBAPI
+}
+
+func (d *collectedDB) collect(
+	op string,
+	ccs []*db.ConsumedCapacity,
+	write bool,
+	count int,
+	scanned int) {
+
+	stats := Stats{Count: count, ScannedCount: scanned}
+	stats.addCapacity(ccs, write)
+	d.c.Collect(op, stats)
+}
+
+func (d *collectedDB) GetItemWithContext(
+	ctx aws.Context,
+	input *db.GetItemInput,
+	opts ...request.Option) (*db.GetItemOutput, error) {
+
+	in := *input
+	in.ReturnConsumedCapacity = returnCapacity(input.ReturnConsumedCapacity)
+	out, err := d.DynamoDBAPI.GetItemWithContext(ctx, &in, opts...)
+	if err == nil {
+		count := 0
+		if len(out.Item) > 0 {
+			count = 1
+		}
+		d.collect("GetItem", []*db.ConsumedCapacity{out.ConsumedCapacity}, false, count, 0)
+	}
+	return out, err
+}
+
+func (d *collectedDB) QueryWithContext(
+	ctx aws.Context,
+	input *db.QueryInput,
+	opts ...request.Option) (*db.QueryOutput, error) {
+
+	in := *input
+	in.ReturnConsumedCapacity = returnCapacity(input.ReturnConsumedCapacity)
+	out, err := d.DynamoDBAPI.QueryWithContext(ctx, &in, opts...)
+	if err == nil {
+		d.collect(
+			"Query",
+			[]*db.ConsumedCapacity{out.ConsumedCapacity},
+			false,
+			int(aws.Int64Value(out.Count)),
+			int(aws.Int64Value(out.ScannedCount)),
+		)
+	}
+	return out, err
+}
+
+func (d *collectedDB) ScanWithContext(
+	ctx aws.Context,
+	input *db.ScanInput,
+	opts ...request.Option) (*db.ScanOutput, error) {
+
+	in := *input
+	in.ReturnConsumedCapacity = returnCapacity(input.ReturnConsumedCapacity)
+	out, err := d.DynamoDBAPI.ScanWithContext(ctx, &in, opts...)
+	if err == nil {
+		d.collect(
+			"Scan",
+			[]*db.ConsumedCapacity{out.ConsumedCapacity},
+			false,
+			int(aws.Int64Value(out.Count)),
+			int(aws.Int64Value(out.ScannedCount)),
+		)
+	}
+	return out, err
+}
+
+func (d *collectedDB) BatchGetItemWithContext(
+	ctx aws.Context,
+	input *db.BatchGetItemInput,
+	opts ...request.Option) (*db.BatchGetItemOutput, error) {
+
+	in := *input
+	in.ReturnConsumedCapacity = returnCapacity(input.ReturnConsumedCapacity)
+	out, err := d.DynamoDBAPI.BatchGetItemWithContext(ctx, &in, opts...)
+	if err == nil {
+		count := 0
+		for _, items := range out.Responses {
+			count += len(items)
+		}
+		d.collect("BatchGetItem", out.ConsumedCapacity, false, count, 0)
+	}
+	return out, err
+}
+
+func (d *collectedDB) TransactGetItemsWithContext(
+	ctx aws.Context,
+	input *db.TransactGetItemsInput,
+	opts ...request.Option) (*db.TransactGetItemsOutput, error) {
+
+	in := *input
+	in.ReturnConsumedCapacity = returnCapacity(input.ReturnConsumedCapacity)
+	out, err := d.DynamoDBAPI.TransactGetItemsWithContext(ctx, &in, opts...)
+	if err == nil {
+		count := 0
+		for _, resp := range out.Responses {
+			if resp != nil && len(resp.Item) > 0 {
+				count++
+			}
+		}
+		d.collect("TransactGetItems", out.ConsumedCapacity, false, count, 0)
+	}
+	return out, err
+}
+
+func (d *collectedDB) PutItemWithContext(
+	ctx aws.Context,
+	input *db.PutItemInput,
+	opts ...request.Option) (*db.PutItemOutput, error) {
+
+	in := *input
+	in.ReturnConsumedCapacity = returnCapacity(input.ReturnConsumedCapacity)
+	out, err := d.DynamoDBAPI.PutItemWithContext(ctx, &in, opts...)
+	if err == nil {
+		d.collect("PutItem", []*db.ConsumedCapacity{out.ConsumedCapacity}, true, 0, 0)
+	}
+	return out, err
+}
+
+func (d *collectedDB) DeleteItemWithContext(
+	ctx aws.Context,
+	input *db.DeleteItemInput,
+	opts ...request.Option) (*db.DeleteItemOutput, error) {
+
+	in := *input
+	in.ReturnConsumedCapacity = returnCapacity(input.ReturnConsumedCapacity)
+	out, err := d.DynamoDBAPI.DeleteItemWithContext(ctx, &in, opts...)
+	if err == nil {
+		d.collect("DeleteItem", []*db.ConsumedCapacity{out.ConsumedCapacity}, true, 0, 0)
+	}
+	return out, err
+}
+
+func (d *collectedDB) UpdateItemWithContext(
+	ctx aws.Context,
+	input *db.UpdateItemInput,
+	opts ...request.Option) (*db.UpdateItemOutput, error) {
+
+	in := *input
+	in.ReturnConsumedCapacity = returnCapacity(input.ReturnConsumedCapacity)
+	out, err := d.DynamoDBAPI.UpdateItemWithContext(ctx, &in, opts...)
+	if err == nil {
+		d.collect("UpdateItem", []*db.ConsumedCapacity{out.ConsumedCapacity}, true, 0, 0)
+	}
+	return out, err
+}
+
+func (d *collectedDB) BatchWriteItemWithContext(
+	ctx aws.Context,
+	input *db.BatchWriteItemInput,
+	opts ...request.Option) (*db.BatchWriteItemOutput, error) {
+
+	in := *input
+	in.ReturnConsumedCapacity = returnCapacity(input.ReturnConsumedCapacity)
+	out, err := d.DynamoDBAPI.BatchWriteItemWithContext(ctx, &in, opts...)
+	if err == nil {
+		d.collect("BatchWriteItem", out.ConsumedCapacity, true, 0, 0)
+	}
+	return out, err
+}
+
+func (d *collectedDB) TransactWriteItemsWithContext(
+	ctx aws.Context,
+	input *db.TransactWriteItemsInput,
+	opts ...request.Option) (*db.TransactWriteItemsOutput, error) {
+
+	in := *input
+	in.ReturnConsumedCapacity = returnCapacity(input.ReturnConsumedCapacity)
+	out, err := d.DynamoDBAPI.TransactWriteItemsWithContext(ctx, &in, opts...)
+	if err == nil {
+		d.collect("TransactWriteItems", out.ConsumedCapacity, true, 0, 0)
+	}
+	return out, err
+}
@@ -0,0 +1,135 @@
+package dynami
+
+import (
+	"context"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	db "github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+func (suite *DatabaseTestSuite) TestStats() {
+	assert := suite.Assert()
+	require := suite.Require()
+
+	books := make([]tBook, 10)
+	for i := range books {
+		books[i] = tBook{
+			Title:  randString(10),
+			Author: "Author",
+			Genre:  []string{"Fiction", "Classics"}[i%2],
+		}
+	}
+
+	c := suite.client
+	put := c.BatchPut("Book", books)
+	err := put.Run()
+	require.Nil(err)
+
+	// Writes also consume the capacity of indexes
+	stats := put.Stats()
+	require.Contains(stats.Tables, "Book")
+	assert.True(stats.Tables["Book"].Capacity.Write >= float64(len(books)))
+	assert.True(stats.Tables["Book"].Indexes["GenreIndex"].Write > 0)
+	assert.True(stats.Total().Write > stats.Tables["Book"].Capacity.Write)
+	assert.Zero(stats.Total().Read)
+
+	fetched := make([]tBook, len(books))
+	for i, b := range books {
+		fetched[i] = tBook{Title: b.Title, Author: b.Author}
+	}
+	get := c.BatchGet("Book", fetched, true)
+	err = get.Run()
+	require.Nil(err)
+
+	stats = get.Stats()
+	assert.Equal(len(books), stats.Count)
+	assert.True(stats.Total().Read > 0)
+
+	// Filtered items are scanned but not returned
+	it := c.Query("Book").
+		Index("AuthorIndex").
+		HashFilter("Author", "Author").
+		Filter("Genre = :genre", "Fiction").
+		Run()
+
+	count := 0
+	for it.HasNext() {
+		require.Nil(it.Next(nil))
+		count++
+	}
+	require.Nil(it.Err())
+
+	stats = it.Stats()
+	assert.Equal(count, stats.Count)
+	assert.Equal(len(books), stats.ScannedCount)
+	assert.True(stats.Tables["Book"].Indexes["AuthorIndex"].Read > 0)
+	assert.Zero(stats.Tables["Book"].Capacity.Read)
+
+	// Parallel scans
+	it = c.Query("Book").Parallel(3).Run()
+	for it.HasNext() {
+		require.Nil(it.Next(nil))
+	}
+	require.Nil(it.Err())
+	assert.Equal(len(books), it.Stats().Count)
+	assert.True(it.Stats().Total().Read > 0)
+}
+
+func (suite *DatabaseTestSuite) TestStatsCollector() {
+	assert := suite.Assert()
+	require := suite.Require()
+
+	var mu sync.Mutex
+	collected := map[string]Stats{}
+	collector := StatsCollectorFunc(func(op string, stats Stats) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		s := collected[op]
+		s.Add(stats)
+		collected[op] = s
+	})
+
+	c, err := NewClientFromAPI(suite.db, nil, WithStatsCollector(collector))
+	require.Nil(err)
+
+	book := tBook{Title: "Dracula", Author: "Bram Stoker", Genre: "Horror"}
+	err = c.PutItem("Book", book)
+	require.Nil(err)
+
+	fetched := tBook{Title: book.Title, Author: book.Author}
+	err = c.GetItem("Book", &fetched)
+	require.Nil(err)
+
+	// Fetch using an index
+	fetched = tBook{Title: book.Title, Genre: book.Genre}
+	err = c.GetItem("Book", &fetched)
+	require.Nil(err)
+
+	err = c.DeleteItem("Book", book)
+	require.Nil(err)
+
+	require.Contains(collected, "PutItem")
+	assert.True(collected["PutItem"].Total().Write > 0)
+	assert.Equal(1, collected["GetItem"].Count)
+	assert.True(collected["GetItem"].Total().Read > 0)
+	assert.Equal(1, collected["Query"].Count)
+	assert.True(collected["DeleteItem"].Total().Write > 0)
+
+	// The caller's input is not modified
+	fake := &tCapacityDB{DynamoDBAPI: suite.db, units: 1}
+	cdb := &collectedDB{DynamoDBAPI: fake, c: collector}
+	input := &db.PutItemInput{
+		TableName:              aws.String("Quote"),
+		Item:                   dbitem{"Author": {S: aws.String("a")}, "Text": {S: aws.String("b")}},
+		ReturnConsumedCapacity: aws.String(db.ReturnConsumedCapacityNone),
+	}
+	_, err = cdb.PutItemWithContext(context.Background(), input)
+	require.Nil(err)
+	assert.Equal(db.ReturnConsumedCapacityIndexes, fake.mode)
+	assert.Equal(db.ReturnConsumedCapacityNone, aws.StringValue(input.ReturnConsumedCapacity))
+
+	_, err = NewClientFromAPI(suite.db, nil, WithStatsCollector(nil))
+	assert.NotNil(err)
+}
@@ -0,0 +1,288 @@
+package dynami
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+)
+
+// Table is a handle to a table whose items are of type T.
+// It provides the item, batch, query and stream operations
+// of a client without the runtime type checks of their
+// interface{} arguments. T must be a struct type.
+type Table[T any] struct {
+	c    *Client
+	name string
+
+	err error
+}
+
+// NewTable returns a handle to the given table of c.
+// If T is not a struct type, every operation of the
+// returned table fails.
+func NewTable[T any](c *Client, tableName string) *Table[T] {
+	t := &Table[T]{c: c, name: tableName}
+	if tableName == "" {
+		t.err = fmt.Errorf("dynami: empty table name")
+	} else if typ := reflect.TypeOf((*T)(nil)).Elem(); typ.Kind() != reflect.Struct {
+		t.err = fmt.Errorf("dynami: invalid type (%v)", typ)
+	}
+
+	return t
+}
+
+// Name returns the name of the table.
+func (t *Table[T]) Name() string {
+	return t.name
+}
+
+// Get returns the item with the same key as the given
+// key item. Like GetItem, the key can be the primary
+// key or a secondary index key.
+func (t *Table[T]) Get(ctx context.Context, key T, consistent ...bool) (T, error) {
+	var zero T
+	if t.err != nil {
+		return zero, t.err
+	}
+
+	item := key
+	if err := t.c.GetItemWithContext(ctx, t.name, &item, consistent...); err != nil {
+		return zero, err
+	}
+
+	return item, nil
+}
+
+// Put adds an item to the table. If the item is
+// versioned, the stored version is incremented but
+// the version of the given item is left unchanged.
+func (t *Table[T]) Put(ctx context.Context, item T) error {
+	if t.err != nil {
+		return t.err
+	}
+
+	return t.c.PutItemWithContext(ctx, t.name, item)
+}
+
+// PutIf is the same as Put except that the item is
+// only added if the condition expr is satisfied. See
+// Client.PutItemIf for details.
+func (t *Table[T]) PutIf(
+	ctx context.Context,
+	item T,
+	expr string,
+	values ...interface{}) error {
+
+	if t.err != nil {
+		return t.err
+	}
+
+	return t.c.PutItemIfWithContext(ctx, t.name, item, expr, values...)
+}
+
+// Delete removes the item with the same key as item.
+func (t *Table[T]) Delete(ctx context.Context, item T) error {
+	if t.err != nil {
+		return t.err
+	}
+
+	return t.c.DeleteItemWithContext(ctx, t.name, item)
+}
+
+// DeleteIf is the same as Delete except that the item
+// is only removed if the condition expr is satisfied.
+// See Client.DeleteItemIf for details.
+func (t *Table[T]) DeleteIf(
+	ctx context.Context,
+	item T,
+	expr string,
+	values ...interface{}) error {
+
+	if t.err != nil {
+		return t.err
+	}
+
+	return t.c.DeleteItemIfWithContext(ctx, t.name, item, expr, values...)
+}
+
+// Update returns an update for the item
+// with the same key as the given item.
+func (t *Table[T]) Update(key T) *Update {
+	if t.err != nil {
+		return &Update{err: t.err}
+	}
+
+	return t.c.Update(t.name, key)
+}
+
+// BatchGet returns the items with the same keys as
+// the given key items in the same order. This may
+// return a BatchError along with the fetched items,
+// eg. containing ErrNoSuchItem for missing items.
+func (t *Table[T]) BatchGet(ctx context.Context, keys []T, consistent ...bool) ([]T, error) {
+	if t.err != nil {
+		return nil, t.err
+	}
+
+	items := make([]T, len(keys))
+	copy(items, keys)
+
+	err := t.c.BatchGet(t.name, items, consistent...).RunWithContext(ctx)
+	return items, err
+}
+
+// BatchPut adds the given items to the
+// table. This may return a BatchError.
+func (t *Table[T]) BatchPut(ctx context.Context, items []T) error {
+	if t.err != nil {
+		return t.err
+	}
+
+	return t.c.BatchPut(t.name, items).RunWithContext(ctx)
+}
+
+// BatchDelete removes the given items from
+// the table. This may return a BatchError.
+func (t *Table[T]) BatchDelete(ctx context.Context, items []T) error {
+	if t.err != nil {
+		return t.err
+	}
+
+	return t.c.BatchDelete(t.name, items).RunWithContext(ctx)
+}
+
+// Query returns a new query for the table.
+func (t *Table[T]) Query() *TypedQuery[T] {
+	if t.err != nil {
+		return &TypedQuery[T]{q: &Query{err: t.err}}
+	}
+
+	return &TypedQuery[T]{q: t.c.Query(t.name)}
+}
+
+// Stream returns an iterator over
+// the stream records of the table.
+func (t *Table[T]) Stream(ctx context.Context) (*TypedRecordIterator[T], error) {
+	if t.err != nil {
+		return nil, t.err
+	}
+
+	it, err := t.c.GetStreamWithContext(ctx, t.name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TypedRecordIterator[T]{it}, nil
+}
+
+// TypedQuery is a query whose results are of type
+// T. Its methods are the same as those of Query.
+type TypedQuery[T any] struct {
+	q *Query
+}
+
+// Index is the same as Query.Index.
+func (q *TypedQuery[T]) Index(indexName string) *TypedQuery[T] {
+	q.q.Index(indexName)
+	return q
+}
+
+// Limit is the same as Query.Limit.
+func (q *TypedQuery[T]) Limit(limit int) *TypedQuery[T] {
+	q.q.Limit(limit)
+	return q
+}
+
+// Desc is the same as Query.Desc.
+func (q *TypedQuery[T]) Desc() *TypedQuery[T] {
+	q.q.Desc()
+	return q
+}
+
+// Consistent is the same as Query.Consistent.
+func (q *TypedQuery[T]) Consistent() *TypedQuery[T] {
+	q.q.Consistent()
+	return q
+}
+
+// Select is the same as Query.Select.
+func (q *TypedQuery[T]) Select(attrs ...string) *TypedQuery[T] {
+	q.q.Select(attrs...)
+	return q
+}
+
+// StartFrom is the same as Query.StartFrom.
+func (q *TypedQuery[T]) StartFrom(cursor string) *TypedQuery[T] {
+	q.q.StartFrom(cursor)
+	return q
+}
+
+// HashFilter is the same as Query.HashFilter.
+func (q *TypedQuery[T]) HashFilter(name string, value interface{}) *TypedQuery[T] {
+	q.q.HashFilter(name, value)
+	return q
+}
+
+// RangeFilter is the same as Query.RangeFilter.
+func (q *TypedQuery[T]) RangeFilter(expr string, values ...interface{}) *TypedQuery[T] {
+	q.q.RangeFilter(expr, values...)
+	return q
+}
+
+// Filter is the same as Query.Filter.
+func (q *TypedQuery[T]) Filter(expr string, values ...interface{}) *TypedQuery[T] {
+	q.q.Filter(expr, values...)
+	return q
+}
+
+// Parallel is the same as Query.Parallel.
+func (q *TypedQuery[T]) Parallel(segments int) *TypedQuery[T] {
+	q.q.Parallel(segments)
+	return q
+}
+
+// Run executes the query and returns an iterator over its results.
+func (q *TypedQuery[T]) Run() *TypedIterator[T] {
+	return &TypedIterator[T]{q.q.Run()}
+}
+
+// RunWithContext is the same as Run with
+// the addition of a request context.
+func (q *TypedQuery[T]) RunWithContext(ctx context.Context) *TypedIterator[T] {
+	return &TypedIterator[T]{q.q.RunWithContext(ctx)}
+}
+
+// TypedIterator is an ItemIterator whose items are of type T.
+type TypedIterator[T any] struct {
+	*ItemIterator
+}
+
+// Next returns the next result. This returns the
+// error that stopped the iteration, if any.
+func (it *TypedIterator[T]) Next() (T, error) {
+	var item T
+	if err := it.ItemIterator.Next(&item); err != nil {
+		var zero T
+		return zero, err
+	}
+
+	return item, nil
+}
+
+// TypedRecordIterator is a RecordIterator
+// whose records are of type T.
+type TypedRecordIterator[T any] struct {
+	*RecordIterator
+}
+
+// Next returns the next record and its type.
+func (it *TypedRecordIterator[T]) Next() (T, RecordType, error) {
+	var record T
+	rtype, err := it.RecordIterator.Next(&record)
+	if err != nil {
+		var zero T
+		return zero, rtype, err
+	}
+
+	return record, rtype, nil
+}
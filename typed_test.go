@@ -0,0 +1,107 @@
+package dynami
+
+import (
+	"context"
+
+	db "github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+func (suite *DatabaseTestSuite) TestTable() {
+	assert := suite.Assert()
+	require := suite.Require()
+
+	ctx := context.Background()
+	books := NewTable[tBook](suite.client, "Book")
+	assert.Equal("Book", books.Name())
+
+	book := tBook{
+		Title:  "The Picture of Dorian Gray",
+		Author: "Oscar Wilde",
+		Genre:  "Gothic",
+		Info:   tInfo{Publisher: "Lippincott", DatePublished: 1890},
+	}
+	err := books.Put(ctx, book)
+	require.Nil(err)
+
+	fetched, err := books.Get(ctx, tBook{Title: book.Title, Author: book.Author}, true)
+	require.Nil(err)
+	assert.Equal(book, fetched)
+
+	_, err = books.Get(ctx, tBook{Title: "Nothing", Author: "Nobody"})
+	assert.Equal(ErrNoSuchItem, err)
+
+	others := []tBook{
+		{Title: "The Castle of Otranto", Author: "Horace Walpole", Genre: "Gothic"},
+		{Title: "The Monk", Author: "Matthew Lewis", Genre: "Gothic"},
+	}
+	err = books.BatchPut(ctx, others)
+	require.Nil(err)
+
+	keys := []tBook{
+		{Title: others[0].Title, Author: others[0].Author},
+		{Title: others[1].Title, Author: others[1].Author},
+	}
+	fetchedAll, err := books.BatchGet(ctx, keys, true)
+	require.Nil(err)
+	assert.Equal(others, fetchedAll)
+	assert.Empty(keys[0].Genre)
+
+	it := books.Query().
+		Index("GenreIndex").
+		HashFilter("Genre", "Gothic").
+		Run()
+
+	results := []tBook{}
+	for it.HasNext() {
+		b, err := it.Next()
+		require.Nil(err)
+		results = append(results, b)
+	}
+	require.Nil(it.Err())
+	assert.Len(results, 3)
+
+	err = books.Delete(ctx, book)
+	require.Nil(err)
+	err = books.BatchDelete(ctx, others)
+	require.Nil(err)
+
+	_, err = books.Get(ctx, tBook{Title: book.Title, Author: book.Author}, true)
+	assert.Equal(ErrNoSuchItem, err)
+
+	// Only structs are allowed
+	invalid := NewTable[string](suite.client, "Book")
+	err = invalid.Put(ctx, "item")
+	assert.NotNil(err)
+	assert.NotNil(invalid.Query().Run().Err())
+}
+
+func (suite *DatabaseTestSuite) TestTableStream() {
+	assert := suite.Assert()
+	require := suite.Require()
+
+	err := createStreamTable(
+		suite.db,
+		"StreamTable",
+		db.StreamViewTypeNewAndOldImages,
+	)
+	require.Nil(err)
+
+	ctx := context.Background()
+	items := NewTable[tItem](suite.client, "StreamTable")
+
+	item := tItem{Key: randString(20), Value: randString(10)}
+	err = items.Put(ctx, item)
+	require.Nil(err)
+
+	it, err := items.Stream(ctx)
+	require.Nil(err)
+
+	require.True(it.HasNext())
+	record, rtype, err := it.Next()
+	require.Nil(err)
+	assert.Equal(AddedRecord, rtype)
+	assert.Equal(item, record)
+
+	_, err = NewTable[tItem](suite.client, "").Stream(ctx)
+	assert.NotNil(err)
+}
@@ -1,6 +1,7 @@
 package dynami
 
 import (
+	"errors"
 	"fmt"
 	"math/rand"
 	"reflect"
@@ -8,8 +9,9 @@ import (
 
 	sc "github.com/robskie/dynami/schema"
 
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	db "github.com/aws/aws-sdk-go/service/dynamodb"
-	dbattribute "github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
 )
 
 type indexType string
@@ -50,9 +52,9 @@ func getPrimaryKey(item interface{}) (*dbkey, error) {
 	val := reflect.Indirect(reflect.ValueOf(item))
 	item = val.Interface()
 
-	kv, err := dbattribute.MarshalMap(item)
+	kv, err := marshalItem(item)
 	if err != nil {
-		return nil, fmt.Errorf("dynami: invalid item (%v)", err)
+		return nil, fmt.Errorf("dynami: invalid item (%w)", err)
 	}
 
 	schema := sc.GetSchema(item)
@@ -63,7 +65,7 @@ func getPrimaryKey(item interface{}) (*dbkey, error) {
 			return nil, fmt.Errorf("dynami: key (%v) has no value", k.Name)
 		}
 
-		if isZeroValue(v) {
+		if isEmptyKey(v, kv[k.Name]) {
 			return nil, fmt.Errorf("dynami: incomplete primary key")
 		}
 		key.value[k.Name] = kv[k.Name]
@@ -80,9 +82,9 @@ func getSecondaryKey(item interface{}) (*dbkey, error) {
 	val := reflect.Indirect(reflect.ValueOf(item))
 	item = val.Interface()
 
-	kv, err := dbattribute.MarshalMap(item)
+	kv, err := marshalItem(item)
 	if err != nil {
-		return nil, fmt.Errorf("dynami: invalid item (%v)", err)
+		return nil, fmt.Errorf("dynami: invalid item (%w)", err)
 	}
 
 	key := &dbkey{value: dbitem{}}
@@ -97,8 +99,8 @@ Indices:
 	// Get secondary indices
 	for i, idx := range secondaryIdxs {
 		for _, k := range idx.Key {
-			v := val.FieldByName(k.Name)
-			if isZeroValue(v) {
+			v, _ := valueByName(val, k.Name)
+			if isEmptyKey(v, kv[k.Name]) {
 				key.value = dbitem{}
 				continue Indices
 			}
@@ -122,20 +124,126 @@ Indices:
 	return key, nil
 }
 
-func removeEmptyAttr(item dbitem) dbitem {
+// itemVersion contains the version check
+// of an item that has a version field.
+type itemVersion struct {
+	cond  *condition
+	field reflect.Value
+	value int64
+}
+
+// getVersion returns the version attribute name and the
+// version field of a struct item. name is empty if the item
+// has no field tagged with dbversion. The returned field is
+// only settable if item is a pointer.
+func getVersion(item interface{}) (string, reflect.Value) {
+	val := reflect.Indirect(reflect.ValueOf(item))
+	if val.Kind() != reflect.Struct {
+		return "", reflect.Value{}
+	}
+
+	name := sc.GetSchema(val.Interface()).VersionAttribute
+	if name == "" {
+		return "", reflect.Value{}
+	}
+
+	field, err := valueByName(val, name)
+	if err != nil {
+		return "", reflect.Value{}
+	}
+
+	return name, field
+}
+
+// increment increments the version field of
+// the item. This does nothing if v is nil.
+func (v *itemVersion) increment() {
+	if v != nil {
+		setVersionValue(v.field, v.value+1)
+	}
+}
+
+// conditionFailed returns the error for a write operation
+// whose condition cond or version check v has failed.
+func conditionFailed(cond *condition, v *itemVersion) error {
+	if cond == nil && v != nil && v.cond != nil {
+		return ErrVersionConflict
+	}
+
+	return ErrConditionFailed
+}
+
+func versionValue(field reflect.Value) int64 {
+	switch field.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16,
+		reflect.Uint32, reflect.Uint64:
+		return int64(field.Uint())
+	default:
+		return field.Int()
+	}
+}
+
+func setVersionValue(field reflect.Value, version int64) {
+	if !field.CanSet() {
+		return
+	}
+
+	switch field.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16,
+		reflect.Uint32, reflect.Uint64:
+		field.SetUint(uint64(version))
+	default:
+		field.SetInt(version)
+	}
+}
+
+// removeEmptyAttr removes or converts the empty attributes
+// of item, including those of its nested maps and lists,
+// according to mode. Empty sets are always removed.
+func removeEmptyAttr(item dbitem, mode EmptyValues) dbitem {
 	for attrName, attrValue := range item {
-		if attrValue.S != nil && *attrValue.S == "" {
-			delete(item, attrName)
-		} else if attrValue.NULL != nil && *attrValue.NULL == true {
+		if !removeEmptyValue(attrValue, mode) {
 			delete(item, attrName)
-		} else if attrValue.M != nil {
-			removeEmptyAttr(attrValue.M)
 		}
 	}
 
 	return item
 }
 
+// removeEmptyValue removes the empty values inside av
+// and returns false if av itself should be omitted.
+// Empty sets are omitted regardless of mode. List
+// elements can't be omitted without shifting the rest,
+// so they are replaced with nulls instead.
+func removeEmptyValue(av *db.AttributeValue, mode EmptyValues) bool {
+	switch {
+	case isEmptySet(av):
+		return false
+	case av.S != nil && *av.S == "":
+		return mode != OmitEmpty
+	case av.NULL != nil && *av.NULL == true:
+		return mode == KeepNulls
+	case av.M != nil:
+		removeEmptyAttr(av.M, mode)
+	case av.L != nil:
+		for i, e := range av.L {
+			if !removeEmptyValue(e, mode) {
+				av.L[i] = &db.AttributeValue{NULL: aws.Bool(true)}
+			}
+		}
+	}
+
+	return true
+}
+
+// isEmptySet returns true if av is a set without any
+// elements. DynamoDB doesn't allow storing empty sets.
+func isEmptySet(av *db.AttributeValue) bool {
+	return (av.SS != nil && len(av.SS) == 0) ||
+		(av.NS != nil && len(av.NS) == 0) ||
+		(av.BS != nil && len(av.BS) == 0)
+}
+
 func toPtr(v interface{}) interface{} {
 	switch vv := v.(type) {
 	case string:
@@ -163,8 +271,39 @@ func toPtr(v interface{}) interface{} {
 	}
 }
 
-func isZeroValue(val reflect.Value) bool {
-	return !val.IsValid() || val.Interface() == reflect.Zero(val.Type()).Interface()
+// isEmptyKey returns true if the key attribute av, which is
+// marshaled from val, is missing or empty. Zero numbers are
+// only empty if val is a number and not a pointer or a type
+// with a custom marshaler. Attributes that can't be keys,
+// eg. lists and maps, are also considered empty.
+func isEmptyKey(val reflect.Value, av *db.AttributeValue) bool {
+	switch {
+	case av == nil || aws.BoolValue(av.NULL):
+		return true
+	case av.S != nil:
+		return *av.S == ""
+	case av.B != nil:
+		return len(av.B) == 0
+	case av.N != nil:
+		return val.IsValid() &&
+			isNumberKind(val.Kind()) &&
+			!implements(val.Type(), marshalerType) &&
+			val.IsZero()
+	}
+
+	return true
+}
+
+func isNumberKind(k reflect.Kind) bool {
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16,
+		reflect.Int32, reflect.Int64, reflect.Uint,
+		reflect.Uint8, reflect.Uint16, reflect.Uint32,
+		reflect.Uint64, reflect.Float32, reflect.Float64:
+		return true
+	}
+
+	return false
 }
 
 func valueByName(val reflect.Value, name string) (reflect.Value, error) {
@@ -282,6 +421,18 @@ func checkSliceType(item interface{}, types ...interface{}) error {
 	return fmt.Errorf("dynami: invalid type (%v)", reflect.TypeOf(item))
 }
 
+// awsErrCode returns the error code of an AWS
+// error. This returns an empty string if err is
+// not and doesn't wrap an AWS error.
+func awsErrCode(err error) string {
+	var aerr awserr.Error
+	if errors.As(err, &aerr) {
+		return aerr.Code()
+	}
+
+	return ""
+}
+
 func max(a, b int) int {
 	if a > b {
 		return a